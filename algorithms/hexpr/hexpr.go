@@ -0,0 +1,649 @@
+// Package hexpr parses and evaluates arithmetic and boolean expressions. Expressions are converted
+// from infix notation into Reverse Polish Notation (RPN) with the shunting-yard algorithm and then
+// evaluated with a stack. The operators, their precedence and associativity, and the available
+// functions can all be configured.
+package hexpr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+	"github.com/snhilde/dsa/data_structures/hstack"
+)
+
+// This is the standard error message when trying to use an invalid parser.
+var errBadParser = fmt.Errorf("parser must be created with New() first")
+
+// Operator describes an operator that can be used in expressions. An operator is either binary, like
+// the "+" in "a + b", or unary, like the "-" in "-a". Exactly one of Binary and Unary must be set.
+// Unary operators always come before their operand. The same symbol can be used for both a binary
+// and a unary operator, and the parser decides which is meant by where the symbol appears.
+type Operator struct {
+	// Symbol is the text of the operator, such as "+" or "&&". It can also be a word, such as "and".
+	Symbol string
+	// Operators with a higher precedence are applied before operators with a lower precedence.
+	Precedence int
+	// RightAssoc makes a chain of operators with the same precedence group from the right, so that
+	// "a ^ b ^ c" is the same as "a ^ (b ^ c)". Otherwise, they group from the left.
+	RightAssoc bool
+	// Binary applies the operator to its left and right operands.
+	Binary func(left, right interface{}) (interface{}, error)
+	// Unary applies the operator to its single operand.
+	Unary func(operand interface{}) (interface{}, error)
+}
+
+// Function is a function that can be called in expressions, such as "max(a, b)".
+type Function func(args ...interface{}) (interface{}, error)
+
+// Parser holds the operators and functions used to compile expressions.
+type Parser struct {
+	binary map[string]Operator
+	unary  map[string]Operator
+	funcs  map[string]function
+
+	// symbols holds the symbols of all operators that are not words, longest first. When reading an
+	// expression, the longest symbol that matches is used, so that "<=" isn't read as "<" and "=".
+	symbols []string
+}
+
+// function is an internal type for a function and the number of arguments it takes.
+type function struct {
+	fn    Function
+	arity int
+}
+
+// Expression is a compiled expression. It can be evaluated many times with different variables.
+type Expression struct {
+	rpn *hqueue.Queue
+}
+
+// tokenKind is the kind of a single token in an expression.
+type tokenKind int
+
+const (
+	tokValue tokenKind = iota
+	tokIdent
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+
+	// These are only determined while parsing.
+	tokBinary
+	tokUnary
+	tokFunc
+)
+
+// token is an internal type for a single token in an expression.
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value interface{}
+
+	// These are filled in while parsing, depending on the kind of token.
+	op    Operator
+	fn    function
+	nargs int
+}
+
+// New creates a new parser with the default operators. From lowest to highest precedence, these are
+// "||", then "&&", then "==" and "!=", then "<", "<=", ">", and ">=", then "+" and "-", then "*", "/",
+// and "%", then the unary "-", "+", and "!", and finally the right-associative "^". Numbers are
+// evaluated as float64. The "+" operator also joins strings, and the comparison operators also
+// compare strings. There are no default functions.
+func New() *Parser {
+	p := new(Parser)
+	p.binary = make(map[string]Operator)
+	p.unary = make(map[string]Operator)
+	p.funcs = make(map[string]function)
+
+	for _, op := range defaultOperators() {
+		p.AddOperator(op)
+	}
+
+	return p
+}
+
+// AddOperator adds an operator to the parser. If an operator of the same kind with the same symbol
+// already exists, then it is replaced.
+func (p *Parser) AddOperator(op Operator) error {
+	if p == nil || p.binary == nil {
+		return errBadParser
+	} else if (op.Binary == nil) == (op.Unary == nil) {
+		return fmt.Errorf("operator must be either binary or unary")
+	} else if !validSymbol(op.Symbol) {
+		return fmt.Errorf("invalid operator symbol %q", op.Symbol)
+	}
+
+	if op.Binary != nil {
+		p.binary[op.Symbol] = op
+	} else {
+		p.unary[op.Symbol] = op
+	}
+	p.buildSymbols()
+
+	return nil
+}
+
+// RemoveOperator removes the binary or unary operator with the symbol from the parser.
+func (p *Parser) RemoveOperator(symbol string, unary bool) error {
+	if p == nil || p.binary == nil {
+		return errBadParser
+	}
+
+	ops := p.binary
+	if unary {
+		ops = p.unary
+	}
+	if _, ok := ops[symbol]; !ok {
+		return fmt.Errorf("unknown operator %q", symbol)
+	}
+	delete(ops, symbol)
+	p.buildSymbols()
+
+	return nil
+}
+
+// AddFunction adds a function to the parser that takes the given number of arguments. If arity is
+// negative, then the function takes any number of arguments. If a function with the same name already
+// exists, then it is replaced.
+func (p *Parser) AddFunction(name string, arity int, fn Function) error {
+	if p == nil || p.binary == nil {
+		return errBadParser
+	} else if fn == nil {
+		return fmt.Errorf("missing function callback")
+	} else if !validIdent(name) {
+		return fmt.Errorf("invalid function name %q", name)
+	}
+
+	p.funcs[name] = function{fn: fn, arity: arity}
+
+	return nil
+}
+
+// Compile parses the expression and converts it into a form that can be evaluated. Operators and
+// functions are looked up now, so changing the parser afterward does not affect the expression.
+// Variables are looked up when the expression is evaluated.
+func (p *Parser) Compile(expr string) (*Expression, error) {
+	if p == nil || p.binary == nil {
+		return nil, errBadParser
+	}
+
+	tokens, err := p.tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	rpn, err := p.shunt(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Expression{rpn: rpn}, nil
+}
+
+// Eval compiles and evaluates the expression with the variables.
+func (p *Parser) Eval(expr string, vars map[string]interface{}) (interface{}, error) {
+	e, err := p.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Eval(vars)
+}
+
+// Eval evaluates the expression with the variables.
+func (e *Expression) Eval(vars map[string]interface{}) (interface{}, error) {
+	if e == nil {
+		return nil, fmt.Errorf("expression must be created with Compile() first")
+	}
+
+	rpn, err := e.rpn.Copy()
+	if err != nil {
+		return nil, err
+	}
+
+	stack := hstack.New()
+	for rpn.Count() > 0 {
+		tok := rpn.Pop().(token)
+
+		var v interface{}
+		switch tok.kind {
+		case tokValue:
+			v = tok.value
+		case tokIdent:
+			var ok bool
+			if v, ok = vars[tok.text]; !ok {
+				return nil, fmt.Errorf("unknown variable %q at position %d", tok.text, tok.pos)
+			}
+		case tokUnary:
+			operand, _ := stack.TryPop()
+			v, err = tok.op.Unary(operand)
+		case tokBinary:
+			right, _ := stack.TryPop()
+			left, _ := stack.TryPop()
+			v, err = tok.op.Binary(left, right)
+		case tokFunc:
+			// The arguments come off the stack in reverse order.
+			args := make([]interface{}, tok.nargs)
+			for i := len(args) - 1; i >= 0; i-- {
+				args[i], _ = stack.TryPop()
+			}
+			v, err = tok.fn.fn(args...)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s at position %d: %w", tok.text, tok.pos, err)
+		}
+		stack.Add(v)
+	}
+
+	// The parser makes sure that there is always exactly one value left.
+	return stack.Pop(), nil
+}
+
+// String returns the expression in Reverse Polish Notation, with the tokens separated by spaces.
+// Unary operators are shown with a "u" prefix, and functions are shown with their number of
+// arguments, such as "max/2".
+func (e *Expression) String() string {
+	if e == nil {
+		return "<nil>"
+	}
+
+	rpn, _ := e.rpn.Copy()
+	builder := new(strings.Builder)
+	for rpn.Count() > 0 {
+		if builder.Len() > 0 {
+			builder.WriteString(" ")
+		}
+
+		tok := rpn.Pop().(token)
+		switch tok.kind {
+		case tokUnary:
+			builder.WriteString("u" + tok.text)
+		case tokFunc:
+			builder.WriteString(fmt.Sprintf("%s/%d", tok.text, tok.nargs))
+		default:
+			builder.WriteString(tok.text)
+		}
+	}
+
+	return builder.String()
+}
+
+// shunt converts the tokens from infix notation into Reverse Polish Notation using the shunting-yard
+// algorithm. Operators and functions wait on a stack until everything that they apply to has been
+// added to the output queue.
+func (p *Parser) shunt(tokens []token) (*hqueue.Queue, error) {
+	output := hqueue.New()
+	ops := hstack.New()
+
+	// This keeps track of the number of arguments seen so far for each function call that is in
+	// progress.
+	args := hstack.New()
+
+	// When this is true, the next token should be a value, a unary operator, or an opening
+	// parenthesis. Otherwise, it should be a binary operator, a comma, or a closing parenthesis.
+	expectValue := true
+
+	for i, tok := range tokens {
+		switch tok.kind {
+		case tokValue, tokIdent:
+			if !expectValue {
+				return nil, unexpected(tok)
+			}
+			if tok.kind == tokIdent && i+1 < len(tokens) && tokens[i+1].kind == tokLParen {
+				// This is a function call.
+				fn, ok := p.funcs[tok.text]
+				if !ok {
+					return nil, fmt.Errorf("unknown function %q at position %d", tok.text, tok.pos)
+				}
+				tok.kind = tokFunc
+				tok.fn = fn
+				ops.Add(tok)
+				continue
+			}
+			output.Add(tok)
+			expectValue = false
+
+		case tokOperator:
+			if expectValue {
+				op, ok := p.unary[tok.text]
+				if !ok {
+					return nil, unexpected(tok)
+				}
+				tok.kind = tokUnary
+				tok.op = op
+				ops.Add(tok)
+				continue
+			}
+			op, ok := p.binary[tok.text]
+			if !ok {
+				return nil, unexpected(tok)
+			}
+			tok.kind = tokBinary
+			tok.op = op
+			popOperators(ops, output, op)
+			ops.Add(tok)
+			expectValue = true
+
+		case tokLParen:
+			if !expectValue {
+				return nil, unexpected(tok)
+			}
+			if top, err := ops.Peek(); err == nil && top.(token).kind == tokFunc {
+				args.Add(0)
+			}
+			ops.Add(tok)
+
+		case tokComma:
+			if expectValue {
+				return nil, unexpected(tok)
+			}
+			if err := popToParen(ops, output); err != nil {
+				return nil, unexpected(tok)
+			}
+			if !isCall(ops) {
+				return nil, unexpected(tok)
+			}
+			args.Add(args.Pop().(int) + 1)
+			expectValue = true
+
+		case tokRParen:
+			emptyCall := i > 0 && tokens[i-1].kind == tokLParen
+			if expectValue && !emptyCall {
+				return nil, unexpected(tok)
+			}
+			if err := popToParen(ops, output); err != nil {
+				return nil, fmt.Errorf("unmatched ')' at position %d", tok.pos)
+			}
+			call := isCall(ops)
+			ops.Pop()
+			if call {
+				if err := p.finishCall(ops, output, args, emptyCall); err != nil {
+					return nil, err
+				}
+			} else if emptyCall {
+				return nil, unexpected(tok)
+			}
+			expectValue = false
+		}
+	}
+
+	if expectValue {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	// Move all remaining operators to the output.
+	for ops.Count() > 0 {
+		tok := ops.Pop().(token)
+		if tok.kind == tokLParen {
+			return nil, fmt.Errorf("unmatched '(' at position %d", tok.pos)
+		}
+		output.Add(tok)
+	}
+
+	return output, nil
+}
+
+// finishCall moves a function from the top of the operator stack to the output once all of its
+// arguments have been read.
+func (p *Parser) finishCall(ops *hstack.Stack, output *hqueue.Queue, args *hstack.Stack, empty bool) error {
+	tok := ops.Pop().(token)
+	tok.nargs = args.Pop().(int)
+	if !empty {
+		// The last argument isn't followed by a comma.
+		tok.nargs++
+	}
+
+	if tok.fn.arity >= 0 && tok.nargs != tok.fn.arity {
+		return fmt.Errorf("function %q at position %d takes %d arguments, not %d", tok.text, tok.pos,
+			tok.fn.arity, tok.nargs)
+	}
+	output.Add(tok)
+
+	return nil
+}
+
+// popOperators moves operators from the stack to the output for as long as they should be applied
+// before the new operator.
+func popOperators(ops *hstack.Stack, output *hqueue.Queue, op Operator) {
+	for {
+		v, err := ops.Peek()
+		if err != nil {
+			return
+		}
+
+		top := v.(token)
+		if top.kind != tokBinary && top.kind != tokUnary {
+			return
+		}
+		if top.op.Precedence < op.Precedence || (top.op.Precedence == op.Precedence && op.RightAssoc) {
+			return
+		}
+		output.Add(ops.Pop())
+	}
+}
+
+// popToParen moves operators from the stack to the output until it reaches an opening parenthesis,
+// which is left on the stack. This returns an error if there isn't one.
+func popToParen(ops *hstack.Stack, output *hqueue.Queue) error {
+	for {
+		v, err := ops.Peek()
+		if err != nil {
+			return err
+		}
+		if v.(token).kind == tokLParen {
+			return nil
+		}
+		output.Add(ops.Pop())
+	}
+}
+
+// isCall checks whether the opening parenthesis at the top of the stack belongs to a function call.
+func isCall(ops *hstack.Stack) bool {
+	v, err := ops.PeekN(2)
+	if err != nil {
+		return false
+	}
+
+	return v[1].(token).kind == tokFunc
+}
+
+// unexpected builds the error for a token that appears where it isn't allowed.
+func unexpected(tok token) error {
+	return fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+// tokenize splits the expression into tokens.
+func (p *Parser) tokenize(expr string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+			continue
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+			continue
+		case c == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+			continue
+		}
+
+		tok, n, err := p.readToken(expr, i)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		i += n
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	return tokens, nil
+}
+
+// readToken reads a number, string, word, or operator symbol starting at position i. It returns the
+// token and the number of bytes that it takes up.
+func (p *Parser) readToken(expr string, i int) (token, int, error) {
+	c := expr[i]
+	switch {
+	case isDigit(c) || (c == '.' && i+1 < len(expr) && isDigit(expr[i+1])):
+		return readNumber(expr, i)
+	case c == '"':
+		return readString(expr, i)
+	case isLetter(c):
+		n := 1
+		for i+n < len(expr) && (isLetter(expr[i+n]) || isDigit(expr[i+n]) || expr[i+n] == '.') {
+			n++
+		}
+		word := expr[i : i+n]
+
+		tok := token{kind: tokIdent, text: word, pos: i}
+		if _, ok := p.binary[word]; ok {
+			tok.kind = tokOperator
+		} else if _, ok := p.unary[word]; ok {
+			tok.kind = tokOperator
+		} else if word == "true" || word == "false" {
+			tok.kind = tokValue
+			tok.value = word == "true"
+		}
+		return tok, n, nil
+	}
+
+	for _, symbol := range p.symbols {
+		if strings.HasPrefix(expr[i:], symbol) {
+			return token{kind: tokOperator, text: symbol, pos: i}, len(symbol), nil
+		}
+	}
+
+	return token{}, 0, fmt.Errorf("unexpected %q at position %d", c, i)
+}
+
+// readNumber reads a number starting at position i.
+func readNumber(expr string, i int) (token, int, error) {
+	n := 0
+	for i+n < len(expr) && (isDigit(expr[i+n]) || expr[i+n] == '.') {
+		n++
+	}
+
+	// Check for an exponent.
+	if i+n < len(expr) && (expr[i+n] == 'e' || expr[i+n] == 'E') {
+		m := n + 1
+		if i+m < len(expr) && (expr[i+m] == '+' || expr[i+m] == '-') {
+			m++
+		}
+		if i+m < len(expr) && isDigit(expr[i+m]) {
+			for i+m < len(expr) && isDigit(expr[i+m]) {
+				m++
+			}
+			n = m
+		}
+	}
+
+	text := expr[i : i+n]
+	v, err := parseFloat(text)
+	if err != nil {
+		return token{}, 0, fmt.Errorf("invalid number %q at position %d", text, i)
+	}
+
+	return token{kind: tokValue, text: text, pos: i, value: v}, n, nil
+}
+
+// readString reads a double-quoted string starting at position i. The string can contain the same
+// escape sequences as a Go string.
+func readString(expr string, i int) (token, int, error) {
+	for n := 1; i+n < len(expr); n++ {
+		switch expr[i+n] {
+		case '\\':
+			// Skip the escaped character.
+			n++
+		case '"':
+			text := expr[i : i+n+1]
+			v, err := unquote(text)
+			if err != nil {
+				return token{}, 0, fmt.Errorf("invalid string %s at position %d", text, i)
+			}
+			return token{kind: tokValue, text: text, pos: i, value: v}, n + 1, nil
+		}
+	}
+
+	return token{}, 0, fmt.Errorf("unterminated string at position %d", i)
+}
+
+// buildSymbols rebuilds the list of operator symbols that are not words.
+func (p *Parser) buildSymbols() {
+	seen := make(map[string]bool)
+	p.symbols = p.symbols[:0]
+	for _, ops := range []map[string]Operator{p.binary, p.unary} {
+		for symbol := range ops {
+			if !seen[symbol] && !isLetter(symbol[0]) {
+				seen[symbol] = true
+				p.symbols = append(p.symbols, symbol)
+			}
+		}
+	}
+
+	// Sort the longest symbols first, and then alphabetically to keep the order stable.
+	sort.Slice(p.symbols, func(i, j int) bool {
+		if len(p.symbols[i]) != len(p.symbols[j]) {
+			return len(p.symbols[i]) > len(p.symbols[j])
+		}
+		return p.symbols[i] < p.symbols[j]
+	})
+}
+
+// validSymbol checks whether the symbol can be used for an operator. It must either be a word or be
+// made up only of punctuation that doesn't have another meaning in expressions.
+func validSymbol(symbol string) bool {
+	if symbol == "" {
+		return false
+	} else if isLetter(symbol[0]) {
+		return validIdent(symbol)
+	}
+
+	for i := 0; i < len(symbol); i++ {
+		c := symbol[i]
+		if c <= ' ' || c > '~' || isLetter(c) || isDigit(c) || strings.IndexByte(`()",.`, c) >= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validIdent checks whether the name can be used for a variable, function, or word operator.
+func validIdent(name string) bool {
+	if name == "" || !isLetter(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isLetter(name[i]) && !isDigit(name[i]) && name[i] != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
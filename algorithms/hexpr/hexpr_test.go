@@ -0,0 +1,427 @@
+package hexpr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/snhilde/dsa/algorithms/hexpr"
+)
+
+func TestBadPtr(t *testing.T) {
+	var p *hexpr.Parser
+
+	if err := p.AddOperator(hexpr.Operator{Symbol: "+", Unary: neg}); err == nil {
+		t.Error("unexpectedly passed AddOperator() test with bad pointer")
+	}
+	if err := p.RemoveOperator("+", false); err == nil {
+		t.Error("unexpectedly passed RemoveOperator() test with bad pointer")
+	}
+	if err := p.AddFunction("f", 1, maxFunc); err == nil {
+		t.Error("unexpectedly passed AddFunction() test with bad pointer")
+	}
+	if _, err := p.Compile("1"); err == nil {
+		t.Error("unexpectedly passed Compile() test with bad pointer")
+	}
+	if _, err := p.Eval("1", nil); err == nil {
+		t.Error("unexpectedly passed Eval() test with bad pointer")
+	}
+
+	var e *hexpr.Expression
+	if _, err := e.Eval(nil); err == nil {
+		t.Error("unexpectedly passed Expression.Eval() test with bad pointer")
+	}
+	if s := e.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", s)
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	// A parser that wasn't created with New has nowhere to keep its operators and functions.
+	var p hexpr.Parser
+
+	if err := p.AddOperator(hexpr.Operator{Symbol: "+", Unary: neg}); err == nil {
+		t.Error("unexpectedly passed AddOperator() test with zero-value parser")
+	}
+	if err := p.RemoveOperator("+", false); err == nil {
+		t.Error("unexpectedly passed RemoveOperator() test with zero-value parser")
+	}
+	if err := p.AddFunction("f", 1, maxFunc); err == nil {
+		t.Error("unexpectedly passed AddFunction() test with zero-value parser")
+	}
+	if _, err := p.Compile("1"); err == nil {
+		t.Error("unexpectedly passed Compile() test with zero-value parser")
+	}
+}
+
+func TestBadArgs(t *testing.T) {
+	p := hexpr.New()
+
+	// Operators must be exactly one of binary or unary.
+	if err := p.AddOperator(hexpr.Operator{Symbol: "#"}); err == nil {
+		t.Error("unexpectedly passed AddOperator() test with no callback")
+	}
+	if err := p.AddOperator(hexpr.Operator{Symbol: "#", Unary: neg, Binary: sub}); err == nil {
+		t.Error("unexpectedly passed AddOperator() test with both callbacks")
+	}
+
+	// Operator symbols can't clash with the rest of the syntax.
+	for _, symbol := range []string{"", "(", "a+", "1", "+,", "# #", "\"", "."} {
+		if err := p.AddOperator(hexpr.Operator{Symbol: symbol, Binary: sub}); err == nil {
+			t.Errorf("unexpectedly passed AddOperator() test with symbol %q", symbol)
+		}
+	}
+
+	if err := p.RemoveOperator("#", false); err == nil {
+		t.Error("unexpectedly passed RemoveOperator() test with unknown operator")
+	}
+	if err := p.AddFunction("f", 1, nil); err == nil {
+		t.Error("unexpectedly passed AddFunction() test with missing callback")
+	}
+	if err := p.AddFunction("1f", 1, maxFunc); err == nil {
+		t.Error("unexpectedly passed AddFunction() test with invalid name")
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2", 3},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"100 / 10 / 5", 2},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"2 ^ -1", 0.5},
+		{"-(3 + 4) * 2", -14},
+		{"--5", 5},
+		{"+5 - -5", 10},
+		{"17 % 5", 2},
+		{"1.5e2 + .5", 150.5},
+		{"((((7))))", 7},
+	}
+
+	p := hexpr.New()
+	for _, test := range tests {
+		v, err := p.Eval(test.expr, nil)
+		if err != nil {
+			t.Error(test.expr, err)
+			continue
+		}
+		if v != test.want {
+			t.Error("Incorrect result for", test.expr)
+			t.Log("\tExpected:", test.want)
+			t.Log("\tReceived:", v)
+		}
+	}
+}
+
+func TestBoolean(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"true", true},
+		{"!true", false},
+		{"1 < 2", true},
+		{"2 <= 2", true},
+		{"3 > 4", false},
+		{"3 >= 4", false},
+		{"1 + 1 == 2", true},
+		{"1 != 1", false},
+		{"true && false", false},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!(1 > 2) && 2 > 1", true},
+		{"\"apple\" < \"banana\"", true},
+		{"\"a\" + \"b\" == \"ab\"", true},
+		{"\"a\" == 1", false},
+	}
+
+	p := hexpr.New()
+	for _, test := range tests {
+		v, err := p.Eval(test.expr, nil)
+		if err != nil {
+			t.Error(test.expr, err)
+			continue
+		}
+		if v != test.want {
+			t.Error("Incorrect result for", test.expr)
+			t.Log("\tExpected:", test.want)
+			t.Log("\tReceived:", v)
+		}
+	}
+}
+
+func TestRPN(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"1 + 2 * 3", "1 2 3 * +"},
+		{"(1 + 2) * 3", "1 2 + 3 *"},
+		{"a - b - c", "a b - c -"},
+		{"a ^ b ^ c", "a b c ^ ^"},
+		{"-a ^ 2", "a 2 ^ u-"},
+		{"max(a, b + 1) * 2", "a b 1 + max/2 2 *"},
+		{"max(min(1, 2), 3)", "1 2 min/2 3 max/2"},
+		{"a > 1 && !b", "a 1 > b u! &&"},
+	}
+
+	p := newParser(t)
+	for _, test := range tests {
+		e, err := p.Compile(test.expr)
+		if err != nil {
+			t.Error(test.expr, err)
+			continue
+		}
+		if s := e.String(); s != test.want {
+			t.Error("Incorrect RPN for", test.expr)
+			t.Log("\tExpected:", test.want)
+			t.Log("\tReceived:", s)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	p := hexpr.New()
+
+	e, err := p.Compile("user.age >= 18 && country == \"NZ\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same expression should be reusable with different variables.
+	tests := []struct {
+		vars map[string]interface{}
+		want bool
+	}{
+		{map[string]interface{}{"user.age": 30, "country": "NZ"}, true},
+		{map[string]interface{}{"user.age": int64(12), "country": "NZ"}, false},
+		{map[string]interface{}{"user.age": 18.0, "country": "AU"}, false},
+	}
+	for i, test := range tests {
+		v, err := e.Eval(test.vars)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if v != test.want {
+			t.Error("Incorrect result for test", i)
+			t.Log("\tExpected:", test.want)
+			t.Log("\tReceived:", v)
+		}
+	}
+
+	// A missing variable should be reported when evaluating.
+	if _, err := e.Eval(map[string]interface{}{"country": "NZ"}); err == nil {
+		t.Error("unexpectedly passed Eval() test with missing variable")
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	p := newParser(t)
+
+	// Test an empty argument list.
+	p.AddFunction("pi", 0, func(args ...interface{}) (interface{}, error) {
+		return 3.0, nil
+	})
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"max(1, 5, 3)", 5},
+		{"max(4)", 4},
+		{"min(max(1, 2), 3) + 1", 3},
+		{"pi() * 2", 6},
+		{"max(-1, -(2), x * 2)", 8},
+	}
+	for _, test := range tests {
+		v, err := p.Eval(test.expr, map[string]interface{}{"x": 4})
+		if err != nil {
+			t.Error(test.expr, err)
+			continue
+		}
+		if v != test.want {
+			t.Error("Incorrect result for", test.expr)
+			t.Log("\tExpected:", test.want)
+			t.Log("\tReceived:", v)
+		}
+	}
+
+	// Errors from functions should be passed back.
+	if _, err := p.Eval("max()", nil); !errors.Is(err, errNoArgs) {
+		t.Error("Function error was not passed back")
+		t.Log("\tExpected:", errNoArgs)
+		t.Log("\tReceived:", err)
+	}
+}
+
+func TestCustomOperators(t *testing.T) {
+	p := hexpr.New()
+
+	// Add word operators.
+	and, _ := p.Compile("true && false")
+	if err := p.AddOperator(hexpr.Operator{Symbol: "and", Precedence: 20, Binary: both}); err != nil {
+		t.Error(err)
+	}
+	if err := p.AddOperator(hexpr.Operator{Symbol: "not", Precedence: 70, Unary: not}); err != nil {
+		t.Error(err)
+	}
+	checkEval(t, p, "not false and 2 > 1", true)
+
+	// Make subtraction right-associative.
+	if err := p.AddOperator(hexpr.Operator{Symbol: "-", Precedence: 50, RightAssoc: true, Binary: sub}); err != nil {
+		t.Error(err)
+	}
+	checkEval(t, p, "10 - 4 - 3", 9.0)
+
+	// Give addition a higher precedence than multiplication.
+	p.AddOperator(hexpr.Operator{Symbol: "+", Precedence: 65, Binary: func(a, b interface{}) (interface{}, error) {
+		x, _ := hexpr.ToFloat(a)
+		y, _ := hexpr.ToFloat(b)
+		return x + y, nil
+	}})
+	checkEval(t, p, "2 * 3 + 4", 14.0)
+
+	// Add a longer symbol that starts the same as an existing one.
+	p.AddOperator(hexpr.Operator{Symbol: "**", Precedence: 80, RightAssoc: true, Binary: func(a, b interface{}) (interface{}, error) {
+		return fmt.Sprintf("(%v**%v)", a, b), nil
+	}})
+	checkEval(t, p, "1 ** 2 ** 3", "(1**(2**3))")
+
+	// Removing an operator should make it invalid.
+	if err := p.RemoveOperator("*", false); err != nil {
+		t.Error(err)
+	}
+	if _, err := p.Eval("2 * 3", nil); err == nil {
+		t.Error("unexpectedly passed Eval() test with removed operator")
+	}
+	checkEval(t, p, "2 ** 3", "(2**3)")
+
+	// Expressions compiled before the changes should not be affected.
+	if v, err := and.Eval(nil); v != false || err != nil {
+		t.Error("Earlier expression was affected by new operators")
+		t.Log("\tReceived:", v, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	bad := []string{
+		"",
+		"   ",
+		"1 +",
+		"* 2",
+		"1 2",
+		"(1 + 2",
+		"1 + 2)",
+		"()",
+		"1 (2)",
+		"max(1,)",
+		"max(,1)",
+		"(1, 2)",
+		"unknown(1)",
+		"min(1)",
+		"1 $ 2",
+		"1..2",
+		"\"unterminated",
+		"x x",
+	}
+
+	p := newParser(t)
+	for _, expr := range bad {
+		if _, err := p.Compile(expr); err == nil {
+			t.Errorf("unexpectedly compiled %q", expr)
+		}
+	}
+
+	// These compile but fail when evaluated.
+	bad = []string{
+		"1 / 0",
+		"5 % 0",
+		"1 + true",
+		"!1",
+		"-\"a\"",
+		"true && 1",
+		"1 < \"a\"",
+	}
+	for _, expr := range bad {
+		if _, err := p.Eval(expr, nil); err == nil {
+			t.Errorf("unexpectedly evaluated %q", expr)
+		}
+	}
+}
+
+var errNoArgs = fmt.Errorf("no arguments")
+
+func newParser(t *testing.T) *hexpr.Parser {
+	p := hexpr.New()
+	if err := p.AddFunction("max", -1, maxFunc); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddFunction("min", 2, func(args ...interface{}) (interface{}, error) {
+		a, _ := hexpr.ToFloat(args[0])
+		b, _ := hexpr.ToFloat(args[1])
+		if a < b {
+			return a, nil
+		}
+		return b, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func maxFunc(args ...interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errNoArgs
+	}
+
+	max, _ := hexpr.ToFloat(args[0])
+	for _, v := range args[1:] {
+		if n, _ := hexpr.ToFloat(v); n > max {
+			max = n
+		}
+	}
+
+	return max, nil
+}
+
+func neg(v interface{}) (interface{}, error) {
+	n, _ := hexpr.ToFloat(v)
+	return -n, nil
+}
+
+func not(v interface{}) (interface{}, error) {
+	return !v.(bool), nil
+}
+
+func sub(a, b interface{}) (interface{}, error) {
+	x, _ := hexpr.ToFloat(a)
+	y, _ := hexpr.ToFloat(b)
+	return x - y, nil
+}
+
+func both(a, b interface{}) (interface{}, error) {
+	return a.(bool) && b.(bool), nil
+}
+
+func checkEval(t *testing.T, p *hexpr.Parser, expr string, want interface{}) {
+	v, err := p.Eval(expr, nil)
+	if err != nil {
+		t.Error(expr, err)
+		return
+	}
+	if v != want {
+		t.Error("Incorrect result for", expr)
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", v)
+	}
+}
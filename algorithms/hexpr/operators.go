@@ -0,0 +1,214 @@
+package hexpr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// These are the precedences of the default operators.
+const (
+	precOr = (iota + 1) * 10
+	precAnd
+	precEqual
+	precCompare
+	precAdd
+	precMultiply
+	precUnary
+	precPower
+)
+
+// defaultOperators builds the operators that every new parser starts with.
+func defaultOperators() []Operator {
+	return []Operator{
+		{Symbol: "||", Precedence: precOr, Binary: logic(func(a, b bool) bool { return a || b })},
+		{Symbol: "&&", Precedence: precAnd, Binary: logic(func(a, b bool) bool { return a && b })},
+
+		{Symbol: "==", Precedence: precEqual, Binary: func(a, b interface{}) (interface{}, error) {
+			return equal(a, b), nil
+		}},
+		{Symbol: "!=", Precedence: precEqual, Binary: func(a, b interface{}) (interface{}, error) {
+			return !equal(a, b), nil
+		}},
+
+		{Symbol: "<", Precedence: precCompare, Binary: compare(func(c int) bool { return c < 0 })},
+		{Symbol: "<=", Precedence: precCompare, Binary: compare(func(c int) bool { return c <= 0 })},
+		{Symbol: ">", Precedence: precCompare, Binary: compare(func(c int) bool { return c > 0 })},
+		{Symbol: ">=", Precedence: precCompare, Binary: compare(func(c int) bool { return c >= 0 })},
+
+		{Symbol: "+", Precedence: precAdd, Binary: add},
+		{Symbol: "-", Precedence: precAdd, Binary: arithmetic(func(a, b float64) (float64, error) {
+			return a - b, nil
+		})},
+
+		{Symbol: "*", Precedence: precMultiply, Binary: arithmetic(func(a, b float64) (float64, error) {
+			return a * b, nil
+		})},
+		{Symbol: "/", Precedence: precMultiply, Binary: arithmetic(func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return a / b, nil
+		})},
+		{Symbol: "%", Precedence: precMultiply, Binary: arithmetic(func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return math.Mod(a, b), nil
+		})},
+
+		{Symbol: "-", Precedence: precUnary, RightAssoc: true, Unary: func(v interface{}) (interface{}, error) {
+			n, ok := ToFloat(v)
+			if !ok {
+				return nil, fmt.Errorf("operand must be a number")
+			}
+			return -n, nil
+		}},
+		{Symbol: "+", Precedence: precUnary, RightAssoc: true, Unary: func(v interface{}) (interface{}, error) {
+			n, ok := ToFloat(v)
+			if !ok {
+				return nil, fmt.Errorf("operand must be a number")
+			}
+			return n, nil
+		}},
+		{Symbol: "!", Precedence: precUnary, RightAssoc: true, Unary: func(v interface{}) (interface{}, error) {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operand must be a boolean")
+			}
+			return !b, nil
+		}},
+
+		{Symbol: "^", Precedence: precPower, RightAssoc: true, Binary: arithmetic(func(a, b float64) (float64, error) {
+			return math.Pow(a, b), nil
+		})},
+	}
+}
+
+// ToFloat converts any of Go's numeric types to a float64. The second return value is false if v is
+// not a number. This is useful for writing custom operators and functions.
+func ToFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+
+	return 0, false
+}
+
+// arithmetic builds a binary operator that works on two numbers.
+func arithmetic(fn func(a, b float64) (float64, error)) func(interface{}, interface{}) (interface{}, error) {
+	return func(left, right interface{}) (interface{}, error) {
+		a, ok1 := ToFloat(left)
+		b, ok2 := ToFloat(right)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("operands must be numbers")
+		}
+
+		return fn(a, b)
+	}
+}
+
+// add adds two numbers or joins two strings.
+func add(left, right interface{}) (interface{}, error) {
+	if a, ok := left.(string); ok {
+		if b, ok := right.(string); ok {
+			return a + b, nil
+		}
+	}
+
+	return arithmetic(func(a, b float64) (float64, error) {
+		return a + b, nil
+	})(left, right)
+}
+
+// logic builds a binary operator that works on two booleans.
+func logic(fn func(a, b bool) bool) func(interface{}, interface{}) (interface{}, error) {
+	return func(left, right interface{}) (interface{}, error) {
+		a, ok1 := left.(bool)
+		b, ok2 := right.(bool)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("operands must be booleans")
+		}
+
+		return fn(a, b), nil
+	}
+}
+
+// compare builds a binary operator that orders two numbers or two strings. The function receives -1,
+// 0, or 1 depending on whether the left operand is less than, equal to, or greater than the right.
+func compare(fn func(c int) bool) func(interface{}, interface{}) (interface{}, error) {
+	return func(left, right interface{}) (interface{}, error) {
+		c := 0
+		if a, ok := left.(string); ok {
+			b, ok := right.(string)
+			if !ok {
+				return nil, fmt.Errorf("operands must both be numbers or both be strings")
+			}
+			if a < b {
+				c = -1
+			} else if a > b {
+				c = 1
+			}
+			return fn(c), nil
+		}
+
+		a, ok1 := ToFloat(left)
+		b, ok2 := ToFloat(right)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("operands must both be numbers or both be strings")
+		}
+		if a < b {
+			c = -1
+		} else if a > b {
+			c = 1
+		}
+
+		return fn(c), nil
+	}
+}
+
+// equal checks whether two values are the same. Numbers of different types are equal if they have
+// the same value.
+func equal(a, b interface{}) bool {
+	x, ok1 := ToFloat(a)
+	y, ok2 := ToFloat(b)
+	if ok1 && ok2 {
+		return x == y
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// parseFloat parses a number in an expression.
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+// unquote parses a double-quoted string in an expression.
+func unquote(s string) (string, error) {
+	return strconv.Unquote(s)
+}
@@ -0,0 +1,546 @@
+// Package hcache provides fixed-size key/value caches with least-recently-used (LRU) and
+// least-frequently-used (LFU) eviction and optional expiration.
+package hcache
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+// This is the standard error message when trying to use an invalid cache.
+var errBadCache = fmt.Errorf("cache must be created with NewLRU() or NewLFU() first")
+
+// Clock is the source of the current time for a cache. It only needs to be replaced when the passage
+// of time needs to be controlled, such as in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// Cache is the main type for this package. It holds a limited number of items, and when it is full,
+// adding a new item will evict an existing one according to the cache's policy.
+type Cache struct {
+	items    map[interface{}]*entry
+	policy   policy
+	capacity int
+	ttl      time.Duration
+	clock    Clock
+	onEvict  func(key, value interface{})
+}
+
+// entry is an internal type for an individual item in the cache. Entries are kept in hlist's doubly
+// linked lists in the order that the policy wants to keep them, and each entry holds its own node so
+// that it can be moved in constant time.
+type entry struct {
+	key     interface{}
+	value   interface{}
+	expires time.Time
+	node    *hlist.DoublyNode
+	bucket  *bucket // only used by the LFU policy
+}
+
+// policy decides which entry is evicted when the cache is full.
+type policy interface {
+	// add starts tracking a new entry.
+	add(e *entry)
+	// touch records that an entry was used.
+	touch(e *entry)
+	// remove stops tracking an entry.
+	remove(e *entry)
+	// victim returns the entry that should be evicted next.
+	victim() *entry
+	// each calls fn on every entry, beginning with the one that will be evicted last.
+	each(fn func(e *entry))
+	// clear stops tracking all entries.
+	clear()
+}
+
+// systemClock is the default clock. It reports the actual time.
+type systemClock struct{}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewLRU creates a new cache that holds up to capacity items. When the cache is full, the item that
+// was used least recently is evicted.
+func NewLRU(capacity int) (*Cache, error) {
+	return newCache(capacity, new(lruPolicy))
+}
+
+// NewLFU creates a new cache that holds up to capacity items. When the cache is full, the item that
+// has been used the fewest times is evicted. If multiple items have been used the same number of
+// times, then the one among them that was used least recently is evicted.
+func NewLFU(capacity int) (*Cache, error) {
+	return newCache(capacity, new(lfuPolicy))
+}
+
+// Get gets the value for the key and marks it as used. If the key is not in the cache or its value
+// has expired, then this returns nil and false.
+func (c *Cache) Get(key interface{}) (interface{}, bool) {
+	e := c.lookup(key)
+	if e == nil {
+		return nil, false
+	}
+
+	c.policy.touch(e)
+
+	return e.value, true
+}
+
+// Peek gets the value for the key without marking it as used. If the key is not in the cache or its
+// value has expired, then this returns nil and false.
+func (c *Cache) Peek(key interface{}) (interface{}, bool) {
+	e := c.lookup(key)
+	if e == nil {
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+// Put adds the key and value to the cache and marks it as used. If the key is already in the cache,
+// then its value is replaced and its expiration is reset. If the cache is full, then an item is
+// evicted to make room. Keys must be hashable, as for map keys, which also rules out interface
+// values that hold slices, maps, or functions.
+func (c *Cache) Put(key, value interface{}) error {
+	if c == nil || c.items == nil {
+		return errBadCache
+	}
+
+	if !hashable(reflect.ValueOf(key)) {
+		return fmt.Errorf("key of type %T is not hashable", key)
+	}
+
+	if e, ok := c.items[key]; ok {
+		e.value = value
+		e.expires = c.expiration()
+		c.policy.touch(e)
+		return nil
+	}
+
+	if len(c.items) >= c.capacity {
+		c.evict(c.policy.victim())
+	}
+
+	e := &entry{key: key, value: value, expires: c.expiration()}
+	c.items[key] = e
+	c.policy.add(e)
+
+	return nil
+}
+
+// Remove removes the key and its value from the cache. It returns true if the key was in the cache.
+// The eviction callback is not called for items that are removed this way.
+func (c *Cache) Remove(key interface{}) bool {
+	if c == nil || c.items == nil {
+		return false
+	}
+
+	if !hashable(reflect.ValueOf(key)) {
+		return false
+	}
+
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+
+	c.policy.remove(e)
+	delete(c.items, key)
+
+	return true
+}
+
+// Len gets the number of items in the cache, or -1 if the cache hasn't been created yet. Items that
+// have expired but have not been accessed or purged yet are included in the count.
+func (c *Cache) Len() int {
+	if c == nil || c.items == nil {
+		return -1
+	}
+
+	return len(c.items)
+}
+
+// Cap gets the maximum number of items that the cache can hold, or -1 if the cache hasn't been
+// created yet.
+func (c *Cache) Cap() int {
+	if c == nil || c.items == nil {
+		return -1
+	}
+
+	return c.capacity
+}
+
+// Resize changes the maximum number of items that the cache can hold. If the cache currently holds
+// more items than the new capacity, then items are evicted until it fits.
+func (c *Cache) Resize(capacity int) error {
+	if c == nil || c.items == nil {
+		return errBadCache
+	} else if capacity < 1 {
+		return fmt.Errorf("capacity must be at least 1")
+	}
+
+	c.capacity = capacity
+	for len(c.items) > c.capacity {
+		c.evict(c.policy.victim())
+	}
+
+	return nil
+}
+
+// Keys returns all keys in the cache, beginning with the one that will be evicted last and ending
+// with the one that will be evicted next.
+func (c *Cache) Keys() []interface{} {
+	if c == nil || c.items == nil || len(c.items) == 0 {
+		return nil
+	}
+
+	keys := make([]interface{}, 0, len(c.items))
+	c.policy.each(func(e *entry) {
+		keys = append(keys, e.key)
+	})
+
+	return keys
+}
+
+// Purge removes all items that have expired and returns how many were removed. The eviction callback
+// is called for each one.
+func (c *Cache) Purge() int {
+	if c == nil || c.items == nil {
+		return 0
+	}
+
+	var expired []*entry
+	now := c.clock.Now()
+	c.policy.each(func(e *entry) {
+		if isExpired(e, now) {
+			expired = append(expired, e)
+		}
+	})
+
+	// Evict in the same order that the policy would have.
+	for i := len(expired) - 1; i >= 0; i-- {
+		c.evict(expired[i])
+	}
+
+	return len(expired)
+}
+
+// Clear removes all items from the cache. The capacity and all settings are kept, and the eviction
+// callback is not called.
+func (c *Cache) Clear() error {
+	if c == nil || c.items == nil {
+		return errBadCache
+	}
+
+	c.items = make(map[interface{}]*entry)
+	c.policy.clear()
+
+	return nil
+}
+
+// SetEvictCallback sets a function that will be called with the key and value of every item that
+// is evicted, either because the cache was full or because the item expired. Pass nil to remove the
+// callback.
+func (c *Cache) SetEvictCallback(cb func(key, value interface{})) error {
+	if c == nil || c.items == nil {
+		return errBadCache
+	}
+
+	c.onEvict = cb
+
+	return nil
+}
+
+// SetTTL sets how long items remain valid after they are added or replaced. A ttl of 0 means that
+// items never expire. The new ttl only applies to items that are put in the cache after this call.
+func (c *Cache) SetTTL(ttl time.Duration) error {
+	if c == nil || c.items == nil {
+		return errBadCache
+	} else if ttl < 0 {
+		return fmt.Errorf("ttl cannot be negative")
+	}
+
+	c.ttl = ttl
+
+	return nil
+}
+
+// SetClock sets the clock that the cache uses to determine when items expire.
+func (c *Cache) SetClock(clock Clock) error {
+	if c == nil || c.items == nil {
+		return errBadCache
+	} else if clock == nil {
+		return fmt.Errorf("missing clock")
+	}
+
+	c.clock = clock
+
+	return nil
+}
+
+// String returns a comma-separated list of the key/value pairs in the cache, in the same order as
+// Keys.
+func (c *Cache) String() string {
+	if c == nil || c.items == nil {
+		return "<nil>"
+	} else if len(c.items) == 0 {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	c.policy.each(func(e *entry) {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v: %v", e.key, e.value))
+	})
+
+	return builder.String()
+}
+
+// newCache is an internal convenience function for creating a new cache with the provided policy.
+func newCache(capacity int, p policy) (*Cache, error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("capacity must be at least 1")
+	}
+
+	c := new(Cache)
+	c.items = make(map[interface{}]*entry)
+	c.policy = p
+	c.capacity = capacity
+	c.clock = systemClock{}
+
+	return c, nil
+}
+
+// lookup finds the entry for the key. If the entry has expired, then it is evicted and nil is
+// returned.
+func (c *Cache) lookup(key interface{}) *entry {
+	if c == nil || c.items == nil {
+		return nil
+	}
+
+	if !hashable(reflect.ValueOf(key)) {
+		return nil
+	}
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+
+	if isExpired(e, c.clock.Now()) {
+		c.evict(e)
+		return nil
+	}
+
+	return e
+}
+
+// evict removes the entry from the cache and calls the eviction callback.
+func (c *Cache) evict(e *entry) {
+	if e == nil {
+		return
+	}
+
+	c.policy.remove(e)
+	delete(c.items, e.key)
+
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
+
+// expiration gets the time at which a new or replaced entry will expire, or the zero time if entries
+// do not expire.
+func (c *Cache) expiration() time.Time {
+	if c.ttl == 0 {
+		return time.Time{}
+	}
+
+	return c.clock.Now().Add(c.ttl)
+}
+
+// isExpired checks whether or not the entry is expired at the provided time.
+func isExpired(e *entry, now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// lruPolicy evicts the entry that was used least recently. The front of the list is the most recently
+// used entry.
+type lruPolicy struct {
+	entries hlist.DoublyList
+}
+
+func (p *lruPolicy) add(e *entry) {
+	e.node = p.entries.PushFront(e)
+}
+
+func (p *lruPolicy) touch(e *entry) {
+	p.entries.MoveToFront(e.node)
+}
+
+func (p *lruPolicy) remove(e *entry) {
+	p.entries.Remove(e.node)
+	e.node = nil
+}
+
+func (p *lruPolicy) victim() *entry {
+	return entryOf(p.entries.Back())
+}
+
+func (p *lruPolicy) each(fn func(e *entry)) {
+	eachEntry(&p.entries, fn)
+}
+
+func (p *lruPolicy) clear() {
+	p.entries.Clear()
+}
+
+// lfuPolicy evicts the entry that was used the fewest times. Entries are grouped into buckets by
+// their use count, and the buckets are kept in ascending order so that every operation takes
+// constant time.
+type lfuPolicy struct {
+	buckets hlist.DoublyList
+}
+
+// bucket holds all entries that have been used the same number of times, in least-recently-used
+// order.
+type bucket struct {
+	count   int
+	entries hlist.DoublyList
+	node    *hlist.DoublyNode
+}
+
+func (p *lfuPolicy) add(e *entry) {
+	first := bucketOf(p.buckets.Front())
+	if first == nil || first.count != 1 {
+		first = p.insertAfter(nil, 1)
+	}
+
+	e.node = first.entries.PushFront(e)
+	e.bucket = first
+}
+
+func (p *lfuPolicy) touch(e *entry) {
+	b := e.bucket
+	next := bucketOf(b.node.Next())
+	if next == nil || next.count != b.count+1 {
+		next = p.insertAfter(b, b.count+1)
+	}
+
+	b.entries.Remove(e.node)
+	e.node = next.entries.PushFront(e)
+	e.bucket = next
+
+	if b.entries.Length() == 0 {
+		p.buckets.Remove(b.node)
+	}
+}
+
+func (p *lfuPolicy) remove(e *entry) {
+	b := e.bucket
+	b.entries.Remove(e.node)
+	e.node = nil
+	e.bucket = nil
+
+	if b.entries.Length() == 0 {
+		p.buckets.Remove(b.node)
+	}
+}
+
+func (p *lfuPolicy) victim() *entry {
+	first := bucketOf(p.buckets.Front())
+	if first == nil {
+		return nil
+	}
+
+	return entryOf(first.entries.Back())
+}
+
+func (p *lfuPolicy) each(fn func(e *entry)) {
+	for node := p.buckets.Back(); node != nil; node = node.Prev() {
+		eachEntry(&bucketOf(node).entries, fn)
+	}
+}
+
+func (p *lfuPolicy) clear() {
+	p.buckets.Clear()
+}
+
+// insertAfter creates a new bucket for the count and links it in after the provided bucket. If the
+// provided bucket is nil, then the new bucket is linked in at the front.
+func (p *lfuPolicy) insertAfter(b *bucket, count int) *bucket {
+	nb := &bucket{count: count}
+	if b == nil {
+		nb.node = p.buckets.PushFront(nb)
+	} else {
+		nb.node, _ = p.buckets.InsertAfter(nb, b.node)
+	}
+
+	return nb
+}
+
+// entryOf gets the entry held by the node, or nil if there is no node.
+func entryOf(node *hlist.DoublyNode) *entry {
+	if node == nil {
+		return nil
+	}
+
+	return node.Item().(*entry)
+}
+
+// bucketOf gets the bucket held by the node, or nil if there is no node.
+func bucketOf(node *hlist.DoublyNode) *bucket {
+	if node == nil {
+		return nil
+	}
+
+	return node.Item().(*bucket)
+}
+
+// eachEntry calls fn on every entry in the list, from the front to the back.
+func eachEntry(l *hlist.DoublyList, fn func(e *entry)) {
+	for node := l.Front(); node != nil; node = node.Next() {
+		fn(entryOf(node))
+	}
+}
+
+// hashable checks whether or not the value can be used as a map key. A type can be comparable and still
+// hold something that isn't, such as a struct with an interface field holding a slice, so this also
+// checks what is actually stored.
+func hashable(v reflect.Value) bool {
+	if v.IsValid() && !v.Type().Comparable() {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		// This is a nil interface.
+		return true
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false
+	case reflect.Interface:
+		return hashable(v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !hashable(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !hashable(v.Field(i)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,388 @@
+package hcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hcache"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+func TestBadPtr(t *testing.T) {
+	var c *hcache.Cache
+
+	// Test Get().
+	if v, ok := c.Get("key"); v != nil || ok {
+		t.Error("unexpectedly passed Get() test with bad pointer")
+	}
+
+	// Test Peek().
+	if v, ok := c.Peek("key"); v != nil || ok {
+		t.Error("unexpectedly passed Peek() test with bad pointer")
+	}
+
+	// Test Put().
+	if err := c.Put("key", "value"); err == nil {
+		t.Error("unexpectedly passed Put() test with bad pointer")
+	}
+
+	// Test Remove().
+	if c.Remove("key") {
+		t.Error("unexpectedly passed Remove() test with bad pointer")
+	}
+
+	// Test Len().
+	if n := c.Len(); n != -1 {
+		t.Error("unexpectedly passed Len() test with bad pointer")
+		t.Log("\tExpected: -1")
+		t.Log("\tReceived:", n)
+	}
+
+	// Test Cap().
+	if n := c.Cap(); n != -1 {
+		t.Error("unexpectedly passed Cap() test with bad pointer")
+	}
+
+	// Test Resize().
+	if err := c.Resize(10); err == nil {
+		t.Error("unexpectedly passed Resize() test with bad pointer")
+	}
+
+	// Test Keys().
+	if keys := c.Keys(); keys != nil {
+		t.Error("unexpectedly passed Keys() test with bad pointer")
+	}
+
+	// Test Purge().
+	if n := c.Purge(); n != 0 {
+		t.Error("unexpectedly passed Purge() test with bad pointer")
+	}
+
+	// Test Clear().
+	if err := c.Clear(); err == nil {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+
+	// Test SetEvictCallback().
+	if err := c.SetEvictCallback(nil); err == nil {
+		t.Error("unexpectedly passed SetEvictCallback() test with bad pointer")
+	}
+
+	// Test SetTTL().
+	if err := c.SetTTL(time.Second); err == nil {
+		t.Error("unexpectedly passed SetTTL() test with bad pointer")
+	}
+
+	// Test SetClock().
+	if err := c.SetClock(new(fakeClock)); err == nil {
+		t.Error("unexpectedly passed SetClock() test with bad pointer")
+	}
+
+	// Test String().
+	if s := c.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", s)
+	}
+}
+
+func TestBadArgs(t *testing.T) {
+	if _, err := hcache.NewLRU(0); err == nil {
+		t.Error("unexpectedly passed NewLRU() test with zero capacity")
+	}
+	if _, err := hcache.NewLFU(-1); err == nil {
+		t.Error("unexpectedly passed NewLFU() test with negative capacity")
+	}
+
+	c := newLRU(t, 2)
+	if err := c.Put([]int{1}, "value"); err == nil {
+		t.Error("unexpectedly passed Put() test with non-comparable key")
+	}
+	if _, ok := c.Get([]int{1}); ok {
+		t.Error("unexpectedly passed Get() test with non-comparable key")
+	}
+
+	// A comparable type can still hold a value that can't be hashed.
+	wrapped := struct{ v interface{} }{[]int{1}}
+	if err := c.Put(wrapped, "value"); err == nil {
+		t.Error("unexpectedly passed Put() test with unhashable key")
+	}
+	if _, ok := c.Get(wrapped); ok {
+		t.Error("unexpectedly passed Get() test with unhashable key")
+	}
+	if c.Remove(wrapped) {
+		t.Error("unexpectedly passed Remove() test with unhashable key")
+	}
+	if err := c.Put([1]interface{}{map[int]int{}}, "value"); err == nil {
+		t.Error("unexpectedly passed Put() test with unhashable array key")
+	}
+	if err := c.Resize(0); err == nil {
+		t.Error("unexpectedly passed Resize() test with zero capacity")
+	}
+	if err := c.SetTTL(-time.Second); err == nil {
+		t.Error("unexpectedly passed SetTTL() test with negative ttl")
+	}
+	if err := c.SetClock(nil); err == nil {
+		t.Error("unexpectedly passed SetClock() test with missing clock")
+	}
+	checkString(t, c, "<empty>")
+	checkLen(t, c, 0)
+}
+
+func TestLRU(t *testing.T) {
+	c := newLRU(t, 3)
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	checkString(t, c, "c: 3, b: 2, a: 1")
+	checkLen(t, c, 3)
+
+	// Getting an item should make it the most recently used.
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Error("Failed to get a")
+		t.Log("\tReceived:", v, ok)
+	}
+	checkString(t, c, "a: 1, c: 3, b: 2")
+
+	// Peeking should not change the order.
+	if v, ok := c.Peek("b"); !ok || v != 2 {
+		t.Error("Failed to peek b")
+		t.Log("\tReceived:", v, ok)
+	}
+	checkString(t, c, "a: 1, c: 3, b: 2")
+
+	// Adding another item should evict the least recently used.
+	c.Put("d", 4)
+	checkString(t, c, "d: 4, a: 1, c: 3")
+	checkLen(t, c, 3)
+	if _, ok := c.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+
+	// Replacing an item should update its value and mark it as used.
+	c.Put("c", 30)
+	checkString(t, c, "c: 30, d: 4, a: 1")
+	checkLen(t, c, 3)
+
+	// Test removing items.
+	if !c.Remove("d") {
+		t.Error("Failed to remove d")
+	}
+	if c.Remove("d") {
+		t.Error("Unexpectedly removed d twice")
+	}
+	checkString(t, c, "c: 30, a: 1")
+	checkLen(t, c, 2)
+
+	// Test clearing the cache.
+	if err := c.Clear(); err != nil {
+		t.Error(err)
+	}
+	checkString(t, c, "<empty>")
+	checkLen(t, c, 0)
+	c.Put("e", 5)
+	checkString(t, c, "e: 5")
+}
+
+func TestLFU(t *testing.T) {
+	c, err := hcache.NewLFU(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	checkString(t, c, "c: 3, b: 2, a: 1")
+
+	// Use a twice and c once.
+	c.Get("a")
+	c.Get("a")
+	c.Get("c")
+	checkString(t, c, "a: 1, c: 3, b: 2")
+
+	// b has been used the least, so it should be evicted.
+	c.Put("d", 4)
+	checkString(t, c, "a: 1, c: 3, d: 4")
+	if _, ok := c.Peek("b"); ok {
+		t.Error("b was not evicted")
+	}
+
+	// d and the new item will be tied. d was used less recently, so it should be evicted.
+	c.Put("e", 5)
+	checkString(t, c, "a: 1, c: 3, e: 5")
+
+	// Peeking should not count as a use.
+	c.Peek("e")
+	c.Peek("e")
+	c.Put("f", 6)
+	checkString(t, c, "a: 1, c: 3, f: 6")
+
+	// Removing an item should not disturb the others.
+	c.Remove("c")
+	checkString(t, c, "a: 1, f: 6")
+	checkLen(t, c, 2)
+}
+
+func TestResize(t *testing.T) {
+	c := newLRU(t, 5)
+
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+
+	for i := 0; i < 5; i++ {
+		c.Put(i, i*10)
+	}
+	checkLen(t, c, 5)
+	if n := c.Cap(); n != 5 {
+		t.Error("Incorrect capacity")
+		t.Log("\tExpected: 5")
+		t.Log("\tReceived:", n)
+	}
+
+	// Shrinking should evict the least recently used items in order.
+	if err := c.Resize(2); err != nil {
+		t.Error(err)
+	}
+	checkString(t, c, "4: 40, 3: 30")
+	checkLen(t, c, 2)
+	if len(evicted) != 3 || evicted[0] != 0 || evicted[1] != 1 || evicted[2] != 2 {
+		t.Error("Incorrect items evicted")
+		t.Log("\tExpected: [0 1 2]")
+		t.Log("\tReceived:", evicted)
+	}
+
+	// Growing should make room for more items without evicting.
+	evicted = nil
+	c.Resize(4)
+	c.Put(5, 50)
+	c.Put(6, 60)
+	checkLen(t, c, 4)
+	if len(evicted) != 0 {
+		t.Error("Unexpectedly evicted items")
+		t.Log("\tReceived:", evicted)
+	}
+
+	// Removing and clearing should not call the callback.
+	c.Remove(5)
+	c.Clear()
+	if len(evicted) != 0 {
+		t.Error("Unexpectedly called callback")
+		t.Log("\tReceived:", evicted)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := newLRU(t, 10)
+	c.SetClock(clock)
+	c.SetTTL(time.Minute)
+
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+
+	c.Put("a", 1)
+	clock.Advance(30 * time.Second)
+	c.Put("b", 2)
+
+	// Nothing should have expired yet.
+	if _, ok := c.Get("a"); !ok {
+		t.Error("a expired too early")
+	}
+
+	// a should now be expired, but b should still be good.
+	clock.Advance(30 * time.Second)
+	if _, ok := c.Peek("a"); ok {
+		t.Error("a did not expire")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("b expired too early")
+	}
+	checkLen(t, c, 1)
+
+	// Replacing an item should reset its expiration.
+	clock.Advance(20 * time.Second)
+	c.Put("b", 20)
+	clock.Advance(50 * time.Second)
+	if v, ok := c.Get("b"); !ok || v != 20 {
+		t.Error("b expired too early after being replaced")
+	}
+
+	// Test purging expired items.
+	c.Put("c", 3)
+	c.SetTTL(0)
+	c.Put("d", 4)
+	clock.Advance(time.Hour)
+	if n := c.Purge(); n != 2 {
+		t.Error("Incorrect number of items purged")
+		t.Log("\tExpected: 2")
+		t.Log("\tReceived:", n)
+	}
+	checkString(t, c, "d: 4")
+
+	if len(evicted) != 3 || evicted[0] != "a" || evicted[1] != "b" || evicted[2] != "c" {
+		t.Error("Incorrect items evicted")
+		t.Log("\tExpected: [a b c]")
+		t.Log("\tReceived:", evicted)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	c := newLRU(t, 3)
+	if keys := c.Keys(); keys != nil {
+		t.Error("Unexpectedly received keys for empty cache")
+	}
+
+	c.Put(1, "a")
+	c.Put(2, "b")
+	c.Put(3, "c")
+	c.Get(1)
+	keys := c.Keys()
+	if len(keys) != 3 || keys[0] != 1 || keys[1] != 3 || keys[2] != 2 {
+		t.Error("Incorrect keys")
+		t.Log("\tExpected: [1 3 2]")
+		t.Log("\tReceived:", keys)
+	}
+}
+
+func newLRU(t *testing.T, capacity int) *hcache.Cache {
+	c, err := hcache.NewLRU(capacity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func checkString(t *testing.T, c *hcache.Cache, want string) {
+	if c.String() != want {
+		t.Error("Cache contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", c)
+	}
+}
+
+func checkLen(t *testing.T, c *hcache.Cache, want int) {
+	if c.Len() != want {
+		t.Error("Incorrect length")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", c.Len())
+	}
+}
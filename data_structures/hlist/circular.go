@@ -0,0 +1,234 @@
+package hlist
+
+import (
+	"fmt"
+	"strings"
+)
+
+// This is the standard error message when trying to use an invalid circular list.
+var errBadCircularList = fmt.Errorf("list must be created with NewCircular() first")
+
+// CircularList is a doubly linked list whose ends are joined together into a ring. It keeps track of
+// two positions in the ring: the oldest item, which is where the ring begins when listing its items,
+// and a cursor, which can be moved freely in either direction to cycle through the items.
+//
+// If the list is given a capacity, then it acts as a ring buffer. Once it is full, adding an item
+// overwrites the oldest one.
+type CircularList struct {
+	head     *rnode
+	cursor   *rnode
+	length   int
+	capacity int
+}
+
+// rnode is an internal type for an individual node in the circular list.
+type rnode struct {
+	item interface{}
+	next *rnode
+	prev *rnode
+}
+
+// NewCircular creates a new circular list. If capacity is greater than 0, then the list will hold at
+// most that many items, and adding more will overwrite the oldest items. A capacity of 0 means that
+// the list can grow without limit.
+func NewCircular(capacity int) (*CircularList, error) {
+	if capacity < 0 {
+		return nil, fmt.Errorf("capacity cannot be negative")
+	}
+
+	c := new(CircularList)
+	c.capacity = capacity
+
+	return c, nil
+}
+
+// String returns a comma-separated list of the string representations of all of the items in the
+// list, from the oldest to the newest.
+func (c *CircularList) String() string {
+	if c == nil {
+		return "<nil>"
+	} else if c.head == nil {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	node := c.head
+	for i := 0; i < c.length; i++ {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", node.item))
+		node = node.next
+	}
+
+	return builder.String()
+}
+
+// Length gets the number of items in the list, or -1 if list hasn't been created yet.
+func (c *CircularList) Length() int {
+	if c == nil {
+		return -1
+	}
+
+	return c.length
+}
+
+// Capacity gets the maximum number of items that the list can hold, or 0 if there is no limit. This
+// returns -1 if list hasn't been created yet.
+func (c *CircularList) Capacity() int {
+	if c == nil {
+		return -1
+	}
+
+	return c.capacity
+}
+
+// Add adds one or more items to the list after the newest item. If the list is at capacity, then each
+// new item overwrites the oldest item instead. If the cursor was pointing at an item that was
+// overwritten, then it now points at the new item. The first item added to an empty list becomes the
+// cursor's position.
+func (c *CircularList) Add(items ...interface{}) error {
+	if c == nil {
+		return errBadCircularList
+	}
+
+	for _, item := range items {
+		if c.capacity > 0 && c.length == c.capacity {
+			// Reuse the oldest node for the new item. Moving the head forward makes this node the
+			// newest one in the ring.
+			c.head.item = item
+			c.head = c.head.next
+			continue
+		}
+
+		node := &rnode{item: item}
+		if c.head == nil {
+			node.next = node
+			node.prev = node
+			c.head = node
+			c.cursor = node
+		} else {
+			// The newest node is right before the head.
+			node.next = c.head
+			node.prev = c.head.prev
+			c.head.prev.next = node
+			c.head.prev = node
+		}
+		c.length++
+	}
+
+	return nil
+}
+
+// Current gets the item at the cursor, or nil if the list is empty.
+func (c *CircularList) Current() interface{} {
+	if c == nil || c.cursor == nil {
+		return nil
+	}
+
+	return c.cursor.item
+}
+
+// Next moves the cursor forward by one item and returns the item at the new position. Moving forward
+// from the newest item wraps around to the oldest item.
+func (c *CircularList) Next() interface{} {
+	c.Rotate(1)
+
+	return c.Current()
+}
+
+// Prev moves the cursor backward by one item and returns the item at the new position. Moving
+// backward from the oldest item wraps around to the newest item.
+func (c *CircularList) Prev() interface{} {
+	c.Rotate(-1)
+
+	return c.Current()
+}
+
+// Rotate moves the cursor n items forward around the ring. If n is negative, then the cursor moves
+// backward.
+func (c *CircularList) Rotate(n int) {
+	if c == nil || c.cursor == nil {
+		return
+	}
+
+	// There's no point in going around the ring more than once.
+	n %= c.length
+	if n < 0 {
+		// It might be shorter to go forward.
+		if -n > c.length/2 {
+			n += c.length
+		}
+	} else if n > c.length/2 {
+		n -= c.length
+	}
+
+	for ; n > 0; n-- {
+		c.cursor = c.cursor.next
+	}
+	for ; n < 0; n++ {
+		c.cursor = c.cursor.prev
+	}
+}
+
+// Reset moves the cursor back to the oldest item.
+func (c *CircularList) Reset() {
+	if c != nil {
+		c.cursor = c.head
+	}
+}
+
+// Remove removes the item at the cursor and returns its value. The cursor moves forward to the next
+// item.
+func (c *CircularList) Remove() interface{} {
+	if c == nil || c.cursor == nil {
+		return nil
+	}
+
+	node := c.cursor
+	if c.length == 1 {
+		c.head = nil
+		c.cursor = nil
+		c.length = 0
+		return node.item
+	}
+
+	node.prev.next = node.next
+	node.next.prev = node.prev
+	if c.head == node {
+		c.head = node.next
+	}
+	c.cursor = node.next
+	c.length--
+
+	return node.item
+}
+
+// Items returns a slice of all items in the list, from the oldest to the newest.
+func (c *CircularList) Items() []interface{} {
+	if c == nil || c.head == nil {
+		return nil
+	}
+
+	items := make([]interface{}, c.length)
+	node := c.head
+	for i := range items {
+		items[i] = node.item
+		node = node.next
+	}
+
+	return items
+}
+
+// Clear removes all items from the list. The capacity is kept.
+func (c *CircularList) Clear() error {
+	if c == nil {
+		return errBadCircularList
+	}
+
+	c.head = nil
+	c.cursor = nil
+	c.length = 0
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package hlist_test
+
+import (
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+func TestCircularBadPtr(t *testing.T) {
+	var c *hlist.CircularList
+
+	// Test String().
+	if s := c.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", s)
+	}
+
+	// Test Length().
+	if n := c.Length(); n != -1 {
+		t.Error("unexpectedly passed Length() test with bad pointer")
+	}
+
+	// Test Capacity().
+	if n := c.Capacity(); n != -1 {
+		t.Error("unexpectedly passed Capacity() test with bad pointer")
+	}
+
+	// Test Add().
+	if err := c.Add(1); err == nil {
+		t.Error("unexpectedly passed Add() test with bad pointer")
+	}
+
+	// Test Current(), Next(), and Prev().
+	if c.Current() != nil || c.Next() != nil || c.Prev() != nil {
+		t.Error("unexpectedly passed cursor tests with bad pointer")
+	}
+
+	// Test Rotate() and Reset(). These should not panic.
+	c.Rotate(1)
+	c.Reset()
+
+	// Test Remove().
+	if v := c.Remove(); v != nil {
+		t.Error("unexpectedly passed Remove() test with bad pointer")
+	}
+
+	// Test Items().
+	if v := c.Items(); v != nil {
+		t.Error("unexpectedly passed Items() test with bad pointer")
+	}
+
+	// Test Clear().
+	if err := c.Clear(); err == nil {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+}
+
+func TestCircularBadArgs(t *testing.T) {
+	if _, err := hlist.NewCircular(-1); err == nil {
+		t.Error("unexpectedly passed NewCircular() test with negative capacity")
+	}
+
+	c := newCircular(t, 0)
+	checkCircular(t, c, "<empty>", 0)
+
+	// Moving around an empty list should do nothing.
+	if c.Current() != nil || c.Next() != nil || c.Prev() != nil {
+		t.Error("unexpectedly received item from empty list")
+	}
+	if v := c.Remove(); v != nil {
+		t.Error("unexpectedly removed item from empty list")
+	}
+	checkCircular(t, c, "<empty>", 0)
+}
+
+func TestCircularCursor(t *testing.T) {
+	c := newCircular(t, 0)
+	c.Add("a", "b", "c", "d")
+	checkCircular(t, c, "a, b, c, d", 4)
+
+	// The cursor should start on the first item and wrap around in both directions.
+	checkCurrent(t, c, "a")
+	if v := c.Next(); v != "b" {
+		t.Error("Incorrect next item")
+		t.Log("\tExpected: b")
+		t.Log("\tReceived:", v)
+	}
+	c.Next()
+	c.Next()
+	if v := c.Next(); v != "a" {
+		t.Error("Cursor did not wrap around forward")
+		t.Log("\tExpected: a")
+		t.Log("\tReceived:", v)
+	}
+	if v := c.Prev(); v != "d" {
+		t.Error("Cursor did not wrap around backward")
+		t.Log("\tExpected: d")
+		t.Log("\tReceived:", v)
+	}
+
+	// Test rotating by more than one step in each direction.
+	c.Rotate(2)
+	checkCurrent(t, c, "b")
+	c.Rotate(-3)
+	checkCurrent(t, c, "c")
+	c.Rotate(9)
+	checkCurrent(t, c, "d")
+	c.Rotate(-8)
+	checkCurrent(t, c, "d")
+	c.Rotate(0)
+	checkCurrent(t, c, "d")
+
+	// Moving the cursor should not change the order of the items.
+	checkCircular(t, c, "a, b, c, d", 4)
+
+	// Test resetting the cursor.
+	c.Reset()
+	checkCurrent(t, c, "a")
+}
+
+func TestCircularRoundRobin(t *testing.T) {
+	c := newCircular(t, 0)
+	c.Add("w1", "w2", "w3")
+
+	// Hand out work to each worker in turn.
+	want := []string{"w1", "w2", "w3", "w1", "w2", "w3", "w1"}
+	for i, w := range want {
+		if v := c.Current(); v != w {
+			t.Error("Incorrect worker at turn", i)
+			t.Log("\tExpected:", w)
+			t.Log("\tReceived:", v)
+		}
+		c.Next()
+	}
+
+	// Remove the current worker. The next one in line should take its place.
+	checkCurrent(t, c, "w2")
+	if v := c.Remove(); v != "w2" {
+		t.Error("Incorrect item removed")
+		t.Log("\tExpected: w2")
+		t.Log("\tReceived:", v)
+	}
+	checkCurrent(t, c, "w3")
+	checkCircular(t, c, "w1, w3", 2)
+
+	// Adding a worker should put it at the end of the line.
+	c.Add("w4")
+	checkCircular(t, c, "w1, w3, w4", 3)
+	if v := c.Next(); v != "w4" {
+		t.Error("Incorrect next item")
+		t.Log("\tExpected: w4")
+		t.Log("\tReceived:", v)
+	}
+
+	// Remove the oldest item so the head moves.
+	c.Reset()
+	c.Remove()
+	checkCircular(t, c, "w3, w4", 2)
+	checkCurrent(t, c, "w3")
+
+	// Remove everything.
+	c.Remove()
+	c.Remove()
+	checkCircular(t, c, "<empty>", 0)
+	if v := c.Current(); v != nil {
+		t.Error("Unexpectedly received item from empty list")
+	}
+}
+
+func TestCircularCapacity(t *testing.T) {
+	c := newCircular(t, 3)
+	if n := c.Capacity(); n != 3 {
+		t.Error("Incorrect capacity")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", n)
+	}
+
+	c.Add(1, 2, 3)
+	checkCircular(t, c, "1, 2, 3", 3)
+	checkCurrent(t, c, 1)
+
+	// Adding more should overwrite the oldest items, keeping a window of the most recent.
+	c.Add(4)
+	checkCircular(t, c, "2, 3, 4", 3)
+	c.Add(5, 6, 7)
+	checkCircular(t, c, "5, 6, 7", 3)
+
+	// The cursor was on the node that held 1, which has been overwritten twice.
+	checkCurrent(t, c, 7)
+	c.Reset()
+	checkCurrent(t, c, 5)
+	if v := c.Prev(); v != 7 {
+		t.Error("Incorrect newest item")
+		t.Log("\tExpected: 7")
+		t.Log("\tReceived:", v)
+	}
+
+	// Removing an item should make room again.
+	c.Reset()
+	c.Remove()
+	checkCircular(t, c, "6, 7", 2)
+	c.Add(8)
+	checkCircular(t, c, "6, 7, 8", 3)
+	c.Add(9)
+	checkCircular(t, c, "7, 8, 9", 3)
+
+	// Clearing should keep the capacity.
+	c.Clear()
+	checkCircular(t, c, "<empty>", 0)
+	c.Add(1, 2, 3, 4)
+	checkCircular(t, c, "2, 3, 4", 3)
+	items := c.Items()
+	if len(items) != 3 || items[0] != 2 || items[2] != 4 {
+		t.Error("Incorrect items")
+		t.Log("\tExpected: [2 3 4]")
+		t.Log("\tReceived:", items)
+	}
+}
+
+func newCircular(t *testing.T, capacity int) *hlist.CircularList {
+	c, err := hlist.NewCircular(capacity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func checkCurrent(t *testing.T, c *hlist.CircularList, want interface{}) {
+	if v := c.Current(); v != want {
+		t.Error("Incorrect item at cursor")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", v)
+	}
+}
+
+func checkCircular(t *testing.T, c *hlist.CircularList, want string, length int) {
+	if c.String() != want {
+		t.Error("List contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", c)
+	}
+	if c.Length() != length {
+		t.Error("Incorrect length")
+		t.Log("\tExpected:", length)
+		t.Log("\tReceived:", c.Length())
+	}
+}
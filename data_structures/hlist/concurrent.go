@@ -0,0 +1,371 @@
+package hlist
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// This is the standard error message when trying to use an invalid concurrent list.
+var errBadConcurrentList = fmt.Errorf("list must be created with NewConcurrent() first")
+
+// ConcurrentList is a linked list that is safe for use by multiple goroutines at the same time.
+// Every node has its own lock, and operations walk the list using lock coupling (also known as
+// hand-over-hand locking): the lock for the next node is acquired before the lock for the current
+// node is released. This allows goroutines working on different parts of the list to proceed in
+// parallel instead of contending for a single lock on the entire list.
+type ConcurrentList struct {
+	// length is accessed atomically and is kept first to guarantee 64-bit alignment.
+	length int64
+	// head is a sentinel node that never holds an item. Having it means that there is always a node
+	// to lock before the first real node, which keeps insertions and removals at index 0 from being
+	// special cases.
+	head *cnode
+}
+
+// cnode is an internal type for an individual node in the concurrent list.
+type cnode struct {
+	mu      sync.Mutex
+	item    interface{}
+	next    *cnode
+	removed bool
+}
+
+// NewConcurrent creates a new concurrent linked list.
+func NewConcurrent() *ConcurrentList {
+	l := new(ConcurrentList)
+	l.head = new(cnode)
+
+	return l
+}
+
+// String returns a comma-separated list of the string representations of all of the items in the
+// linked list.
+func (l *ConcurrentList) String() string {
+	if l == nil || l.head == nil {
+		return "<nil>"
+	}
+
+	items := l.Items()
+	if len(items) == 0 {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for _, item := range items {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", item))
+	}
+
+	return builder.String()
+}
+
+// Length gets the number of nodes in the list, or -1 if list hasn't been created yet. Because other
+// goroutines may be modifying the list, the value is only a snapshot at the time of the call.
+func (l *ConcurrentList) Length() int {
+	if l == nil || l.head == nil {
+		return -1
+	}
+
+	return int(atomic.LoadInt64(&l.length))
+}
+
+// Insert inserts one or more items into the list at the specified index.
+func (l *ConcurrentList) Insert(index int, items ...interface{}) error {
+	// Make sure that none of the items is this list itself.
+	for _, v := range items {
+		if nl, ok := v.(*ConcurrentList); ok && l != nil && l == nl {
+			return fmt.Errorf("can't add list to itself")
+		}
+	}
+
+	prior, err := l.lockPrior(index)
+	if err != nil {
+		return err
+	}
+	defer prior.mu.Unlock()
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	// Build out the chain of items. Nobody else can see these nodes yet, so they don't need to be
+	// locked while they are being linked in.
+	var begin, end *cnode
+	for _, item := range items {
+		node := &cnode{item: item}
+		if begin == nil {
+			begin = node
+		} else {
+			end.next = node
+		}
+		end = node
+	}
+
+	end.next = prior.next
+	prior.next = begin
+	atomic.AddInt64(&l.length, int64(len(items)))
+
+	return nil
+}
+
+// Append adds one or more items to the end of the list.
+func (l *ConcurrentList) Append(items ...interface{}) error {
+	if l == nil || l.head == nil {
+		return errBadConcurrentList
+	}
+
+	// Make sure that none of the items is this list itself.
+	for _, v := range items {
+		if nl, ok := v.(*ConcurrentList); ok && l == nl {
+			return fmt.Errorf("can't add list to itself")
+		}
+	}
+
+	// We can't rely on the length here because other goroutines might be changing it while we
+	// walk the list. Instead, we'll go until we find the last node.
+	prior := l.head
+	prior.mu.Lock()
+	for prior.next != nil {
+		next := prior.next
+		next.mu.Lock()
+		prior.mu.Unlock()
+		prior = next
+	}
+	defer prior.mu.Unlock()
+
+	for _, item := range items {
+		prior.next = &cnode{item: item}
+		prior = prior.next
+	}
+	atomic.AddInt64(&l.length, int64(len(items)))
+
+	return nil
+}
+
+// Index gets the index of the first matching item, or -1 if not found.
+func (l *ConcurrentList) Index(item interface{}) int {
+	if l == nil || l.head == nil {
+		return -1
+	}
+
+	index := -1
+	i := 0
+	l.walk(func(node *cnode) bool {
+		if reflect.DeepEqual(node.item, item) {
+			index = i
+			return false
+		}
+		i++
+		return true
+	})
+
+	return index
+}
+
+// Item gets the item at the index.
+func (l *ConcurrentList) Item(index int) interface{} {
+	prior, err := l.lockPrior(index)
+	if err != nil {
+		return nil
+	}
+	defer prior.mu.Unlock()
+
+	if prior.next == nil {
+		return nil
+	}
+
+	// Even though the next node's item will never change, we still need to lock the node to read
+	// its contents safely.
+	node := prior.next
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	return node.item
+}
+
+// Items returns a slice of all items in the list in order of insertion.
+func (l *ConcurrentList) Items() []interface{} {
+	if l == nil || l.head == nil {
+		return nil
+	}
+
+	var items []interface{}
+	l.walk(func(node *cnode) bool {
+		items = append(items, node.item)
+		return true
+	})
+
+	return items
+}
+
+// Exists checks whether or not the item exists in the list.
+func (l *ConcurrentList) Exists(item interface{}) bool {
+	return l.Index(item) >= 0
+}
+
+// Remove removes an item from the list and returns its value.
+func (l *ConcurrentList) Remove(index int) interface{} {
+	prior, err := l.lockPrior(index)
+	if err != nil {
+		return nil
+	}
+	defer prior.mu.Unlock()
+
+	pop := prior.next
+	if pop == nil {
+		return nil
+	}
+
+	// We need to hold the lock on the node that we're removing so that any iterators currently
+	// waiting on it will see that it was removed. We leave its next pointer alone so that those
+	// iterators can still find their way back to the rest of the list.
+	pop.mu.Lock()
+	prior.next = pop.next
+	pop.removed = true
+	pop.mu.Unlock()
+
+	atomic.AddInt64(&l.length, -1)
+
+	return pop.item
+}
+
+// RemoveMatch finds the first item with a matching value and removes it from the list.
+func (l *ConcurrentList) RemoveMatch(value interface{}) {
+	if l == nil || l.head == nil {
+		return
+	}
+
+	// We can't look up the index and then remove it like List does because another goroutine might
+	// shift the items around in between those two steps. Instead, we'll do it all in one walk.
+	prior := l.head
+	prior.mu.Lock()
+	for node := prior.next; node != nil; node = prior.next {
+		node.mu.Lock()
+		if reflect.DeepEqual(node.item, value) {
+			prior.next = node.next
+			node.removed = true
+			node.mu.Unlock()
+			prior.mu.Unlock()
+			atomic.AddInt64(&l.length, -1)
+			return
+		}
+		prior.mu.Unlock()
+		prior = node
+	}
+	prior.mu.Unlock()
+}
+
+// Clear resets the list to its initial state.
+func (l *ConcurrentList) Clear() error {
+	if l == nil || l.head == nil {
+		return errBadConcurrentList
+	}
+
+	l.head.mu.Lock()
+	defer l.head.mu.Unlock()
+
+	// Mark every node as removed so that any active iterators stop returning items.
+	for node := l.head.next; node != nil; {
+		node.mu.Lock()
+		node.removed = true
+		next := node.next
+		node.mu.Unlock()
+		node = next
+	}
+	l.head.next = nil
+	atomic.StoreInt64(&l.length, 0)
+
+	return nil
+}
+
+// Yield provides an unbuffered channel that will continually pass successive items until the list
+// is exhausted. The channel quit is used to communicate when iteration should be stopped. Send an
+// empty struct (struct{}{}) on the channel to break the communication. This will happen
+// automatically if the list is exhausted. If this is not needed, pass nil as the argument.
+//
+// It is safe to modify the list while iterating over it, including from the goroutine receiving the
+// items. No locks are held while waiting to send an item. Items that are removed before the
+// iterator reaches them will not be sent, but items that are inserted behind the iterator's current
+// position will not be seen.
+func (l *ConcurrentList) Yield(quit <-chan struct{}) <-chan interface{} {
+	if l == nil || l.head == nil || l.Length() == 0 {
+		return nil
+	}
+
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+
+		node := l.head
+		node.mu.Lock()
+		next := node.next
+		node.mu.Unlock()
+
+		for next != nil {
+			node = next
+			node.mu.Lock()
+			item, removed := node.item, node.removed
+			next = node.next
+			node.mu.Unlock()
+
+			if removed {
+				continue
+			}
+
+			select {
+			case ch <- item:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// lockPrior walks the list hand-over-hand and returns the node immediately before the specified
+// index. The node is returned locked, and it is up to the caller to unlock it.
+func (l *ConcurrentList) lockPrior(index int) (*cnode, error) {
+	if l == nil || l.head == nil {
+		return nil, errBadConcurrentList
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("invalid index")
+	}
+
+	prior := l.head
+	prior.mu.Lock()
+	for i := 0; i < index; i++ {
+		next := prior.next
+		if next == nil {
+			prior.mu.Unlock()
+			return nil, fmt.Errorf("out of bounds")
+		}
+		next.mu.Lock()
+		prior.mu.Unlock()
+		prior = next
+	}
+
+	return prior, nil
+}
+
+// walk calls fn on each node in the list in order while that node is locked. If fn returns false,
+// then the walk stops.
+func (l *ConcurrentList) walk(fn func(node *cnode) bool) {
+	prior := l.head
+	prior.mu.Lock()
+	for node := prior.next; node != nil; node = node.next {
+		node.mu.Lock()
+		prior.mu.Unlock()
+		prior = node
+		if !fn(node) {
+			break
+		}
+	}
+	prior.mu.Unlock()
+}
@@ -0,0 +1,297 @@
+package hlist_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+func TestConcurrentBadPtr(t *testing.T) {
+	var l *hlist.ConcurrentList
+
+	// Test String().
+	if s := l.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", s)
+	}
+
+	// Test Length().
+	if n := l.Length(); n != -1 {
+		t.Error("unexpectedly passed Length() test with bad pointer")
+		t.Log("\tExpected: -1")
+		t.Log("\tReceived:", n)
+	}
+
+	// Test Insert().
+	if err := l.Insert(0, "item"); err == nil {
+		t.Error("unexpectedly passed Insert() test with bad pointer")
+	}
+
+	// Test Append().
+	if err := l.Append("item"); err == nil {
+		t.Error("unexpectedly passed Append() test with bad pointer")
+	}
+
+	// Test Index().
+	if i := l.Index("item"); i != -1 {
+		t.Error("unexpectedly passed Index() test with bad pointer")
+	}
+
+	// Test Item().
+	if v := l.Item(0); v != nil {
+		t.Error("unexpectedly passed Item() test with bad pointer")
+	}
+
+	// Test Items().
+	if v := l.Items(); v != nil {
+		t.Error("unexpectedly passed Items() test with bad pointer")
+	}
+
+	// Test Exists().
+	if l.Exists("item") {
+		t.Error("unexpectedly passed Exists() test with bad pointer")
+	}
+
+	// Test Remove().
+	if v := l.Remove(0); v != nil {
+		t.Error("unexpectedly passed Remove() test with bad pointer")
+	}
+
+	// Test Clear().
+	if err := l.Clear(); err == nil {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+
+	// Test Yield().
+	if ch := l.Yield(nil); ch != nil {
+		t.Error("unexpectedly passed Yield() test with bad pointer")
+	}
+
+	// Test using a list that wasn't created with NewConcurrent().
+	l = new(hlist.ConcurrentList)
+	if err := l.Append("item"); err == nil {
+		t.Error("unexpectedly passed Append() test with uninitialized list")
+	}
+}
+
+func TestConcurrentBadArgs(t *testing.T) {
+	l := hlist.NewConcurrent()
+	checkConcurrentString(t, l, "<empty>")
+	checkConcurrentLength(t, l, 0)
+
+	if err := l.Insert(-1, "item"); err == nil {
+		t.Error("unexpectedly passed Insert() test for negative index")
+	}
+	if err := l.Insert(1, "item"); err == nil {
+		t.Error("unexpectedly passed Insert() test for out-of-range index")
+	}
+	if v := l.Remove(0); v != nil {
+		t.Error("unexpectedly passed Remove() test for empty list")
+	}
+	checkConcurrentString(t, l, "<empty>")
+	checkConcurrentLength(t, l, 0)
+
+	// Test trying to add list to itself.
+	l.Append(1, 2, 3)
+	if err := l.Append(4, l); err == nil {
+		t.Error("unexpectedly passed Append() test for adding list to itself")
+	}
+	if err := l.Insert(1, l); err == nil {
+		t.Error("unexpectedly passed Insert() test for adding list to itself")
+	}
+	checkConcurrentString(t, l, "1, 2, 3")
+	checkConcurrentLength(t, l, 3)
+}
+
+func TestConcurrentOperations(t *testing.T) {
+	l := hlist.NewConcurrent()
+
+	if err := l.Append(1, 2, 3); err != nil {
+		t.Error(err)
+	}
+	checkConcurrentString(t, l, "1, 2, 3")
+	checkConcurrentLength(t, l, 3)
+
+	if err := l.Insert(0, "a"); err != nil {
+		t.Error(err)
+	}
+	if err := l.Insert(2, "b", "c"); err != nil {
+		t.Error(err)
+	}
+	if err := l.Insert(l.Length(), "z"); err != nil {
+		t.Error(err)
+	}
+	checkConcurrentString(t, l, "a, 1, b, c, 2, 3, z")
+	checkConcurrentLength(t, l, 7)
+
+	if i := l.Index("c"); i != 3 {
+		t.Error("Incorrect index for c")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", i)
+	}
+	if v := l.Item(4); v != 2 {
+		t.Error("Incorrect item at index 4")
+		t.Log("\tExpected: 2")
+		t.Log("\tReceived:", v)
+	}
+	if !l.Exists("z") {
+		t.Error("z unexpectedly does not exist")
+	}
+	if l.Exists("y") {
+		t.Error("y unexpectedly exists")
+	}
+
+	if v := l.Remove(0); v != "a" {
+		t.Error("Incorrect item removed")
+		t.Log("\tExpected: a")
+		t.Log("\tReceived:", v)
+	}
+	if v := l.Remove(l.Length() - 1); v != "z" {
+		t.Error("Incorrect item removed")
+		t.Log("\tExpected: z")
+		t.Log("\tReceived:", v)
+	}
+	l.RemoveMatch("b")
+	checkConcurrentString(t, l, "1, c, 2, 3")
+	checkConcurrentLength(t, l, 4)
+
+	if err := l.Clear(); err != nil {
+		t.Error(err)
+	}
+	checkConcurrentString(t, l, "<empty>")
+	checkConcurrentLength(t, l, 0)
+}
+
+func TestConcurrentYield(t *testing.T) {
+	l := hlist.NewConcurrent()
+	l.Append(0, 1, 2, 3, 4, 5)
+
+	// Remove items while iterating. Items that haven't been reached yet should not be sent.
+	var received []interface{}
+	for v := range l.Yield(nil) {
+		received = append(received, v)
+		if v == 1 {
+			l.RemoveMatch(2)
+			l.RemoveMatch(3)
+		}
+	}
+	if len(received) != 4 || received[0] != 0 || received[1] != 1 || received[2] != 4 || received[3] != 5 {
+		t.Error("Incorrect items yielded")
+		t.Log("\tExpected: [0 1 4 5]")
+		t.Log("\tReceived:", received)
+	}
+	checkConcurrentString(t, l, "0, 1, 4, 5")
+
+	// Test stopping iteration early.
+	quit := make(chan struct{})
+	ch := l.Yield(quit)
+	<-ch
+	close(quit)
+	for range ch {
+		// Drain anything that was in flight. The channel must be closed by the iterator.
+	}
+}
+
+func TestConcurrentStress(t *testing.T) {
+	l := hlist.NewConcurrent()
+
+	const workers = 8
+	const perWorker = 200
+
+	// Have a set of goroutines adding items while another set is removing them and another set is
+	// iterating over the list.
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				if i%2 == 0 {
+					l.Insert(0, w*perWorker+i)
+				} else {
+					l.Append(w*perWorker + i)
+				}
+			}
+		}(w)
+	}
+
+	removed := make(chan interface{}, workers*perWorker)
+	for w := 0; w < workers/2; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				if v := l.Remove(0); v != nil {
+					removed <- v
+				}
+			}
+		}()
+	}
+
+	for w := 0; w < workers/2; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				ch := l.Yield(nil)
+				if ch == nil {
+					// The list is currently empty.
+					continue
+				}
+				for v := range ch {
+					if v.(int)%100 == 0 {
+						l.Exists(v)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(removed)
+
+	// Every item that was added must now be either in the list or have been removed, with no
+	// duplicates and nothing missing.
+	var all []int
+	for v := range removed {
+		all = append(all, v.(int))
+	}
+	for _, v := range l.Items() {
+		all = append(all, v.(int))
+	}
+	if len(all) != workers*perWorker {
+		t.Error("Incorrect number of items")
+		t.Log("\tExpected:", workers*perWorker)
+		t.Log("\tReceived:", len(all))
+	}
+	sort.Ints(all)
+	for i, v := range all {
+		if v != i {
+			t.Error("Missing or duplicated item", i)
+			break
+		}
+	}
+	if n := l.Length(); n != len(l.Items()) {
+		t.Error("Length does not match contents")
+		t.Log("\tExpected:", len(l.Items()))
+		t.Log("\tReceived:", n)
+	}
+}
+
+func checkConcurrentString(t *testing.T, l *hlist.ConcurrentList, want string) {
+	if l.String() != want {
+		t.Error("List contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", l)
+	}
+}
+
+func checkConcurrentLength(t *testing.T, l *hlist.ConcurrentList, want int) {
+	if l.Length() != want {
+		t.Error("Incorrect length")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", l.Length())
+	}
+}
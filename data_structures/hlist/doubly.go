@@ -0,0 +1,257 @@
+package hlist
+
+import (
+	"fmt"
+	"strings"
+)
+
+// This is the standard error message when trying to use a node that doesn't belong to the list.
+var errBadDoublyNode = fmt.Errorf("node is not in this list")
+
+// DoublyList is a doubly linked list that hands out its nodes, so that an item can be moved or
+// removed in constant time by anyone holding its node. This makes it a good base for structures that
+// keep their own index of the items, such as caches. The zero value is an empty list that is ready
+// to use.
+type DoublyList struct {
+	head   *DoublyNode
+	tail   *DoublyNode
+	length int
+}
+
+// DoublyNode is a node in a DoublyList. It stays valid until it is removed from its list.
+type DoublyNode struct {
+	item interface{}
+	list *DoublyList
+	next *DoublyNode
+	prev *DoublyNode
+}
+
+// NewDoubly creates a new doubly linked list.
+func NewDoubly() *DoublyList {
+	return new(DoublyList)
+}
+
+// String returns a comma-separated list of the string representations of all of the items in the
+// list, from the front to the back.
+func (l *DoublyList) String() string {
+	if l == nil {
+		return "<nil>"
+	} else if l.head == nil {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for node := l.head; node != nil; node = node.next {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", node.item))
+	}
+
+	return builder.String()
+}
+
+// Length gets the number of nodes in the list, or -1 if the list hasn't been created yet.
+func (l *DoublyList) Length() int {
+	if l == nil {
+		return -1
+	}
+
+	return l.length
+}
+
+// Front gets the first node in the list, or nil if the list is empty.
+func (l *DoublyList) Front() *DoublyNode {
+	if l == nil {
+		return nil
+	}
+
+	return l.head
+}
+
+// Back gets the last node in the list, or nil if the list is empty.
+func (l *DoublyList) Back() *DoublyNode {
+	if l == nil {
+		return nil
+	}
+
+	return l.tail
+}
+
+// PushFront adds the item to the front of the list and returns its node, or nil if the list hasn't
+// been created yet.
+func (l *DoublyList) PushFront(item interface{}) *DoublyNode {
+	if l == nil {
+		return nil
+	}
+
+	node := &DoublyNode{item: item}
+	l.link(node, nil)
+
+	return node
+}
+
+// PushBack adds the item to the back of the list and returns its node, or nil if the list hasn't been
+// created yet.
+func (l *DoublyList) PushBack(item interface{}) *DoublyNode {
+	if l == nil {
+		return nil
+	}
+
+	node := &DoublyNode{item: item}
+	l.link(node, l.tail)
+
+	return node
+}
+
+// InsertAfter adds the item to the list right after the provided node and returns the new node. The
+// provided node must be in this list.
+func (l *DoublyList) InsertAfter(item interface{}, mark *DoublyNode) (*DoublyNode, error) {
+	if l == nil {
+		return nil, errBadList
+	} else if !l.owns(mark) {
+		return nil, errBadDoublyNode
+	}
+
+	node := &DoublyNode{item: item}
+	l.link(node, mark)
+
+	return node, nil
+}
+
+// MoveToFront moves the node to the front of the list. The node must be in this list.
+func (l *DoublyList) MoveToFront(node *DoublyNode) error {
+	if l == nil {
+		return errBadList
+	} else if !l.owns(node) {
+		return errBadDoublyNode
+	}
+
+	if node != l.head {
+		l.unlink(node)
+		l.link(node, nil)
+	}
+
+	return nil
+}
+
+// Remove removes the node from the list and returns its item. This returns nil if the node is not in
+// this list.
+func (l *DoublyList) Remove(node *DoublyNode) interface{} {
+	if l == nil || !l.owns(node) {
+		return nil
+	}
+
+	l.unlink(node)
+
+	return node.item
+}
+
+// Items returns a slice of all items in the list, from the front to the back.
+func (l *DoublyList) Items() []interface{} {
+	if l == nil || l.length == 0 {
+		return nil
+	}
+
+	items := make([]interface{}, 0, l.length)
+	for node := l.head; node != nil; node = node.next {
+		items = append(items, node.item)
+	}
+
+	return items
+}
+
+// Clear removes all items from the list. Nodes that were handed out are no longer in the list
+// afterward.
+func (l *DoublyList) Clear() error {
+	if l == nil {
+		return errBadList
+	}
+
+	// Detach every node so that stale nodes can't be used to change the list later.
+	for node := l.head; node != nil; {
+		next := node.next
+		node.list, node.next, node.prev = nil, nil, nil
+		node = next
+	}
+	*l = DoublyList{}
+
+	return nil
+}
+
+// Item gets the node's item.
+func (n *DoublyNode) Item() interface{} {
+	if n == nil {
+		return nil
+	}
+
+	return n.item
+}
+
+// SetItem replaces the node's item.
+func (n *DoublyNode) SetItem(item interface{}) {
+	if n != nil {
+		n.item = item
+	}
+}
+
+// Next gets the node after this one, or nil if this is the last node or it is not in a list.
+func (n *DoublyNode) Next() *DoublyNode {
+	if n == nil {
+		return nil
+	}
+
+	return n.next
+}
+
+// Prev gets the node before this one, or nil if this is the first node or it is not in a list.
+func (n *DoublyNode) Prev() *DoublyNode {
+	if n == nil {
+		return nil
+	}
+
+	return n.prev
+}
+
+// owns checks whether or not the node is in this list.
+func (l *DoublyList) owns(node *DoublyNode) bool {
+	return node != nil && node.list == l
+}
+
+// link links the node in after the provided node. If the provided node is nil, then the node is linked
+// in at the front.
+func (l *DoublyList) link(node, after *DoublyNode) {
+	node.list = l
+	node.prev = after
+	if after == nil {
+		node.next = l.head
+		l.head = node
+	} else {
+		node.next = after.next
+		after.next = node
+	}
+
+	if node.next != nil {
+		node.next.prev = node
+	} else {
+		l.tail = node
+	}
+	l.length++
+}
+
+// unlink removes the node from the list.
+func (l *DoublyList) unlink(node *DoublyNode) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		l.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		l.tail = node.prev
+	}
+
+	node.list, node.next, node.prev = nil, nil, nil
+	l.length--
+}
@@ -0,0 +1,139 @@
+package hlist_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+func TestDoublyBadPtr(t *testing.T) {
+	var l *hlist.DoublyList
+
+	if s := l.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", s)
+	}
+	if n := l.Length(); n != -1 {
+		t.Error("unexpectedly passed Length() test with bad pointer")
+	}
+	if l.Front() != nil || l.Back() != nil {
+		t.Error("unexpectedly passed Front()/Back() test with bad pointer")
+	}
+	if l.PushFront(1) != nil || l.PushBack(1) != nil {
+		t.Error("unexpectedly passed PushFront()/PushBack() test with bad pointer")
+	}
+	if _, err := l.InsertAfter(1, nil); err == nil {
+		t.Error("unexpectedly passed InsertAfter() test with bad pointer")
+	}
+	if err := l.MoveToFront(nil); err == nil {
+		t.Error("unexpectedly passed MoveToFront() test with bad pointer")
+	}
+	if v := l.Remove(nil); v != nil {
+		t.Error("unexpectedly passed Remove() test with bad pointer")
+	}
+	if v := l.Items(); v != nil {
+		t.Error("unexpectedly passed Items() test with bad pointer")
+	}
+	if err := l.Clear(); err == nil {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+
+	// Nodes that don't belong to the list should be rejected.
+	l1, l2 := hlist.NewDoubly(), hlist.NewDoubly()
+	node := l2.PushBack("x")
+	if _, err := l1.InsertAfter(1, node); err == nil {
+		t.Error("unexpectedly passed InsertAfter() test with node from another list")
+	}
+	if err := l1.MoveToFront(node); err == nil {
+		t.Error("unexpectedly passed MoveToFront() test with node from another list")
+	}
+	if v := l1.Remove(node); v != nil {
+		t.Error("unexpectedly passed Remove() test with node from another list")
+	}
+	checkDoubly(t, l2, []interface{}{"x"})
+}
+
+func TestDoubly(t *testing.T) {
+	// The zero value should be ready to use.
+	var l hlist.DoublyList
+	checkDoubly(t, &l, nil)
+
+	b := l.PushBack("b")
+	a := l.PushFront("a")
+	d := l.PushBack("d")
+	c, err := l.InsertAfter("c", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDoubly(t, &l, []interface{}{"a", "b", "c", "d"})
+	if l.Front() != a || l.Back() != d || a.Next() != b || c.Prev() != b {
+		t.Error("Incorrect links")
+	}
+
+	// Moving nodes around should keep the links in both directions.
+	if err := l.MoveToFront(d); err != nil {
+		t.Error(err)
+	}
+	if err := l.MoveToFront(d); err != nil {
+		t.Error(err)
+	}
+	checkDoubly(t, &l, []interface{}{"d", "a", "b", "c"})
+	if l.Back() != c || c.Next() != nil || d.Prev() != nil {
+		t.Error("Incorrect links after move")
+	}
+
+	// Removing a node should return its item and detach it.
+	if v := l.Remove(b); v != "b" {
+		t.Error("Incorrect item removed")
+		t.Log("\tExpected: b")
+		t.Log("\tReceived:", v)
+	}
+	if v := l.Remove(b); v != nil {
+		t.Error("Removed node twice")
+	}
+	if b.Next() != nil || b.Prev() != nil {
+		t.Error("Removed node is still linked")
+	}
+	checkDoubly(t, &l, []interface{}{"d", "a", "c"})
+
+	// Items can be changed through their nodes.
+	a.SetItem("A")
+	if v := a.Item(); v != "A" {
+		t.Error("Incorrect item after SetItem()")
+	}
+
+	// Clearing should detach every node.
+	if err := l.Clear(); err != nil {
+		t.Error(err)
+	}
+	checkDoubly(t, &l, nil)
+	if err := l.MoveToFront(a); err == nil {
+		t.Error("Cleared node is still in the list")
+	}
+}
+
+func checkDoubly(t *testing.T, l *hlist.DoublyList, want []interface{}) {
+	if items := l.Items(); !reflect.DeepEqual(items, want) {
+		t.Error("Incorrect items")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", items)
+	}
+	if n := l.Length(); n != len(want) {
+		t.Error("Incorrect length")
+		t.Log("\tExpected:", len(want))
+		t.Log("\tReceived:", n)
+	}
+
+	// Walking backward should give the same items in reverse.
+	var back []interface{}
+	for node := l.Back(); node != nil; node = node.Prev() {
+		back = append([]interface{}{node.Item()}, back...)
+	}
+	if !reflect.DeepEqual(back, want) {
+		t.Error("Incorrect items walking backward")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", back)
+	}
+}
@@ -0,0 +1,211 @@
+package hlist
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// registry holds the types that have been registered with RegisterType. It maps names to types for
+// decoding and types to names for encoding.
+var registry = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}{
+	types: make(map[string]reflect.Type),
+	names: make(map[reflect.Type]string),
+}
+
+// typedItem is the JSON representation of an item whose type has been registered.
+type typedItem struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// RegisterType records the type of sample under the provided name so that items of that type keep
+// their original Go type when a list is encoded and then decoded again. The type is registered with
+// encoding/gob as well.
+//
+// When encoding a list to JSON, items of registered types are written as an object holding the name
+// and the value, like {"type": "name", "value": ...}, and all other items are written as their plain
+// JSON values. When decoding, objects in that form with a registered name are decoded back into the
+// registered type, and everything else is decoded with the default rules of encoding/json.
+func RegisterType(name string, sample interface{}) (err error) {
+	if name == "" {
+		return fmt.Errorf("missing type name")
+	} else if sample == nil {
+		return fmt.Errorf("missing sample value")
+	}
+
+	t := reflect.TypeOf(sample)
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if have, ok := registry.types[name]; ok {
+		if have == t {
+			// This exact registration has already been done.
+			return nil
+		}
+		return fmt.Errorf("name %q is already registered for type %v", name, have)
+	}
+	if have, ok := registry.names[t]; ok {
+		return fmt.Errorf("type %v is already registered as %q", t, have)
+	}
+
+	// gob panics if the name or the type has already been registered with it differently.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	gob.RegisterName(name, sample)
+
+	registry.types[name] = t
+	registry.names[t] = name
+
+	return nil
+}
+
+// MarshalJSON encodes the list as a JSON array of its items in order.
+func (l *List) MarshalJSON() ([]byte, error) {
+	if l == nil {
+		return []byte("null"), nil
+	}
+
+	items := make([]interface{}, 0, l.length)
+	for node := l.head; node != nil; node = node.next {
+		item := node.item
+		if name, ok := registeredName(reflect.TypeOf(item)); ok {
+			value, err := json.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			item = typedItem{Type: name, Value: value}
+		}
+		items = append(items, item)
+	}
+
+	return json.Marshal(items)
+}
+
+// UnmarshalJSON decodes a JSON array into the list, replacing any items already in the list.
+func (l *List) UnmarshalJSON(data []byte) error {
+	if l == nil {
+		return errBadList
+	}
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return l.Clear()
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+
+	items := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		item, err := decodeJSONItem(raw)
+		if err != nil {
+			return fmt.Errorf("item %v: %w", i, err)
+		}
+		items[i] = item
+	}
+
+	l.Clear()
+	if len(items) > 0 {
+		l.head, _, l.length = buildChain(items)
+	}
+
+	return nil
+}
+
+// GobEncode encodes the list for use with encoding/gob. The types of all items in the list must be
+// registered with gob, either with RegisterType or with gob.Register.
+func (l *List) GobEncode() ([]byte, error) {
+	if l == nil {
+		return nil, errBadList
+	}
+
+	items := l.Items()
+	if items == nil {
+		items = []interface{}{}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(items); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode decodes a list that was encoded with GobEncode, replacing any items already in the list.
+func (l *List) GobDecode(data []byte) error {
+	if l == nil {
+		return errBadList
+	}
+
+	var items []interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&items); err != nil {
+		return err
+	}
+
+	l.Clear()
+	if len(items) > 0 {
+		l.head, _, l.length = buildChain(items)
+	}
+
+	return nil
+}
+
+// decodeJSONItem decodes a single item from a JSON array.
+func decodeJSONItem(raw json.RawMessage) (interface{}, error) {
+	// Check if this is an item of a registered type. If the object doesn't have exactly the fields
+	// that we wrote out, then it's a regular object that needs to be decoded normally.
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) == nil && len(fields) == 2 {
+		var typed typedItem
+		if err := json.Unmarshal(raw, &typed); err == nil && typed.Value != nil {
+			if t, ok := registeredType(typed.Type); ok {
+				v := reflect.New(t)
+				if err := json.Unmarshal(typed.Value, v.Interface()); err != nil {
+					return nil, err
+				}
+				return v.Elem().Interface(), nil
+			}
+		}
+	}
+
+	var item interface{}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// registeredName gets the name that the type was registered under.
+func registeredName(t reflect.Type) (string, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	name, ok := registry.names[t]
+
+	return name, ok
+}
+
+// registeredType gets the type that was registered under the name.
+func registeredType(name string) (reflect.Type, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	t, ok := registry.types[name]
+
+	return t, ok
+}
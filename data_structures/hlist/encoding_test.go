@@ -0,0 +1,208 @@
+package hlist_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+type encPoint struct {
+	X, Y int
+}
+
+type encJob struct {
+	ID    string
+	Tries int
+}
+
+func init() {
+	if err := hlist.RegisterType("point", encPoint{}); err != nil {
+		panic(err)
+	}
+	if err := hlist.RegisterType("job", &encJob{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	if err := hlist.RegisterType("", 1); err == nil {
+		t.Error("unexpectedly passed RegisterType() test with missing name")
+	}
+	if err := hlist.RegisterType("nothing", nil); err == nil {
+		t.Error("unexpectedly passed RegisterType() test with missing sample")
+	}
+
+	// Registering the same thing again is fine, but reusing a name or a type is not.
+	if err := hlist.RegisterType("point", encPoint{}); err != nil {
+		t.Error(err)
+	}
+	if err := hlist.RegisterType("point", encJob{}); err == nil {
+		t.Error("unexpectedly passed RegisterType() test with duplicate name")
+	}
+	if err := hlist.RegisterType("point2", encPoint{}); err == nil {
+		t.Error("unexpectedly passed RegisterType() test with duplicate type")
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	// Test a nil list.
+	var l *hlist.List
+	if b, err := json.Marshal(l); err != nil || string(b) != "null" {
+		t.Error("Incorrect encoding for nil list")
+		t.Log("\tExpected: null")
+		t.Log("\tReceived:", string(b), err)
+	}
+
+	// Test an empty list.
+	l = hlist.New()
+	checkJSON(t, l, `[]`)
+
+	// Test a list of plain values.
+	l.Append(1, "two", 3.5, nil, []int{4, 5}, map[string]int{"six": 6})
+	checkJSON(t, l, `[1,"two",3.5,null,[4,5],{"six":6}]`)
+
+	// Test a list with registered types.
+	l.Clear()
+	l.Append(encPoint{1, 2}, "plain", &encJob{ID: "a", Tries: 3})
+	checkJSON(t, l, `[{"type":"point","value":{"X":1,"Y":2}},"plain",{"type":"job","value":{"ID":"a","Tries":3}}]`)
+
+	// Test that the list can be nested inside other values.
+	wrapper := struct{ Items *hlist.List }{Items: l}
+	b, err := json.Marshal(wrapper)
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Contains(b, []byte(`"Items":[{"type":"point"`)) {
+		t.Error("List was not encoded as a nested array")
+		t.Log("\tReceived:", string(b))
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	// Test decoding into a nil list.
+	var nl *hlist.List
+	if err := nl.UnmarshalJSON([]byte(`[1]`)); err == nil {
+		t.Error("unexpectedly passed UnmarshalJSON() test with bad pointer")
+	}
+
+	// Test bad input.
+	l := hlist.New()
+	l.Append("keep")
+	if err := json.Unmarshal([]byte(`{"a":1}`), l); err == nil {
+		t.Error("unexpectedly passed UnmarshalJSON() test with non-array")
+	}
+	checkString(t, l, "keep")
+
+	// Test decoding plain values. Numbers come back as float64, like with encoding/json.
+	if err := json.Unmarshal([]byte(`[1, "two", null, [3]]`), l); err != nil {
+		t.Error(err)
+	}
+	checkString(t, l, "1, two, <nil>, [3]")
+	checkLength(t, l, 4)
+	if v := l.Item(0); v != float64(1) {
+		t.Error("Incorrect type for number")
+		t.Log("\tExpected: float64")
+		t.Log("\tReceived:", reflect.TypeOf(v))
+	}
+
+	// Test decoding an object that looks like a typed item but whose type isn't registered.
+	if err := json.Unmarshal([]byte(`[{"type":"unknown","value":1}]`), l); err != nil {
+		t.Error(err)
+	}
+	if _, ok := l.Item(0).(map[string]interface{}); !ok {
+		t.Error("Unregistered object was not decoded as a map")
+		t.Log("\tReceived:", reflect.TypeOf(l.Item(0)))
+	}
+
+	// Test decoding null.
+	if err := json.Unmarshal([]byte(`null`), &l); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	l := hlist.New()
+	l.Append(encPoint{1, 2}, "text", &encJob{ID: "b", Tries: 1}, true, encPoint{-3, 4})
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l2 := hlist.New()
+	if err := json.Unmarshal(b, l2); err != nil {
+		t.Fatal(err)
+	}
+	if !l.Twin(l2) {
+		t.Error("Lists differ after round trip")
+		t.Log("\tExpected:", l)
+		t.Log("\tReceived:", l2)
+	}
+	if _, ok := l2.Item(0).(encPoint); !ok {
+		t.Error("Registered value type was not restored")
+		t.Log("\tReceived:", reflect.TypeOf(l2.Item(0)))
+	}
+	if _, ok := l2.Item(2).(*encJob); !ok {
+		t.Error("Registered pointer type was not restored")
+		t.Log("\tReceived:", reflect.TypeOf(l2.Item(2)))
+	}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	l := hlist.New()
+	l.Append(1, "two", encPoint{3, 4}, &encJob{ID: "c", Tries: 2}, nil)
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(l); err != nil {
+		t.Fatal(err)
+	}
+
+	l2 := hlist.New()
+	l2.Append("old")
+	if err := gob.NewDecoder(buf).Decode(l2); err != nil {
+		t.Fatal(err)
+	}
+	if !l.Twin(l2) {
+		t.Error("Lists differ after round trip")
+		t.Log("\tExpected:", l)
+		t.Log("\tReceived:", l2)
+	}
+	if _, ok := l2.Item(2).(encPoint); !ok {
+		t.Error("Registered type was not restored")
+		t.Log("\tReceived:", reflect.TypeOf(l2.Item(2)))
+	}
+
+	// Test an empty list.
+	buf.Reset()
+	if err := gob.NewEncoder(buf).Encode(hlist.New()); err != nil {
+		t.Fatal(err)
+	}
+	if err := gob.NewDecoder(buf).Decode(l2); err != nil {
+		t.Fatal(err)
+	}
+	checkString(t, l2, "<empty>")
+	checkLength(t, l2, 0)
+
+	// Test an unregistered type.
+	type unregistered struct{ A int }
+	l.Append(unregistered{1})
+	if err := gob.NewEncoder(buf).Encode(l); err == nil {
+		t.Error("unexpectedly passed GobEncode() test with unregistered type")
+	}
+}
+
+func checkJSON(t *testing.T, l *hlist.List, want string) {
+	b, err := json.Marshal(l)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b) != want {
+		t.Error("Incorrect JSON encoding")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", string(b))
+	}
+}
@@ -472,6 +472,16 @@ func buildChain(items []interface{}) (*hnode, *hnode, int) {
 	return anchor.next, tail, num
 }
 
+// newListFrom is an internal convenience function for creating a new list with the items.
+func newListFrom(items []interface{}) *List {
+	l := New()
+	if len(items) > 0 {
+		l.head, _, l.length = buildChain(items)
+	}
+
+	return l
+}
+
 // helper to get the node immediately before the specified index.
 func (l *List) getPrior(index int) (*hnode, error) {
 	if l == nil {
@@ -0,0 +1,283 @@
+package hlist
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// This is the standard error message when trying to use an invalid persistent list.
+var errBadPersistentList = fmt.Errorf("list must be created with NewPersistent() first")
+
+// PersistentList is an immutable linked list. Operations that change the list return a new version of
+// it and leave the original untouched. Nodes are never modified after they are created, so new
+// versions share every node that they have in common with older versions. This makes it cheap to keep
+// many historical versions around at once.
+//
+// Adding to the front of the list and dropping the first item take constant time and share the
+// entire rest of the list. Inserting or removing elsewhere copies the nodes before the index and
+// shares all nodes after it.
+type PersistentList struct {
+	head   *pnode
+	length int
+}
+
+// pnode is an internal type for an individual node in the persistent list.
+type pnode struct {
+	item interface{}
+	next *pnode
+}
+
+// NewPersistent creates a new persistent list holding the items in order.
+func NewPersistent(items ...interface{}) *PersistentList {
+	p := new(PersistentList)
+	for i := len(items) - 1; i >= 0; i-- {
+		p.head = &pnode{item: items[i], next: p.head}
+	}
+	p.length = len(items)
+
+	return p
+}
+
+// String returns a comma-separated list of the string representations of all of the items in the
+// list.
+func (p *PersistentList) String() string {
+	if p == nil {
+		return "<nil>"
+	} else if p.head == nil {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for node := p.head; node != nil; node = node.next {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", node.item))
+	}
+
+	return builder.String()
+}
+
+// Length gets the number of items in the list, or -1 if list hasn't been created yet.
+func (p *PersistentList) Length() int {
+	if p == nil {
+		return -1
+	}
+
+	return p.length
+}
+
+// Prepend returns a new version of the list with the items added to the front. The items keep their
+// order, so the first argument will be the first item in the new list.
+func (p *PersistentList) Prepend(items ...interface{}) (*PersistentList, error) {
+	if p == nil {
+		return nil, errBadPersistentList
+	}
+
+	np := &PersistentList{head: p.head, length: p.length + len(items)}
+	for i := len(items) - 1; i >= 0; i-- {
+		np.head = &pnode{item: items[i], next: np.head}
+	}
+
+	return np, nil
+}
+
+// First gets the first item in the list, or nil if the list is empty.
+func (p *PersistentList) First() interface{} {
+	if p == nil || p.head == nil {
+		return nil
+	}
+
+	return p.head.item
+}
+
+// Tail returns a new version of the list without its first item.
+func (p *PersistentList) Tail() (*PersistentList, error) {
+	if p == nil {
+		return nil, errBadPersistentList
+	} else if p.head == nil {
+		return nil, fmt.Errorf("list is empty")
+	}
+
+	return &PersistentList{head: p.head.next, length: p.length - 1}, nil
+}
+
+// Insert returns a new version of the list with the items inserted at the specified index.
+func (p *PersistentList) Insert(index int, items ...interface{}) (*PersistentList, error) {
+	if p == nil {
+		return nil, errBadPersistentList
+	} else if index < 0 {
+		return nil, fmt.Errorf("invalid index")
+	} else if index > p.length {
+		return nil, fmt.Errorf("out of bounds")
+	}
+
+	// Start with everything from the index onward, which is shared with this version, and then
+	// add the new items and the copied nodes in front of it.
+	rest := p.head
+	for i := 0; i < index; i++ {
+		rest = rest.next
+	}
+
+	np, _ := (&PersistentList{head: rest, length: p.length - index}).Prepend(items...)
+
+	return np.prependCopy(p.head, index), nil
+}
+
+// Remove returns a new version of the list without the item at the specified index.
+func (p *PersistentList) Remove(index int) (*PersistentList, error) {
+	if p == nil {
+		return nil, errBadPersistentList
+	} else if index < 0 {
+		return nil, fmt.Errorf("invalid index")
+	} else if index >= p.length {
+		return nil, fmt.Errorf("out of bounds")
+	}
+
+	rest := p.head
+	for i := 0; i < index; i++ {
+		rest = rest.next
+	}
+
+	np := &PersistentList{head: rest.next, length: p.length - index - 1}
+
+	return np.prependCopy(p.head, index), nil
+}
+
+// Index gets the index of the first matching item, or -1 if not found.
+func (p *PersistentList) Index(item interface{}) int {
+	if p == nil {
+		return -1
+	}
+
+	i := 0
+	for node := p.head; node != nil; node = node.next {
+		if reflect.DeepEqual(node.item, item) {
+			return i
+		}
+		i++
+	}
+
+	// If we're here, then we didn't find anything.
+	return -1
+}
+
+// Item gets the item at the index.
+func (p *PersistentList) Item(index int) interface{} {
+	if p == nil || index < 0 || index >= p.length {
+		return nil
+	}
+
+	node := p.head
+	for i := 0; i < index; i++ {
+		node = node.next
+	}
+
+	return node.item
+}
+
+// Items returns a slice of all items in the list in order.
+func (p *PersistentList) Items() []interface{} {
+	if p == nil || p.head == nil {
+		return nil
+	}
+
+	i := 0
+	items := make([]interface{}, p.length)
+	for node := p.head; node != nil; node = node.next {
+		items[i] = node.item
+		i++
+	}
+
+	return items
+}
+
+// Exists checks whether or not the item exists in the list.
+func (p *PersistentList) Exists(item interface{}) bool {
+	return p.Index(item) >= 0
+}
+
+// Twin checks if the two lists hold the same contents. Unlike List's Twin, two versions that share
+// all of their nodes are still twins, because they can never diverge.
+func (p *PersistentList) Twin(p2 *PersistentList) bool {
+	if p == nil || p2 == nil || p.length != p2.length {
+		return false
+	}
+
+	for node1, node2 := p.head, p2.head; node1 != nil; node1, node2 = node1.next, node2.next {
+		if node1 == node2 {
+			// Everything from here on is shared.
+			return true
+		}
+		if !reflect.DeepEqual(node1.item, node2.item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Yield provides an unbuffered channel that will continually pass successive items until the list
+// is exhausted. The channel quit is used to communicate when iteration should be stopped. Send an
+// empty struct (struct{}{}) on the channel to break the communication. This will happen
+// automatically if the list is exhausted. If this is not needed, pass nil as the argument. Because
+// the list can't change, it is always safe to iterate over it.
+func (p *PersistentList) Yield(quit <-chan struct{}) <-chan interface{} {
+	if p == nil || p.head == nil {
+		return nil
+	}
+
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		for node := p.head; node != nil; node = node.next {
+			select {
+			case ch <- node.item:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// List creates a new mutable List with the same items.
+func (p *PersistentList) List() *List {
+	if p == nil {
+		return nil
+	}
+
+	return newListFrom(p.Items())
+}
+
+// Persistent creates a new persistent list with the same items as the list. Later changes to the
+// list do not affect the persistent list.
+func (l *List) Persistent() (*PersistentList, error) {
+	if l == nil {
+		return nil, errBadList
+	}
+
+	return NewPersistent(l.Items()...), nil
+}
+
+// prependCopy returns a new version of the list with copies of the first n nodes starting at node
+// added to the front.
+func (p *PersistentList) prependCopy(node *pnode, n int) *PersistentList {
+	if n == 0 {
+		return p
+	}
+
+	// Copy the nodes into a new chain, and then link the end of the chain to the current list.
+	anchor := new(pnode)
+	tail := anchor
+	for i := 0; i < n; i++ {
+		tail.next = &pnode{item: node.item}
+		tail = tail.next
+		node = node.next
+	}
+	tail.next = p.head
+
+	return &PersistentList{head: anchor.next, length: p.length + n}
+}
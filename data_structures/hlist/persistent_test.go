@@ -0,0 +1,274 @@
+package hlist_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+func TestPersistentBadPtr(t *testing.T) {
+	var p *hlist.PersistentList
+
+	// Test String().
+	if s := p.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", s)
+	}
+
+	// Test Length().
+	if n := p.Length(); n != -1 {
+		t.Error("unexpectedly passed Length() test with bad pointer")
+	}
+
+	// Test Prepend().
+	if _, err := p.Prepend(1); err == nil {
+		t.Error("unexpectedly passed Prepend() test with bad pointer")
+	}
+
+	// Test First().
+	if v := p.First(); v != nil {
+		t.Error("unexpectedly passed First() test with bad pointer")
+	}
+
+	// Test Tail().
+	if _, err := p.Tail(); err == nil {
+		t.Error("unexpectedly passed Tail() test with bad pointer")
+	}
+
+	// Test Insert().
+	if _, err := p.Insert(0, 1); err == nil {
+		t.Error("unexpectedly passed Insert() test with bad pointer")
+	}
+
+	// Test Remove().
+	if _, err := p.Remove(0); err == nil {
+		t.Error("unexpectedly passed Remove() test with bad pointer")
+	}
+
+	// Test Index().
+	if i := p.Index(1); i != -1 {
+		t.Error("unexpectedly passed Index() test with bad pointer")
+	}
+
+	// Test Item().
+	if v := p.Item(0); v != nil {
+		t.Error("unexpectedly passed Item() test with bad pointer")
+	}
+
+	// Test Items().
+	if v := p.Items(); v != nil {
+		t.Error("unexpectedly passed Items() test with bad pointer")
+	}
+
+	// Test Twin().
+	if p.Twin(hlist.NewPersistent()) {
+		t.Error("unexpectedly passed Twin() test with bad pointer")
+	}
+
+	// Test Yield().
+	if ch := p.Yield(nil); ch != nil {
+		t.Error("unexpectedly passed Yield() test with bad pointer")
+	}
+
+	// Test List().
+	if l := p.List(); l != nil {
+		t.Error("unexpectedly passed List() test with bad pointer")
+	}
+
+	// Test converting a bad List.
+	var l *hlist.List
+	if _, err := l.Persistent(); err == nil {
+		t.Error("unexpectedly passed Persistent() test with bad pointer")
+	}
+}
+
+func TestPersistentBadArgs(t *testing.T) {
+	p := hlist.NewPersistent()
+	checkPersistent(t, p, "<empty>", 0)
+
+	if _, err := p.Tail(); err == nil {
+		t.Error("unexpectedly passed Tail() test for empty list")
+	}
+	if _, err := p.Insert(-1, 1); err == nil {
+		t.Error("unexpectedly passed Insert() test for negative index")
+	}
+	if _, err := p.Insert(1, 1); err == nil {
+		t.Error("unexpectedly passed Insert() test for out-of-range index")
+	}
+	if _, err := p.Remove(0); err == nil {
+		t.Error("unexpectedly passed Remove() test for empty list")
+	}
+	if v := p.First(); v != nil {
+		t.Error("unexpectedly passed First() test for empty list")
+	}
+}
+
+func TestPersistentVersions(t *testing.T) {
+	v0 := hlist.NewPersistent(1, 2, 3)
+	checkPersistent(t, v0, "1, 2, 3", 3)
+
+	// Prepending should not change the original version.
+	v1, err := v0.Prepend("a", "b")
+	if err != nil {
+		t.Error(err)
+	}
+	checkPersistent(t, v1, "a, b, 1, 2, 3", 5)
+	checkPersistent(t, v0, "1, 2, 3", 3)
+
+	// Test Tail() and First().
+	v2, err := v1.Tail()
+	if err != nil {
+		t.Error(err)
+	}
+	checkPersistent(t, v2, "b, 1, 2, 3", 4)
+	if v := v2.First(); v != "b" {
+		t.Error("Incorrect first item")
+		t.Log("\tExpected: b")
+		t.Log("\tReceived:", v)
+	}
+
+	// Test inserting in the middle and at the ends.
+	v3, err := v2.Insert(2, "x", "y")
+	if err != nil {
+		t.Error(err)
+	}
+	checkPersistent(t, v3, "b, 1, x, y, 2, 3", 6)
+	v4, err := v3.Insert(v3.Length(), "end")
+	if err != nil {
+		t.Error(err)
+	}
+	checkPersistent(t, v4, "b, 1, x, y, 2, 3, end", 7)
+	v5, err := v4.Insert(0, "start")
+	if err != nil {
+		t.Error(err)
+	}
+	checkPersistent(t, v5, "start, b, 1, x, y, 2, 3, end", 8)
+
+	// Test removing from the middle and the ends.
+	v6, err := v5.Remove(3)
+	if err != nil {
+		t.Error(err)
+	}
+	checkPersistent(t, v6, "start, b, 1, y, 2, 3, end", 7)
+	v7, err := v6.Remove(0)
+	if err != nil {
+		t.Error(err)
+	}
+	v7, err = v7.Remove(v7.Length() - 1)
+	if err != nil {
+		t.Error(err)
+	}
+	checkPersistent(t, v7, "b, 1, y, 2, 3", 5)
+
+	// Every earlier version should still be intact.
+	checkPersistent(t, v0, "1, 2, 3", 3)
+	checkPersistent(t, v1, "a, b, 1, 2, 3", 5)
+	checkPersistent(t, v2, "b, 1, 2, 3", 4)
+	checkPersistent(t, v3, "b, 1, x, y, 2, 3", 6)
+	checkPersistent(t, v4, "b, 1, x, y, 2, 3, end", 7)
+	checkPersistent(t, v5, "start, b, 1, x, y, 2, 3, end", 8)
+	checkPersistent(t, v6, "start, b, 1, y, 2, 3, end", 7)
+}
+
+func TestPersistentLookup(t *testing.T) {
+	p := hlist.NewPersistent("apples", 1, 3.14, []byte{0xEE})
+
+	if i := p.Index(3.14); i != 2 {
+		t.Error("Incorrect index for 3.14")
+		t.Log("\tExpected: 2")
+		t.Log("\tReceived:", i)
+	}
+	if i := p.Index([]byte{0xEE}); i != 3 {
+		t.Error("Incorrect index for slice")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", i)
+	}
+	if v := p.Item(1); v != 1 {
+		t.Error("Incorrect item at index 1")
+		t.Log("\tExpected: 1")
+		t.Log("\tReceived:", v)
+	}
+	if v := p.Item(4); v != nil {
+		t.Error("unexpectedly passed Item() test for out-of-range index")
+	}
+	if !p.Exists("apples") || p.Exists("oranges") {
+		t.Error("Exists() returned incorrect results")
+	}
+
+	i := 0
+	for v := range p.Yield(nil) {
+		if !reflect.DeepEqual(v, p.Item(i)) {
+			t.Error("Incorrect item yielded at index", i)
+		}
+		i++
+	}
+	if i != 4 {
+		t.Error("Did not receive all items")
+	}
+}
+
+func TestPersistentTwin(t *testing.T) {
+	p1 := hlist.NewPersistent(1, 2, 3)
+	p2 := hlist.NewPersistent(1, 2, 3)
+	if !p1.Twin(p2) {
+		t.Error("Separate lists with same contents are not twins")
+	}
+
+	// Versions that share nodes are twins too.
+	p3, _ := p1.Prepend(0)
+	p4, _ := p3.Tail()
+	if !p1.Twin(p4) {
+		t.Error("Shared versions are not twins")
+	}
+
+	p5, _ := p1.Insert(1, 9)
+	if p1.Twin(p5) {
+		t.Error("Different lists are unexpectedly twins")
+	}
+}
+
+func TestPersistentConversion(t *testing.T) {
+	l := hlist.New()
+	l.Append(1, 2, 3)
+
+	p, err := l.Persistent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPersistent(t, p, "1, 2, 3", 3)
+
+	// Changing the mutable list should not change the persistent list.
+	l.Append(4)
+	l.Remove(0)
+	checkPersistent(t, p, "1, 2, 3", 3)
+
+	// Converting back should give a separate mutable list.
+	nl := p.List()
+	checkString(t, nl, "1, 2, 3")
+	checkLength(t, nl, 3)
+	nl.Append(5)
+	checkPersistent(t, p, "1, 2, 3", 3)
+
+	// Test converting empty lists.
+	p, err = hlist.New().Persistent()
+	if err != nil {
+		t.Error(err)
+	}
+	checkPersistent(t, p, "<empty>", 0)
+	checkString(t, p.List(), "<empty>")
+}
+
+func checkPersistent(t *testing.T, p *hlist.PersistentList, want string, length int) {
+	if p.String() != want {
+		t.Error("List contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", p)
+	}
+	if p.Length() != length {
+		t.Error("Incorrect length")
+		t.Log("\tExpected:", length)
+		t.Log("\tReceived:", p.Length())
+	}
+}
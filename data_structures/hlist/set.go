@@ -0,0 +1,275 @@
+package hlist
+
+import (
+	"reflect"
+)
+
+// The set operations in this file compare items by a key. If a key function is provided, then it is
+// called on each item to get that item's key. Otherwise, the item itself is the key, and items are
+// compared by value: a pointer item is compared by the value that it points to, so two pointers to
+// equal values are duplicates. To compare pointers by address instead, provide a key function that
+// returns the pointer itself. Keys that can be hashed (as for map keys) are looked up in constant time.
+// Keys that can't be hashed, such as slices, maps, and structs or arrays holding them, are compared with
+// reflect.DeepEqual, which takes linear time.
+
+// pointerKey is an internal type for the key of a pointer item. It keeps the pointer's type so that a
+// pointer doesn't match a plain value that is equal to what it points to.
+type pointerKey struct {
+	t reflect.Type
+	v interface{}
+}
+
+// keySet is an internal type that tracks which keys have been seen.
+type keySet struct {
+	key    func(item interface{}) interface{}
+	seen   map[interface{}]struct{}
+	others []interface{}
+}
+
+// Unique removes all items with duplicate keys from the list, keeping the first occurrence of each
+// item in its original position. key can be nil to compare the items themselves.
+func (l *List) Unique(key func(item interface{}) interface{}) error {
+	if l == nil {
+		return errBadList
+	}
+
+	set := newKeySet(key)
+	prev := (*hnode)(nil)
+	for node := l.head; node != nil; node = node.next {
+		if set.add(node.item) {
+			prev = node
+			continue
+		}
+
+		// This is a duplicate. Unlink it from the list.
+		prev.next = node.next
+		l.length--
+	}
+
+	return nil
+}
+
+// Union returns a new list with every unique item from both lists. Items from the current list come
+// first in their original order, followed by the items from list2 that are not in the current list.
+// key can be nil to compare the items themselves.
+func (l *List) Union(list2 *List, key func(item interface{}) interface{}) (*List, error) {
+	if l == nil || list2 == nil {
+		return nil, errBadList
+	}
+
+	set := newKeySet(key)
+	var items []interface{}
+	for _, list := range []*List{l, list2} {
+		for node := list.head; node != nil; node = node.next {
+			if set.add(node.item) {
+				items = append(items, node.item)
+			}
+		}
+	}
+
+	return newListFrom(items), nil
+}
+
+// Intersect returns a new list with the unique items from the current list that are also in list2,
+// in their original order. key can be nil to compare the items themselves.
+func (l *List) Intersect(list2 *List, key func(item interface{}) interface{}) (*List, error) {
+	if l == nil || list2 == nil {
+		return nil, errBadList
+	}
+
+	other := newKeySet(key)
+	other.addList(list2)
+
+	set := newKeySet(key)
+	var items []interface{}
+	for node := l.head; node != nil; node = node.next {
+		if other.has(node.item) && set.add(node.item) {
+			items = append(items, node.item)
+		}
+	}
+
+	return newListFrom(items), nil
+}
+
+// Difference returns a new list with the unique items from the current list that are not in list2,
+// in their original order. key can be nil to compare the items themselves.
+func (l *List) Difference(list2 *List, key func(item interface{}) interface{}) (*List, error) {
+	if l == nil || list2 == nil {
+		return nil, errBadList
+	}
+
+	// Marking everything in the second list as already seen will keep it out of the new list.
+	set := newKeySet(key)
+	set.addList(list2)
+
+	var items []interface{}
+	for node := l.head; node != nil; node = node.next {
+		if set.add(node.item) {
+			items = append(items, node.item)
+		}
+	}
+
+	return newListFrom(items), nil
+}
+
+// SymmetricDifference returns a new list with the unique items that are in only one of the two
+// lists. Items from the current list come first in their original order, followed by the items from
+// list2. key can be nil to compare the items themselves.
+func (l *List) SymmetricDifference(list2 *List, key func(item interface{}) interface{}) (*List, error) {
+	left, err := l.Difference(list2, key)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := list2.Difference(l, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := left.Merge(right); err != nil {
+		return nil, err
+	}
+
+	return left, nil
+}
+
+// SetEqual checks if the two lists hold the same set of items, regardless of order or duplicates.
+// key can be nil to compare the items themselves.
+func (l *List) SetEqual(list2 *List, key func(item interface{}) interface{}) bool {
+	if l == nil || list2 == nil {
+		return false
+	}
+
+	left := newKeySet(key)
+	left.addList(l)
+	right := newKeySet(key)
+	right.addList(list2)
+
+	if left.count() != right.count() {
+		return false
+	}
+
+	for node := l.head; node != nil; node = node.next {
+		if !right.has(node.item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newKeySet is an internal convenience function for creating a new key set.
+func newKeySet(key func(item interface{}) interface{}) *keySet {
+	set := new(keySet)
+	set.key = key
+	set.seen = make(map[interface{}]struct{})
+
+	return set
+}
+
+// add adds the item's key to the set. It returns true if the key was not already in the set.
+func (s *keySet) add(item interface{}) bool {
+	k := s.keyOf(item)
+	if isHashable(k) {
+		if _, ok := s.seen[k]; ok {
+			return false
+		}
+		s.seen[k] = struct{}{}
+		return true
+	}
+
+	for _, other := range s.others {
+		if reflect.DeepEqual(k, other) {
+			return false
+		}
+	}
+	s.others = append(s.others, k)
+
+	return true
+}
+
+// addList adds the keys of all items in the list to the set.
+func (s *keySet) addList(l *List) {
+	for node := l.head; node != nil; node = node.next {
+		s.add(node.item)
+	}
+}
+
+// has checks whether or not the item's key is in the set.
+func (s *keySet) has(item interface{}) bool {
+	k := s.keyOf(item)
+	if isHashable(k) {
+		_, ok := s.seen[k]
+		return ok
+	}
+
+	for _, other := range s.others {
+		if reflect.DeepEqual(k, other) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// count gets the number of unique keys in the set.
+func (s *keySet) count() int {
+	return len(s.seen) + len(s.others)
+}
+
+// keyOf gets the key for the item. Without a key function, a pointer item's key is the value that it
+// points to.
+func (s *keySet) keyOf(item interface{}) interface{} {
+	if s.key != nil {
+		return s.key(item)
+	}
+
+	v := reflect.ValueOf(item)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return item
+	}
+
+	return pointerKey{t: v.Type(), v: v.Elem().Interface()}
+}
+
+// isHashable checks whether or not the value can be used as a map key. Unlike reflect.Type's
+// Comparable, this looks at what is actually stored in any interface fields, because a struct or array
+// that is comparable by type will still panic when hashed if one of its interfaces holds a slice or map.
+func isHashable(v interface{}) bool {
+	if k, ok := v.(pointerKey); ok {
+		return isHashable(k.v)
+	}
+
+	return hashable(reflect.ValueOf(v))
+}
+
+// hashable is the recursive part of isHashable.
+func hashable(v reflect.Value) bool {
+	if v.IsValid() && !v.Type().Comparable() {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		// This is a nil interface.
+		return true
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false
+	case reflect.Interface:
+		return hashable(v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !hashable(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !hashable(v.Field(i)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,289 @@
+package hlist_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+type setEvent struct {
+	ID     int
+	Source string
+}
+
+// eventID is a key function that compares events by ID only.
+func eventID(item interface{}) interface{} {
+	return item.(setEvent).ID
+}
+
+func TestSetBadPtr(t *testing.T) {
+	var l *hlist.List
+
+	if err := l.Unique(nil); err == nil {
+		t.Error("unexpectedly passed Unique() test with bad pointer")
+	}
+	if _, err := l.Union(hlist.New(), nil); err == nil {
+		t.Error("unexpectedly passed Union() test with bad pointer")
+	}
+	if _, err := l.Intersect(hlist.New(), nil); err == nil {
+		t.Error("unexpectedly passed Intersect() test with bad pointer")
+	}
+	if _, err := l.Difference(hlist.New(), nil); err == nil {
+		t.Error("unexpectedly passed Difference() test with bad pointer")
+	}
+	if _, err := l.SymmetricDifference(hlist.New(), nil); err == nil {
+		t.Error("unexpectedly passed SymmetricDifference() test with bad pointer")
+	}
+	if l.SetEqual(hlist.New(), nil) {
+		t.Error("unexpectedly passed SetEqual() test with bad pointer")
+	}
+
+	// Test passing a bad list as the argument.
+	l = hlist.New()
+	if _, err := l.Union(nil, nil); err == nil {
+		t.Error("unexpectedly passed Union() test with bad argument")
+	}
+	if _, err := l.SymmetricDifference(nil, nil); err == nil {
+		t.Error("unexpectedly passed SymmetricDifference() test with bad argument")
+	}
+}
+
+func TestUnique(t *testing.T) {
+	l := hlist.New()
+
+	// Test an empty list.
+	if err := l.Unique(nil); err != nil {
+		t.Error(err)
+	}
+	checkString(t, l, "<empty>")
+	checkLength(t, l, 0)
+
+	// Test comparable items. The first occurrence of each item should be kept.
+	l.Append(3, 1, 3, "a", 2, 1, "a", 3, nil, nil)
+	if err := l.Unique(nil); err != nil {
+		t.Error(err)
+	}
+	checkString(t, l, "3, 1, a, 2, <nil>")
+	checkLength(t, l, 5)
+
+	// Test items that aren't comparable.
+	l.Clear()
+	l.Append([]int{1, 2}, map[string]int{"a": 1}, []int{1, 2}, []int{2, 1}, map[string]int{"a": 1})
+	if err := l.Unique(nil); err != nil {
+		t.Error(err)
+	}
+	checkString(t, l, "[1 2], map[a:1], [2 1]")
+	checkLength(t, l, 3)
+
+	// Test items that are comparable by type but hold slices in their interfaces. These can't be used as
+	// map keys, so they must be compared deeply instead.
+	type wrapper struct {
+		V interface{}
+	}
+	l.Clear()
+	l.Append(wrapper{[]int{1}}, wrapper{[]int{1}}, [1]interface{}{[]int{2}}, [1]interface{}{[]int{2}}, wrapper{3}, wrapper{3})
+	if err := l.Unique(nil); err != nil {
+		t.Error(err)
+	}
+	checkString(t, l, "{[1]}, [[2]], {3}")
+	checkLength(t, l, 3)
+
+	// Test pointers. Items are compared by value, so pointers to equal values are duplicates, but a
+	// pointer is not a duplicate of the plain value.
+	a, b, c := setEvent{1, "a"}, setEvent{1, "a"}, setEvent{2, "c"}
+	s1, s2 := []int{1}, []int{1}
+	l.Clear()
+	l.Append(&a, &b, &c, a, &s1, &s2)
+	if err := l.Unique(nil); err != nil {
+		t.Error(err)
+	}
+	checkLength(t, l, 4)
+	if v := l.Item(0); v != &a {
+		t.Error("First pointer was not kept")
+	}
+
+	// To compare by address, the pointer itself can be the key.
+	l.Clear()
+	l.Append(&a, &b, &a)
+	if err := l.Unique(func(item interface{}) interface{} { return item }); err != nil {
+		t.Error(err)
+	}
+	checkLength(t, l, 2)
+
+	// Test using a key function.
+	l.Clear()
+	l.Append("Apple", "banana", "APPLE", "Banana", "cherry")
+	if err := l.Unique(func(item interface{}) interface{} {
+		return strings.ToLower(item.(string))
+	}); err != nil {
+		t.Error(err)
+	}
+	checkString(t, l, "Apple, banana, cherry")
+	checkLength(t, l, 3)
+
+	// Make sure the list still works normally.
+	l.Append("date")
+	checkString(t, l, "Apple, banana, cherry, date")
+	checkLength(t, l, 4)
+}
+
+func TestUnion(t *testing.T) {
+	l1 := hlist.New()
+	l1.Append(1, 2, 2, 3)
+	l2 := hlist.New()
+	l2.Append(4, 3, 5, 4)
+
+	nl, err := l1.Union(l2, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "1, 2, 3, 4, 5")
+	checkLength(t, nl, 5)
+
+	// The original lists should not be changed.
+	checkString(t, l1, "1, 2, 2, 3")
+	checkString(t, l2, "4, 3, 5, 4")
+
+	// Test merging events from two sources by ID.
+	a := hlist.New()
+	a.Append(setEvent{1, "a"}, setEvent{2, "a"})
+	b := hlist.New()
+	b.Append(setEvent{2, "b"}, setEvent{3, "b"}, setEvent{1, "b"})
+	nl, err = a.Union(b, eventID)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "{1 a}, {2 a}, {3 b}")
+
+	// Test empty lists.
+	nl, err = hlist.New().Union(hlist.New(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "<empty>")
+	checkLength(t, nl, 0)
+}
+
+func TestIntersect(t *testing.T) {
+	l1 := hlist.New()
+	l1.Append(5, 1, 4, 1, 2, 3)
+	l2 := hlist.New()
+	l2.Append(3, 1, 3, 9, 5)
+
+	nl, err := l1.Intersect(l2, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "5, 1, 3")
+	checkLength(t, nl, 3)
+
+	// Test non-comparable items.
+	l1.Clear()
+	l1.Append([]string{"x"}, []string{"y"})
+	l2.Clear()
+	l2.Append([]string{"y"}, []string{"z"})
+	nl, err = l1.Intersect(l2, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "[y]")
+
+	// Test no overlap.
+	l1.Clear()
+	l1.Append(1, 2)
+	l2.Clear()
+	l2.Append(3, 4)
+	nl, err = l1.Intersect(l2, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "<empty>")
+}
+
+func TestDifference(t *testing.T) {
+	l1 := hlist.New()
+	l1.Append(1, 2, 3, 2, 4, 5)
+	l2 := hlist.New()
+	l2.Append(4, 1, 6)
+
+	nl, err := l1.Difference(l2, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "2, 3, 5")
+	checkLength(t, nl, 3)
+
+	// Test with a key function.
+	a := hlist.New()
+	a.Append(setEvent{1, "a"}, setEvent{2, "a"}, setEvent{3, "a"})
+	b := hlist.New()
+	b.Append(setEvent{2, "b"})
+	nl, err = a.Difference(b, eventID)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "{1 a}, {3 a}")
+
+	// Test the difference of a list with itself.
+	nl, err = l1.Difference(l1, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "<empty>")
+	checkString(t, l1, "1, 2, 3, 2, 4, 5")
+}
+
+func TestSymmetricDifference(t *testing.T) {
+	l1 := hlist.New()
+	l1.Append(1, 2, 3, 3)
+	l2 := hlist.New()
+	l2.Append(4, 3, 2, 5, 5)
+
+	nl, err := l1.SymmetricDifference(l2, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	checkString(t, nl, "1, 4, 5")
+	checkLength(t, nl, 3)
+
+	// The original lists should not be changed.
+	checkString(t, l1, "1, 2, 3, 3")
+	checkString(t, l2, "4, 3, 2, 5, 5")
+}
+
+func TestSetEqual(t *testing.T) {
+	l1 := hlist.New()
+	l1.Append(1, 2, 3, 1)
+	l2 := hlist.New()
+	l2.Append(3, 3, 2, 1)
+
+	if !l1.SetEqual(l2, nil) {
+		t.Error("Lists unexpectedly differ")
+	}
+
+	l2.Append(4)
+	if l1.SetEqual(l2, nil) {
+		t.Error("Lists are unexpectedly equal")
+	}
+
+	// Test with a key function.
+	a := hlist.New()
+	a.Append(setEvent{1, "a"}, setEvent{2, "a"})
+	b := hlist.New()
+	b.Append(setEvent{2, "b"}, setEvent{1, "b"})
+	if !a.SetEqual(b, eventID) {
+		t.Error("Lists unexpectedly differ by key")
+	}
+	if a.SetEqual(b, nil) {
+		t.Error("Lists are unexpectedly equal by value")
+	}
+
+	// Test empty lists.
+	if !hlist.New().SetEqual(hlist.New(), nil) {
+		t.Error("Empty lists unexpectedly differ")
+	}
+	if l1.SetEqual(nil, nil) {
+		t.Error("unexpectedly passed SetEqual() test with bad argument")
+	}
+}
@@ -0,0 +1,367 @@
+package hlist
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// This is the maximum number of levels that a skip list can have. With a promotion probability
+	// of 1/2, this is enough for 2^32 items.
+	skipMaxLevel = 32
+	// This is the probability (out of 100) that a node is promoted up to the next level.
+	skipPromoteChance = 50
+)
+
+// This is the standard error message when trying to use an invalid skip list.
+var errBadSkipList = fmt.Errorf("list must be created with NewSkipList() first")
+
+// SkipList is a linked list that keeps its items sorted according to a comparison function. Besides
+// the base level that links every node together, nodes are randomly promoted to higher levels that
+// skip over large portions of the list, which gives expected logarithmic time for inserting,
+// removing, searching, and indexing. Each link also records how many positions it spans, which lets
+// the list find an item's position (and the item at a position) without walking every node.
+type SkipList struct {
+	head   *snode
+	level  int
+	length int
+	less   func(left, right interface{}) bool
+	rng    *rand.Rand
+}
+
+// snode is an internal type for an individual node in the skip list. next[i] is the next node on
+// level i, and span[i] is the number of positions between this node and next[i].
+type snode struct {
+	item interface{}
+	next []*snode
+	span []int
+}
+
+// NewSkipList creates a new skip list. The comparison function less should return true only if left
+// should be sorted before right. Two items are considered equal if neither sorts before the other.
+func NewSkipList(less func(left, right interface{}) bool) (*SkipList, error) {
+	if less == nil {
+		return nil, fmt.Errorf("missing comparison callback")
+	}
+
+	l := new(SkipList)
+	l.head = newSkipNode(nil, skipMaxLevel)
+	l.level = 1
+	l.less = less
+	l.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	return l, nil
+}
+
+// String returns a comma-separated list of the string representations of all of the items in the
+// skip list, in sorted order.
+func (l *SkipList) String() string {
+	if l == nil || l.head == nil {
+		return "<nil>"
+	} else if l.length == 0 {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for node := l.head.next[0]; node != nil; node = node.next[0] {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", node.item))
+	}
+
+	return builder.String()
+}
+
+// Length gets the number of items in the skip list, or -1 if list hasn't been created yet.
+func (l *SkipList) Length() int {
+	if l == nil || l.head == nil {
+		return -1
+	}
+
+	return l.length
+}
+
+// Insert adds one or more items to the skip list in their sorted positions. If an item is equal to
+// items already in the list, then it is placed after all of them.
+func (l *SkipList) Insert(items ...interface{}) error {
+	if l == nil || l.head == nil {
+		return errBadSkipList
+	}
+
+	// Make sure that none of the items is this list itself.
+	for _, v := range items {
+		if nl, ok := v.(*SkipList); ok && l == nl {
+			return fmt.Errorf("can't add list to itself")
+		}
+	}
+
+	for _, item := range items {
+		l.insert(item)
+	}
+
+	return nil
+}
+
+// Remove removes the first item in the skip list that is equal to item and returns its value. If no
+// item matches, then this returns nil.
+func (l *SkipList) Remove(item interface{}) interface{} {
+	if l == nil || l.head == nil {
+		return nil
+	}
+
+	update, _ := l.findPrior(item)
+	node := update[0].next[0]
+	if node == nil || !l.equal(node.item, item) {
+		return nil
+	}
+
+	l.unlink(node, update)
+
+	return node.item
+}
+
+// Search finds the first item in the skip list that is equal to item. It returns the stored item and
+// true if found, or nil and false if not.
+func (l *SkipList) Search(item interface{}) (interface{}, bool) {
+	if l == nil || l.head == nil {
+		return nil, false
+	}
+
+	update, _ := l.findPrior(item)
+	node := update[0].next[0]
+	if node == nil || !l.equal(node.item, item) {
+		return nil, false
+	}
+
+	return node.item, true
+}
+
+// Rank gets the number of items in the skip list that sort before item. If item is in the list, then
+// this is the index of its first occurrence. If it isn't, then this is the index where it would be
+// inserted. This returns -1 if the list hasn't been created yet.
+func (l *SkipList) Rank(item interface{}) int {
+	if l == nil || l.head == nil {
+		return -1
+	}
+
+	_, rank := l.findPrior(item)
+
+	return rank[0]
+}
+
+// ItemAt gets the item at the index in sorted order, or nil if the index is out of bounds.
+func (l *SkipList) ItemAt(index int) interface{} {
+	if l == nil || l.head == nil || index < 0 || index >= l.length {
+		return nil
+	}
+
+	// Positions are counted from the head node, so the item at index 0 is at position 1.
+	want := index + 1
+	pos := 0
+	node := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && pos+node.span[i] <= want {
+			pos += node.span[i]
+			node = node.next[i]
+		}
+		if pos == want {
+			break
+		}
+	}
+
+	return node.item
+}
+
+// Items returns a slice of all items in the skip list in sorted order.
+func (l *SkipList) Items() []interface{} {
+	if l == nil || l.head == nil || l.length == 0 {
+		return nil
+	}
+
+	i := 0
+	items := make([]interface{}, l.length)
+	for node := l.head.next[0]; node != nil; node = node.next[0] {
+		items[i] = node.item
+		i++
+	}
+
+	return items
+}
+
+// Clear resets the skip list to its initial state. The comparison function is kept.
+func (l *SkipList) Clear() error {
+	if l == nil || l.head == nil {
+		return errBadSkipList
+	}
+
+	l.head = newSkipNode(nil, skipMaxLevel)
+	l.level = 1
+	l.length = 0
+
+	return nil
+}
+
+// Yield provides an unbuffered channel that will continually pass successive items in sorted order
+// until the list is exhausted. The channel quit is used to communicate when iteration should be
+// stopped. Send an empty struct (struct{}{}) on the channel to break the communication. This will
+// happen automatically if the list is exhausted. If this is not needed, pass nil as the argument.
+func (l *SkipList) Yield(quit <-chan struct{}) <-chan interface{} {
+	if l == nil || l.head == nil || l.length == 0 {
+		return nil
+	}
+
+	return l.yieldFrom(l.head.next[0], nil, quit)
+}
+
+// YieldRange works like Yield, but it only passes the items that are equal to or greater than low
+// and less than high. Finding the start of the range takes logarithmic time.
+func (l *SkipList) YieldRange(low, high interface{}, quit <-chan struct{}) <-chan interface{} {
+	if l == nil || l.head == nil || l.length == 0 {
+		return nil
+	}
+
+	update, _ := l.findPrior(low)
+
+	return l.yieldFrom(update[0].next[0], high, quit)
+}
+
+// yieldFrom sends the items on the channel starting at the node and continuing until either the end
+// of the list or the first item that is not less than high. If high is nil, then there is no upper
+// bound.
+func (l *SkipList) yieldFrom(start *snode, high interface{}, quit <-chan struct{}) <-chan interface{} {
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		for node := start; node != nil; node = node.next[0] {
+			if high != nil && !l.less(node.item, high) {
+				return
+			}
+
+			select {
+			case ch <- node.item:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// insert adds a single item to the skip list after any items equal to it.
+func (l *SkipList) insert(item interface{}) {
+	// Walk down the levels, keeping track of the last node on each level that comes before our new
+	// node as well as the position of that node.
+	var update [skipMaxLevel]*snode
+	var rank [skipMaxLevel]int
+	node := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for node.next[i] != nil && !l.less(item, node.next[i].item) {
+			rank[i] += node.span[i]
+			node = node.next[i]
+		}
+		update[i] = node
+	}
+
+	// If the new node is taller than any other node, then the head needs to link to it directly on
+	// the new levels.
+	level := l.randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = l.head
+			update[i].span[i] = l.length
+		}
+		l.level = level
+	}
+
+	// Link in the new node on each of its levels, splitting the span of the link that it's being
+	// placed inside of.
+	node = newSkipNode(item, level)
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+
+		node.span[i] = update[i].span[i] - (rank[0] - rank[i])
+		update[i].span[i] = (rank[0] - rank[i]) + 1
+	}
+
+	// The links above the new node now skip over one more position.
+	for i := level; i < l.level; i++ {
+		update[i].span[i]++
+	}
+
+	l.length++
+}
+
+// findPrior walks down the levels and finds the last node on each level whose item sorts before
+// item. It also returns the position of each of those nodes, where the head is at position 0.
+func (l *SkipList) findPrior(item interface{}) ([skipMaxLevel]*snode, [skipMaxLevel]int) {
+	var update [skipMaxLevel]*snode
+	var rank [skipMaxLevel]int
+	node := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for node.next[i] != nil && l.less(node.next[i].item, item) {
+			rank[i] += node.span[i]
+			node = node.next[i]
+		}
+		update[i] = node
+	}
+
+	return update, rank
+}
+
+// unlink removes the node from every level of the skip list. update must hold the last node before
+// node on each level, as returned by findPrior.
+func (l *SkipList) unlink(node *snode, update [skipMaxLevel]*snode) {
+	for i := 0; i < l.level; i++ {
+		if update[i].next[i] == node {
+			update[i].span[i] += node.span[i] - 1
+			update[i].next[i] = node.next[i]
+		} else {
+			update[i].span[i]--
+		}
+	}
+
+	// Drop any levels that are now empty.
+	for l.level > 1 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+
+	l.length--
+}
+
+// equal checks whether or not the two items are equal according to the comparison function.
+func (l *SkipList) equal(left, right interface{}) bool {
+	return !l.less(left, right) && !l.less(right, left)
+}
+
+// randomLevel picks how many levels a new node will be linked into.
+func (l *SkipList) randomLevel() int {
+	level := 1
+	for level < skipMaxLevel && l.rng.Intn(100) < skipPromoteChance {
+		level++
+	}
+
+	return level
+}
+
+// newSkipNode is an internal convenience function for creating a new skip list node with the
+// provided number of levels.
+func newSkipNode(item interface{}, levels int) *snode {
+	node := new(snode)
+	node.item = item
+	node.next = make([]*snode, levels)
+	node.span = make([]int, levels)
+
+	return node
+}
@@ -0,0 +1,398 @@
+package hlist_test
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+func TestSkipListBadPtr(t *testing.T) {
+	var l *hlist.SkipList
+
+	// Test String().
+	if s := l.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", s)
+	}
+
+	// Test Length().
+	if n := l.Length(); n != -1 {
+		t.Error("unexpectedly passed Length() test with bad pointer")
+		t.Log("\tExpected: -1")
+		t.Log("\tReceived:", n)
+	}
+
+	// Test Insert().
+	if err := l.Insert(1); err == nil {
+		t.Error("unexpectedly passed Insert() test with bad pointer")
+	}
+
+	// Test Remove().
+	if v := l.Remove(1); v != nil {
+		t.Error("unexpectedly passed Remove() test with bad pointer")
+	}
+
+	// Test Search().
+	if _, ok := l.Search(1); ok {
+		t.Error("unexpectedly passed Search() test with bad pointer")
+	}
+
+	// Test Rank().
+	if n := l.Rank(1); n != -1 {
+		t.Error("unexpectedly passed Rank() test with bad pointer")
+	}
+
+	// Test ItemAt().
+	if v := l.ItemAt(0); v != nil {
+		t.Error("unexpectedly passed ItemAt() test with bad pointer")
+	}
+
+	// Test Items().
+	if v := l.Items(); v != nil {
+		t.Error("unexpectedly passed Items() test with bad pointer")
+	}
+
+	// Test Clear().
+	if err := l.Clear(); err == nil {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+
+	// Test Yield().
+	if ch := l.Yield(nil); ch != nil {
+		t.Error("unexpectedly passed Yield() test with bad pointer")
+	}
+
+	// Test YieldRange().
+	if ch := l.YieldRange(0, 1, nil); ch != nil {
+		t.Error("unexpectedly passed YieldRange() test with bad pointer")
+	}
+}
+
+func TestSkipListBadArgs(t *testing.T) {
+	if _, err := hlist.NewSkipList(nil); err == nil {
+		t.Error("unexpectedly passed NewSkipList() test for missing comparison callback")
+	}
+
+	l := newIntSkipList(t)
+	checkSkipString(t, l, "<empty>")
+	checkSkipLength(t, l, 0)
+
+	if err := l.Insert(l); err == nil {
+		t.Error("unexpectedly passed Insert() test for adding list to itself")
+	}
+	if v := l.Remove(5); v != nil {
+		t.Error("unexpectedly passed Remove() test for empty list")
+	}
+	if v := l.ItemAt(0); v != nil {
+		t.Error("unexpectedly passed ItemAt() test for empty list")
+	}
+
+	l.Insert(1, 2, 3)
+	if v := l.ItemAt(-1); v != nil {
+		t.Error("unexpectedly passed ItemAt() test for negative index")
+	}
+	if v := l.ItemAt(3); v != nil {
+		t.Error("unexpectedly passed ItemAt() test for out-of-range index")
+	}
+	checkSkipString(t, l, "1, 2, 3")
+	checkSkipLength(t, l, 3)
+}
+
+func TestSkipListInsert(t *testing.T) {
+	l := newIntSkipList(t)
+
+	if err := l.Insert(5); err != nil {
+		t.Error(err)
+	}
+	checkSkipString(t, l, "5")
+	checkSkipLength(t, l, 1)
+
+	if err := l.Insert(3, 9, 1, 7); err != nil {
+		t.Error(err)
+	}
+	checkSkipString(t, l, "1, 3, 5, 7, 9")
+	checkSkipLength(t, l, 5)
+
+	// Duplicates are allowed and are kept in insertion order.
+	if err := l.Insert(5, 0, 10); err != nil {
+		t.Error(err)
+	}
+	checkSkipString(t, l, "0, 1, 3, 5, 5, 7, 9, 10")
+	checkSkipLength(t, l, 8)
+}
+
+func TestSkipListStable(t *testing.T) {
+	// Sort pairs by their first value only. Pairs with the same first value should come out in the
+	// order they went in.
+	type pair struct{ key, order int }
+	l, err := hlist.NewSkipList(func(left, right interface{}) bool {
+		return left.(pair).key < right.(pair).key
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Insert(pair{2, 0}, pair{1, 1}, pair{2, 2}, pair{1, 3}, pair{2, 4})
+	checkSkipString(t, l, "{1 1}, {1 3}, {2 0}, {2 2}, {2 4}")
+
+	// Removing and searching should act on the first matching item.
+	if v, ok := l.Search(pair{2, -1}); !ok || v != (pair{2, 0}) {
+		t.Error("Incorrect search result")
+		t.Log("\tExpected: {2 0}")
+		t.Log("\tReceived:", v)
+	}
+	if v := l.Remove(pair{1, -1}); v != (pair{1, 1}) {
+		t.Error("Incorrect item removed")
+		t.Log("\tExpected: {1 1}")
+		t.Log("\tReceived:", v)
+	}
+	checkSkipString(t, l, "{1 3}, {2 0}, {2 2}, {2 4}")
+}
+
+func TestSkipListRemove(t *testing.T) {
+	l := newIntSkipList(t)
+	l.Insert(4, 2, 8, 6, 10)
+
+	if v := l.Remove(6); v != 6 {
+		t.Error("Incorrect item removed")
+		t.Log("\tExpected: 6")
+		t.Log("\tReceived:", v)
+	}
+	checkSkipString(t, l, "2, 4, 8, 10")
+	checkSkipLength(t, l, 4)
+
+	// Try to remove an item that doesn't exist.
+	if v := l.Remove(5); v != nil {
+		t.Error("Unexpectedly removed non-existent item")
+		t.Log("\tReceived:", v)
+	}
+	checkSkipString(t, l, "2, 4, 8, 10")
+	checkSkipLength(t, l, 4)
+
+	// Remove the first and last items.
+	l.Remove(2)
+	l.Remove(10)
+	checkSkipString(t, l, "4, 8")
+	checkSkipLength(t, l, 2)
+
+	l.Remove(4)
+	l.Remove(8)
+	checkSkipString(t, l, "<empty>")
+	checkSkipLength(t, l, 0)
+
+	// Make sure the list still works after being emptied.
+	l.Insert(3, 1, 2)
+	checkSkipString(t, l, "1, 2, 3")
+	checkSkipLength(t, l, 3)
+}
+
+func TestSkipListSearch(t *testing.T) {
+	l := newIntSkipList(t)
+	l.Insert(10, 20, 30)
+
+	if v, ok := l.Search(20); !ok || v != 20 {
+		t.Error("Failed to find 20")
+		t.Log("\tReceived:", v, ok)
+	}
+	if v, ok := l.Search(25); ok || v != nil {
+		t.Error("Unexpectedly found 25")
+		t.Log("\tReceived:", v, ok)
+	}
+	if _, ok := l.Search(5); ok {
+		t.Error("Unexpectedly found 5")
+	}
+	if _, ok := l.Search(35); ok {
+		t.Error("Unexpectedly found 35")
+	}
+}
+
+func TestSkipListRank(t *testing.T) {
+	l := newIntSkipList(t)
+	l.Insert(10, 20, 20, 30)
+
+	tests := []struct {
+		item int
+		want int
+	}{
+		{5, 0},
+		{10, 0},
+		{15, 1},
+		{20, 1},
+		{25, 3},
+		{30, 3},
+		{35, 4},
+	}
+	for _, test := range tests {
+		if n := l.Rank(test.item); n != test.want {
+			t.Error("Incorrect rank for", test.item)
+			t.Log("\tExpected:", test.want)
+			t.Log("\tReceived:", n)
+		}
+	}
+}
+
+func TestSkipListItemAt(t *testing.T) {
+	l := newIntSkipList(t)
+	l.Insert(50, 10, 40, 20, 30)
+
+	for i, want := range []int{10, 20, 30, 40, 50} {
+		if v := l.ItemAt(i); v != want {
+			t.Error("Incorrect item at index", i)
+			t.Log("\tExpected:", want)
+			t.Log("\tReceived:", v)
+		}
+	}
+}
+
+func TestSkipListYield(t *testing.T) {
+	l := newIntSkipList(t)
+	l.Insert(5, 1, 4, 2, 3)
+
+	i := 1
+	for v := range l.Yield(nil) {
+		if v != i {
+			t.Error("Incorrect item yielded")
+			t.Log("\tExpected:", i)
+			t.Log("\tReceived:", v)
+		}
+		i++
+	}
+	if i != 6 {
+		t.Error("Did not receive all items")
+	}
+
+	// Test yielding a range.
+	var received []int
+	for v := range l.YieldRange(2, 5, nil) {
+		received = append(received, v.(int))
+	}
+	if len(received) != 3 || received[0] != 2 || received[1] != 3 || received[2] != 4 {
+		t.Error("Incorrect range yielded")
+		t.Log("\tExpected: [2 3 4]")
+		t.Log("\tReceived:", received)
+	}
+
+	// Test a range that doesn't overlap the list.
+	received = nil
+	for v := range l.YieldRange(10, 20, nil) {
+		received = append(received, v.(int))
+	}
+	if len(received) != 0 {
+		t.Error("Unexpectedly yielded items outside of the range")
+		t.Log("\tReceived:", received)
+	}
+
+	// Test stopping early.
+	quit := make(chan struct{})
+	ch := l.Yield(quit)
+	<-ch
+	close(quit)
+	for range ch {
+		// Drain anything that was in flight.
+	}
+}
+
+func TestSkipListRandom(t *testing.T) {
+	// Compare a large number of random operations against a sorted slice.
+	l := newIntSkipList(t)
+	var want []int
+
+	for i := 0; i < 5000; i++ {
+		n := rand.Intn(1000)
+		if rand.Intn(3) == 0 && len(want) > 0 {
+			// Remove a random item that exists.
+			n = want[rand.Intn(len(want))]
+			if v := l.Remove(n); v != n {
+				t.Fatal("Failed to remove", n)
+			}
+			j := sort.SearchInts(want, n)
+			want = append(want[:j], want[j+1:]...)
+		} else {
+			l.Insert(n)
+			j := sort.SearchInts(want, n)
+			want = append(want, 0)
+			copy(want[j+1:], want[j:])
+			want[j] = n
+		}
+	}
+
+	checkSkipLength(t, l, len(want))
+	for i, v := range l.Items() {
+		if v != want[i] {
+			t.Fatal("Items are out of order at index", i)
+		}
+	}
+	for i := 0; i < len(want); i += 7 {
+		if v := l.ItemAt(i); v != want[i] {
+			t.Error("Incorrect item at index", i)
+			t.Log("\tExpected:", want[i])
+			t.Log("\tReceived:", v)
+		}
+		if n := l.Rank(want[i]); n != sort.SearchInts(want, want[i]) {
+			t.Error("Incorrect rank for", want[i])
+			t.Log("\tExpected:", sort.SearchInts(want, want[i]))
+			t.Log("\tReceived:", n)
+		}
+	}
+}
+
+func BenchmarkSkipListInsert(b *testing.B) {
+	l, _ := hlist.NewSkipList(lessInt)
+	for i := 0; i < b.N; i++ {
+		l.Insert(rand.Int())
+	}
+}
+
+func BenchmarkSkipListItemAt(b *testing.B) {
+	l, _ := hlist.NewSkipList(lessInt)
+	for i := 0; i < 100000; i++ {
+		l.Insert(rand.Int())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.ItemAt(i % 100000)
+	}
+}
+
+func BenchmarkListItem(b *testing.B) {
+	l := hlist.New()
+	for i := 0; i < 100000; i++ {
+		l.Insert(0, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Item(i % 100000)
+	}
+}
+
+func newIntSkipList(t *testing.T) *hlist.SkipList {
+	l, err := hlist.NewSkipList(lessInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l
+}
+
+func lessInt(left, right interface{}) bool {
+	return left.(int) < right.(int)
+}
+
+func checkSkipString(t *testing.T, l *hlist.SkipList, want string) {
+	if l.String() != want {
+		t.Error("List contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", l)
+	}
+}
+
+func checkSkipLength(t *testing.T, l *hlist.SkipList, want int) {
+	if l.Length() != want {
+		t.Error("Incorrect length")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", l.Length())
+	}
+}
@@ -0,0 +1,457 @@
+package hlist
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// This is the number of items that each node in an unrolled list can hold.
+const unrolledNodeCap = 16
+
+// This is the standard error message when trying to use an invalid unrolled list.
+var errBadUnrolledList = fmt.Errorf("list must be created with NewUnrolled() first")
+
+// UnrolledList is a linked list where each node holds a small array of items instead of a single
+// item. Walking the list then touches far fewer nodes, and the items within a node sit next to each
+// other in memory, which makes traversal much friendlier to the CPU cache. It has the same API as
+// List.
+type UnrolledList struct {
+	head *unode
+	// tail is kept so that appending doesn't need to walk the whole list.
+	tail   *unode
+	length int
+}
+
+// unode is an internal type for an individual node in the unrolled list. Only the first count slots
+// of items are used.
+type unode struct {
+	items [unrolledNodeCap]interface{}
+	count int
+	next  *unode
+}
+
+// NewUnrolled creates a new unrolled linked list.
+func NewUnrolled() *UnrolledList {
+	return new(UnrolledList)
+}
+
+// String returns a comma-separated list of the string representations of all of the items in the
+// linked list.
+func (l *UnrolledList) String() string {
+	if l == nil {
+		return "<nil>"
+	} else if l.head == nil {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for node := l.head; node != nil; node = node.next {
+		for _, item := range node.items[:node.count] {
+			if builder.Len() > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(fmt.Sprintf("%v", item))
+		}
+	}
+
+	return builder.String()
+}
+
+// Length gets the number of items in the list, or -1 if list hasn't been created yet.
+func (l *UnrolledList) Length() int {
+	if l == nil {
+		return -1
+	}
+
+	return l.length
+}
+
+// Insert inserts one or more items into the list at the specified index.
+func (l *UnrolledList) Insert(index int, items ...interface{}) error {
+	if l == nil {
+		return errBadUnrolledList
+	}
+
+	// Make sure that none of the items is this list itself.
+	for _, v := range items {
+		if nl, ok := v.(*UnrolledList); ok && l.Same(nl) {
+			return fmt.Errorf("can't add list to itself")
+		}
+	}
+
+	if index < 0 {
+		return fmt.Errorf("invalid index")
+	} else if index > l.length {
+		return fmt.Errorf("out of bounds")
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	if l.head == nil {
+		l.head = new(unode)
+		l.tail = l.head
+	}
+
+	// Find the node that the index falls in. If the index is right at the boundary between two
+	// nodes, then we'll add to the end of the earlier node. Adding to the end of the list goes
+	// straight to the last node.
+	node := l.head
+	if index == l.length {
+		node = l.tail
+		index = node.count
+	}
+	for index > node.count {
+		index -= node.count
+		node = node.next
+	}
+
+	// Insert the items one after another, picking up where the last one left off.
+	for _, item := range items {
+		node, index = node.insert(index, item)
+	}
+	l.length += len(items)
+
+	// Inserting might have added nodes after the last one.
+	for l.tail.next != nil {
+		l.tail = l.tail.next
+	}
+
+	return nil
+}
+
+// Append adds one or more items to the end of the list.
+func (l *UnrolledList) Append(items ...interface{}) error {
+	return l.Insert(l.Length(), items...)
+}
+
+// Index gets the index of the first matching item, or -1 if not found.
+func (l *UnrolledList) Index(item interface{}) int {
+	if l == nil {
+		return -1
+	}
+
+	i := 0
+	for node := l.head; node != nil; node = node.next {
+		for _, v := range node.items[:node.count] {
+			if reflect.DeepEqual(v, item) {
+				return i
+			}
+			i++
+		}
+	}
+
+	// If we're here, then we didn't find anything.
+	return -1
+}
+
+// Item gets the item at the index.
+func (l *UnrolledList) Item(index int) interface{} {
+	node, offset, _ := l.find(index)
+	if node == nil {
+		return nil
+	}
+
+	return node.items[offset]
+}
+
+// Items returns a slice of all items in the list in order of insertion.
+func (l *UnrolledList) Items() []interface{} {
+	if l == nil || l.head == nil {
+		return nil
+	}
+
+	items := make([]interface{}, 0, l.length)
+	for node := l.head; node != nil; node = node.next {
+		items = append(items, node.items[:node.count]...)
+	}
+
+	return items
+}
+
+// Exists checks whether or not the item exists in the list.
+func (l *UnrolledList) Exists(item interface{}) bool {
+	return l.Index(item) >= 0
+}
+
+// Remove removes an item from the list and returns its value.
+func (l *UnrolledList) Remove(index int) interface{} {
+	node, offset, prev := l.find(index)
+	if node == nil {
+		return nil
+	}
+
+	item := node.items[offset]
+	copy(node.items[offset:], node.items[offset+1:node.count])
+	node.count--
+	node.items[node.count] = nil
+	l.length--
+
+	if node.count == 0 {
+		// The node is now empty. Unlink it.
+		if prev == nil {
+			l.head = node.next
+		} else {
+			prev.next = node.next
+		}
+		if node == l.tail {
+			l.tail = prev
+		}
+	} else if next := node.next; next != nil && node.count+next.count <= unrolledNodeCap/2 {
+		// This node and the next one are both sparse enough to fit into a single node with room to
+		// spare. Combine them to keep the list dense.
+		copy(node.items[node.count:], next.items[:next.count])
+		node.count += next.count
+		node.next = next.next
+		if next == l.tail {
+			l.tail = node
+		}
+	}
+
+	return item
+}
+
+// RemoveMatch finds the first item with a matching value and removes it from the list.
+func (l *UnrolledList) RemoveMatch(value interface{}) {
+	if i := l.Index(value); i >= 0 {
+		l.Remove(i)
+	}
+}
+
+// Copy makes an exact copy of the list.
+func (l *UnrolledList) Copy() (*UnrolledList, error) {
+	if l == nil {
+		return nil, errBadUnrolledList
+	}
+
+	cp := NewUnrolled()
+	var tail *unode
+	for node := l.head; node != nil; node = node.next {
+		// Copying the node copies its array of items as well.
+		nn := new(unode)
+		*nn = *node
+		nn.next = nil
+		if tail == nil {
+			cp.head = nn
+		} else {
+			tail.next = nn
+		}
+		tail = nn
+	}
+	cp.tail = tail
+	cp.length = l.length
+
+	return cp, nil
+}
+
+// Same checks if the two lists point to the same underlying data and are therefore the same list.
+func (l *UnrolledList) Same(list2 *UnrolledList) bool {
+	if l == nil || list2 == nil {
+		return false
+	}
+
+	return l == list2
+}
+
+// Twin checks if the two lists are separate lists but hold the same contents.
+func (l *UnrolledList) Twin(list2 *UnrolledList) bool {
+	if l == nil || list2 == nil || l.Same(list2) {
+		return false
+	}
+
+	// The lists must have the same length. The items might be spread across the nodes differently,
+	// so we can't compare the nodes themselves.
+	if l.length != list2.length {
+		return false
+	}
+
+	return reflect.DeepEqual(l.Items(), list2.Items())
+}
+
+// Merge appends the list to the current list, preserving order. This will take ownership of and
+// clear the provided list.
+func (l *UnrolledList) Merge(list2 *UnrolledList) error {
+	if l == nil {
+		return errBadUnrolledList
+	} else if list2 == nil || list2.head == nil {
+		// Nothing to do.
+		return nil
+	}
+
+	// If we have the same list, then we need to duplicate it first, or else the list will get
+	// cleared at the end.
+	if l.Same(list2) {
+		dup, err := l.Copy()
+		if err != nil {
+			return err
+		}
+		list2 = dup
+	}
+
+	if l.head == nil {
+		l.head = list2.head
+	} else {
+		l.tail.next = list2.head
+	}
+	l.tail = list2.tail
+	l.length += list2.length
+
+	// Give the first list ownership of all nodes.
+	return list2.Clear()
+}
+
+// Clear resets the list to its initial state.
+func (l *UnrolledList) Clear() error {
+	if l == nil {
+		return errBadUnrolledList
+	}
+
+	*l = *(NewUnrolled())
+
+	return nil
+}
+
+// Yield provides an unbuffered channel that will continually pass successive items until the list
+// is exhausted. The channel quit is used to communicate when iteration should be stopped. Send an
+// empty struct (struct{}{}) on the channel to break the communication. This will happen
+// automatically if the list is exhausted. If this is not needed, pass nil as the argument.
+func (l *UnrolledList) Yield(quit <-chan struct{}) <-chan interface{} {
+	if l == nil || l.head == nil {
+		return nil
+	}
+
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		for node := l.head; node != nil; node = node.next {
+			for _, item := range node.items[:node.count] {
+				select {
+				case ch <- item:
+				case <-quit:
+					return
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Sort sorts the list. The comparison function less should return true only if left should be sorted
+// before right. Because the items are already stored in arrays, this sorts them in a single slice
+// and then packs them back into full nodes.
+func (l *UnrolledList) Sort(less func(left, right interface{}) bool) error {
+	if l == nil {
+		return errBadUnrolledList
+	} else if less == nil {
+		return fmt.Errorf("missing comparison callback")
+	}
+
+	if l.length < 2 {
+		// Already sorted.
+		return nil
+	}
+
+	items := l.Items()
+	sort.SliceStable(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+
+	l.pack(items)
+
+	return nil
+}
+
+// SortInt sorts the list. Note: all items in the list must be of type int.
+func (l *UnrolledList) SortInt() error {
+	return l.Sort(func(left, right interface{}) bool {
+		return left.(int) < right.(int)
+	})
+}
+
+// SortStr sorts the list. Note: all items in the list must be of type string.
+func (l *UnrolledList) SortStr() error {
+	return l.Sort(func(left, right interface{}) bool {
+		return left.(string) < right.(string)
+	})
+}
+
+// find gets the node that holds the item at the index, the item's offset within that node, and the
+// node before it (or nil if it's the first node). If the index is out of bounds, then the returned
+// node is nil.
+func (l *UnrolledList) find(index int) (*unode, int, *unode) {
+	if l == nil || index < 0 || index >= l.length {
+		return nil, 0, nil
+	}
+
+	var prev *unode
+	node := l.head
+	for index >= node.count {
+		index -= node.count
+		prev = node
+		node = node.next
+	}
+
+	return node, index, prev
+}
+
+// pack replaces the contents of the list with the items, filling each node completely.
+func (l *UnrolledList) pack(items []interface{}) {
+	l.Clear()
+
+	var tail *unode
+	for i := 0; i < len(items); i += unrolledNodeCap {
+		node := new(unode)
+		node.count = copy(node.items[:], items[i:])
+		if tail == nil {
+			l.head = node
+		} else {
+			tail.next = node
+		}
+		tail = node
+	}
+	l.tail = tail
+	l.length = len(items)
+}
+
+// insert adds the item to the node at the offset. If the node is full, then it is split in half
+// first. This returns the node and offset right after the new item, which is where the next item
+// should go to keep a run of items in order.
+func (n *unode) insert(offset int, item interface{}) (*unode, int) {
+	node := n
+	if node.count == unrolledNodeCap && offset == unrolledNodeCap {
+		// We're adding to the end of a full node. Instead of splitting it and leaving two half-empty
+		// nodes, start a new node after it. This keeps nodes full when appending.
+		next := new(unode)
+		next.next = node.next
+		node.next = next
+		node = next
+		offset = 0
+	} else if node.count == unrolledNodeCap {
+		// Move the back half of the items into a new node.
+		half := unrolledNodeCap / 2
+		split := new(unode)
+		copy(split.items[:], node.items[half:])
+		split.count = unrolledNodeCap - half
+		for i := half; i < unrolledNodeCap; i++ {
+			node.items[i] = nil
+		}
+		node.count = half
+		split.next = node.next
+		node.next = split
+
+		if offset > half {
+			node = split
+			offset -= half
+		}
+	}
+
+	copy(node.items[offset+1:], node.items[offset:node.count])
+	node.items[offset] = item
+	node.count++
+
+	return node, offset + 1
+}
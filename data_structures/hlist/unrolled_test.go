@@ -0,0 +1,450 @@
+package hlist_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hlist"
+)
+
+func TestUnrolledBadPtr(t *testing.T) {
+	var l *hlist.UnrolledList
+
+	// Test String().
+	if s := l.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", s)
+	}
+
+	// Test Length().
+	if n := l.Length(); n != -1 {
+		t.Error("unexpectedly passed Length() test with bad pointer")
+	}
+
+	// Test Insert().
+	if err := l.Insert(0, "item"); err == nil {
+		t.Error("unexpectedly passed Insert() test with bad pointer")
+	}
+
+	// Test Append().
+	if err := l.Append("item"); err == nil {
+		t.Error("unexpectedly passed Append() test with bad pointer")
+	}
+
+	// Test Index().
+	if i := l.Index("item"); i != -1 {
+		t.Error("unexpectedly passed Index() test with bad pointer")
+	}
+
+	// Test Item().
+	if v := l.Item(0); v != nil {
+		t.Error("unexpectedly passed Item() test with bad pointer")
+	}
+
+	// Test Items().
+	if v := l.Items(); v != nil {
+		t.Error("unexpectedly passed Items() test with bad pointer")
+	}
+
+	// Test Exists().
+	if l.Exists("item") {
+		t.Error("unexpectedly passed Exists() test with bad pointer")
+	}
+
+	// Test Remove().
+	if v := l.Remove(0); v != nil {
+		t.Error("unexpectedly passed Remove() test with bad pointer")
+	}
+
+	// Test Copy().
+	if _, err := l.Copy(); err == nil {
+		t.Error("unexpectedly passed Copy() test with bad pointer")
+	}
+
+	// Test Same().
+	if l.Same(hlist.NewUnrolled()) {
+		t.Error("unexpectedly passed Same() test with bad pointer")
+	}
+
+	// Test Twin().
+	if l.Twin(hlist.NewUnrolled()) {
+		t.Error("unexpectedly passed Twin() test with bad pointer")
+	}
+
+	// Test Merge().
+	if err := l.Merge(hlist.NewUnrolled()); err == nil {
+		t.Error("unexpectedly passed Merge() test with bad pointer")
+	}
+
+	// Test Clear().
+	if err := l.Clear(); err == nil {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+
+	// Test Yield().
+	if ch := l.Yield(nil); ch != nil {
+		t.Error("unexpectedly passed Yield() test with bad pointer")
+	}
+
+	// Test Sort().
+	if err := l.SortInt(); err == nil {
+		t.Error("unexpectedly passed SortInt() test with bad pointer")
+	}
+}
+
+func TestUnrolledBadArgs(t *testing.T) {
+	l := hlist.NewUnrolled()
+	checkUnrolledString(t, l, "<empty>")
+	checkUnrolledLength(t, l, 0)
+
+	if err := l.Insert(-1, "item"); err == nil {
+		t.Error("unexpectedly passed Insert() test for negative index")
+	}
+	if err := l.Insert(1, "item"); err == nil {
+		t.Error("unexpectedly passed Insert() test for out-of-range index")
+	}
+	if v := l.Item(0); v != nil {
+		t.Error("unexpectedly passed Item() test for empty list")
+	}
+	if v := l.Remove(0); v != nil {
+		t.Error("unexpectedly passed Remove() test for empty list")
+	}
+	if err := l.Sort(nil); err == nil {
+		t.Error("unexpectedly passed Sort() test for missing sort cb")
+	}
+	checkUnrolledString(t, l, "<empty>")
+	checkUnrolledLength(t, l, 0)
+
+	// Test trying to add list to itself.
+	l.Append(1, 2, 3)
+	if err := l.Append(4, l); err == nil {
+		t.Error("unexpectedly passed Append() test for adding list to itself")
+	}
+	checkUnrolledString(t, l, "1, 2, 3")
+	checkUnrolledLength(t, l, 3)
+}
+
+func TestUnrolledInsertRemove(t *testing.T) {
+	l := hlist.NewUnrolled()
+
+	l.Append(1, 2, 3)
+	l.Insert(0, "a")
+	l.Insert(2, "b", "c")
+	l.Insert(l.Length(), "z")
+	checkUnrolledString(t, l, "a, 1, b, c, 2, 3, z")
+	checkUnrolledLength(t, l, 7)
+
+	if i := l.Index("c"); i != 3 {
+		t.Error("Incorrect index for c")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", i)
+	}
+	if v := l.Item(5); v != 3 {
+		t.Error("Incorrect item at index 5")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", v)
+	}
+	if !l.Exists("z") || l.Exists("y") {
+		t.Error("Exists() returned incorrect results")
+	}
+
+	if v := l.Remove(0); v != "a" {
+		t.Error("Incorrect item removed")
+		t.Log("\tExpected: a")
+		t.Log("\tReceived:", v)
+	}
+	l.RemoveMatch("z")
+	checkUnrolledString(t, l, "1, b, c, 2, 3")
+	checkUnrolledLength(t, l, 5)
+}
+
+func TestUnrolledRandom(t *testing.T) {
+	// Compare many random insertions and removals against a slice. This crosses many node
+	// boundaries, which exercises the splitting and combining of nodes.
+	l := hlist.NewUnrolled()
+	var want []interface{}
+
+	for i := 0; i < 5000; i++ {
+		if rand.Intn(3) == 0 && len(want) > 0 {
+			j := rand.Intn(len(want))
+			if v := l.Remove(j); v != want[j] {
+				t.Fatal("Removed incorrect item at index", j)
+			}
+			want = append(want[:j], want[j+1:]...)
+		} else if rand.Intn(4) == 0 {
+			if err := l.Append(i); err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, i)
+		} else {
+			j := rand.Intn(len(want) + 1)
+			n := rand.Intn(4) + 1
+			items := make([]interface{}, n)
+			for k := range items {
+				items[k] = i*10 + k
+			}
+			if err := l.Insert(j, items...); err != nil {
+				t.Fatal(err)
+			}
+			want = append(want[:j], append(items, want[j:]...)...)
+		}
+	}
+
+	checkUnrolledLength(t, l, len(want))
+	if !reflect.DeepEqual(l.Items(), want) {
+		t.Error("Items differ from expected")
+	}
+	for i := 0; i < len(want); i += 13 {
+		if v := l.Item(i); v != want[i] {
+			t.Error("Incorrect item at index", i)
+			t.Log("\tExpected:", want[i])
+			t.Log("\tReceived:", v)
+		}
+	}
+}
+
+func TestUnrolledAppend(t *testing.T) {
+	// Appending after every kind of change must still add to the very end of the list.
+	l := hlist.NewUnrolled()
+	for i := 0; i < 40; i++ {
+		l.Append(i)
+	}
+	checkUnrolledLength(t, l, 40)
+
+	// Empty out the last node.
+	for i := 0; i < 8; i++ {
+		l.Remove(l.Length() - 1)
+	}
+	l.Append("a")
+
+	// Combine the last two nodes.
+	for l.Length() > 20 {
+		l.Remove(17)
+	}
+	l.Append("b")
+
+	l2 := hlist.NewUnrolled()
+	l2.Append(100, 101)
+	l.Merge(l2)
+	l.Append("c")
+	checkUnrolledString(t, l, "0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 30, 31, a, b, 100, 101, c")
+
+	cp, _ := l.Copy()
+	cp.Append("d")
+	if v := cp.Item(cp.Length() - 1); v != "d" {
+		t.Error("Incorrect last item in copy")
+		t.Log("\tExpected: d")
+		t.Log("\tReceived:", v)
+	}
+
+	// Empty the list entirely, and then start over.
+	for l.Length() > 0 {
+		l.Remove(0)
+	}
+	l.Append("e")
+	checkUnrolledString(t, l, "e")
+
+	l.Append(3, 1, 2)
+	l.Sort(func(left, right interface{}) bool {
+		return fmt.Sprint(left) < fmt.Sprint(right)
+	})
+	l.Append("f")
+	checkUnrolledString(t, l, "1, 2, 3, e, f")
+}
+
+func TestUnrolledCopyTwinMerge(t *testing.T) {
+	l := hlist.NewUnrolled()
+	for i := 0; i < 40; i++ {
+		l.Append(i)
+	}
+
+	cp, err := l.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.Twin(cp) || l.Same(cp) {
+		t.Error("Copy is not a separate twin")
+	}
+
+	// Changing the copy should not change the original.
+	cp.Remove(0)
+	if l.Twin(cp) {
+		t.Error("Lists are unexpectedly twins")
+	}
+	checkUnrolledLength(t, l, 40)
+
+	// Lists with the same items spread across different nodes are still twins.
+	l2 := hlist.NewUnrolled()
+	for i := 39; i >= 0; i-- {
+		l2.Insert(0, i)
+	}
+	if !l.Twin(l2) {
+		t.Error("Lists unexpectedly differ")
+	}
+
+	// Test merging.
+	a := hlist.NewUnrolled()
+	a.Append(1, 2)
+	b := hlist.NewUnrolled()
+	b.Append(3, 4)
+	if err := a.Merge(b); err != nil {
+		t.Error(err)
+	}
+	checkUnrolledString(t, a, "1, 2, 3, 4")
+	checkUnrolledLength(t, a, 4)
+	checkUnrolledString(t, b, "<empty>")
+	checkUnrolledLength(t, b, 0)
+
+	// Test merging a list with itself.
+	if err := a.Merge(a); err != nil {
+		t.Error(err)
+	}
+	checkUnrolledString(t, a, "1, 2, 3, 4, 1, 2, 3, 4")
+	checkUnrolledLength(t, a, 8)
+
+	// Test clearing.
+	a.Clear()
+	checkUnrolledString(t, a, "<empty>")
+	checkUnrolledLength(t, a, 0)
+}
+
+func TestUnrolledYield(t *testing.T) {
+	l := hlist.NewUnrolled()
+	for i := 0; i < 50; i++ {
+		l.Append(i)
+	}
+
+	i := 0
+	for v := range l.Yield(nil) {
+		if v != i {
+			t.Error("Incorrect item yielded")
+			t.Log("\tExpected:", i)
+			t.Log("\tReceived:", v)
+		}
+		i++
+	}
+	if i != 50 {
+		t.Error("Did not receive all items")
+	}
+
+	// Test stopping early.
+	quit := make(chan struct{})
+	ch := l.Yield(quit)
+	<-ch
+	close(quit)
+	for range ch {
+		// Drain anything that was in flight.
+	}
+}
+
+func TestUnrolledSort(t *testing.T) {
+	l := hlist.NewUnrolled()
+	want := make([]interface{}, 100)
+	for i := range want {
+		want[i] = i
+	}
+	for _, i := range rand.Perm(100) {
+		l.Append(i)
+	}
+
+	if err := l.SortInt(); err != nil {
+		t.Error(err)
+	}
+	checkUnrolledLength(t, l, 100)
+	if !reflect.DeepEqual(l.Items(), want) {
+		t.Error("List is not sorted")
+		t.Log("\tReceived:", l)
+	}
+
+	l.Clear()
+	l.Append("pear", "apple", "fig")
+	if err := l.SortStr(); err != nil {
+		t.Error(err)
+	}
+	checkUnrolledString(t, l, "apple, fig, pear")
+
+	// Make sure the list still works after sorting.
+	l.Insert(1, "banana")
+	checkUnrolledString(t, l, "apple, banana, fig, pear")
+}
+
+// The benchmarks below compare List and UnrolledList. Building an UnrolledList allocates one node
+// per 16 items instead of one per item, and walking it follows far fewer pointers.
+
+const benchItems = 100000
+
+func BenchmarkListBuild(b *testing.B) {
+	b.ReportAllocs()
+	items := benchItemSlice()
+	for i := 0; i < b.N; i++ {
+		l := hlist.New()
+		l.Append(items...)
+	}
+}
+
+func BenchmarkUnrolledBuild(b *testing.B) {
+	b.ReportAllocs()
+	items := benchItemSlice()
+	for i := 0; i < b.N; i++ {
+		l := hlist.NewUnrolled()
+		l.Append(items...)
+	}
+}
+
+func BenchmarkUnrolledAppend(b *testing.B) {
+	b.ReportAllocs()
+	items := benchItemSlice()
+	for i := 0; i < b.N; i++ {
+		// Appending one item at a time should cost the same per item no matter how long the list is.
+		l := hlist.NewUnrolled()
+		for _, item := range items {
+			l.Append(item)
+		}
+	}
+}
+
+func BenchmarkListItems(b *testing.B) {
+	l := hlist.New()
+	l.Append(benchItemSlice()...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Items()
+	}
+}
+
+func BenchmarkUnrolledItems(b *testing.B) {
+	l := hlist.NewUnrolled()
+	l.Append(benchItemSlice()...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Items()
+	}
+}
+
+func benchItemSlice() []interface{} {
+	items := make([]interface{}, benchItems)
+	for i := range items {
+		items[i] = i
+	}
+
+	return items
+}
+
+func checkUnrolledString(t *testing.T, l *hlist.UnrolledList, want string) {
+	if l.String() != want {
+		t.Error("List contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", l)
+	}
+}
+
+func checkUnrolledLength(t *testing.T, l *hlist.UnrolledList, want int) {
+	if l.Length() != want {
+		t.Error("Incorrect length")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", l.Length())
+	}
+}
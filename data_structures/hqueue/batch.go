@@ -0,0 +1,117 @@
+package hqueue
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// This is the standard error message when trying to use an invalid batcher.
+var errBadBatcher = fmt.Errorf("must create batcher with NewBatcher() first")
+
+// Taker is a queue that items can be taken from, either waiting for one or not. BlockingQueue,
+// DelayQueue, and LimitedQueue are all Takers.
+type Taker interface {
+	Take(ctx context.Context) (interface{}, error)
+	TryTake() (interface{}, error)
+}
+
+// Batcher takes items from a queue in batches. A batch is finished when it has reached the maximum
+// size, or when the maximum wait has passed since its first item was taken, whichever comes first. This
+// is useful for handling items that are cheaper to deal with many at a time, like flushing log lines to
+// disk.
+//
+// The queue's Take must wait for items to be added by other goroutines, or the maximum wait has no
+// effect. A Queue is not safe for concurrent use and can't wait for items, so to batch items from one,
+// put them through a BlockingQueue instead.
+type Batcher struct {
+	queue Taker
+	size  int
+	wait  time.Duration
+	clock Clock
+}
+
+// NewBatcher creates a new batcher that takes batches of up to size items from queue, waiting at most
+// wait for each batch to fill. If clock is nil, then the system clock is used.
+func NewBatcher(queue Taker, size int, wait time.Duration, clock Clock) (*Batcher, error) {
+	if queue == nil {
+		return nil, fmt.Errorf("missing queue")
+	} else if size < 1 {
+		return nil, fmt.Errorf("batch size must be positive")
+	} else if wait <= 0 {
+		return nil, fmt.Errorf("batch wait must be positive")
+	}
+	if clock == nil {
+		clock = SystemClock()
+	}
+
+	b := new(Batcher)
+	b.queue = queue
+	b.size = size
+	b.wait = wait
+	b.clock = clock
+
+	return b, nil
+}
+
+// Next takes the next batch of items from the queue. It waits as long as needed for the first item, and
+// then until either the batch is full or the maximum wait has passed.
+//
+// If the queue's Take returns an error, such as ErrClosed, then the batch is returned early. The error
+// is only returned if the batch is empty, so that the last items taken from a closed queue are not lost.
+// If ctx is done, then the items collected so far are returned along with ctx's error.
+func (b *Batcher) Next(ctx context.Context) ([]interface{}, error) {
+	if b == nil || b.queue == nil {
+		return nil, errBadBatcher
+	}
+
+	item, err := b.queue.Take(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := make([]interface{}, 1, b.size)
+	batch[0] = item
+
+	// Take whatever is already waiting without starting the timer.
+	if batch = b.drain(batch); len(batch) == b.size {
+		return batch, nil
+	}
+
+	// Wait for the rest of the batch, but only until the timer runs out.
+	timer := b.clock.NewTimer(b.wait)
+	defer timer.Stop()
+	bctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-timer.C():
+			cancel()
+		case <-bctx.Done():
+		}
+	}()
+
+	for len(batch) < b.size {
+		item, err := b.queue.Take(bctx)
+		if err != nil {
+			// Running out of time is the normal way for a batch to end.
+			return batch, ctx.Err()
+		}
+		batch = append(batch, item)
+		batch = b.drain(batch)
+	}
+
+	return batch, nil
+}
+
+// drain adds items that are ready right away to the batch until it is full.
+func (b *Batcher) drain(batch []interface{}) []interface{} {
+	for len(batch) < b.size {
+		item, err := b.queue.TryTake()
+		if err != nil {
+			break
+		}
+		batch = append(batch, item)
+	}
+
+	return batch
+}
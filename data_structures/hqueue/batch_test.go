@@ -0,0 +1,171 @@
+package hqueue_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+// These queues can all be batched.
+var (
+	_ hqueue.Taker = (*hqueue.BlockingQueue)(nil)
+	_ hqueue.Taker = (*hqueue.DelayQueue)(nil)
+	_ hqueue.Taker = (*hqueue.LimitedQueue)(nil)
+)
+
+func TestBatcherBadPtr(t *testing.T) {
+	var b *hqueue.Batcher
+	if _, err := b.Next(context.Background()); err == nil {
+		t.Error("unexpectedly passed Next() test with bad pointer")
+	}
+
+	// A batcher that wasn't created with NewBatcher() should be rejected too.
+	var zero hqueue.Batcher
+	if _, err := zero.Next(context.Background()); err == nil {
+		t.Error("unexpectedly passed Next() test with zero-value batcher")
+	}
+}
+
+func TestBatcherBadArgs(t *testing.T) {
+	q := newBlocking(t, 0)
+
+	if _, err := hqueue.NewBatcher(nil, 1, time.Second, nil); err == nil {
+		t.Error("unexpectedly passed NewBatcher() test with no queue")
+	}
+	if _, err := hqueue.NewBatcher(q, 0, time.Second, nil); err == nil {
+		t.Error("unexpectedly passed NewBatcher() test with no size")
+	}
+	if _, err := hqueue.NewBatcher(q, 1, 0, nil); err == nil {
+		t.Error("unexpectedly passed NewBatcher() test with no wait")
+	}
+}
+
+func TestBatcherSize(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newBlocking(t, 0)
+	b := newBatcher(t, q, 3, clock)
+	ctx := context.Background()
+
+	for i := 0; i < 7; i++ {
+		q.Put(ctx, i)
+	}
+
+	// Full batches come out without waiting for the clock.
+	checkBatch(t, b, []interface{}{0, 1, 2})
+	checkBatch(t, b, []interface{}{3, 4, 5})
+
+	// A batch that is filled while waiting also comes out right away.
+	done := make(chan []interface{})
+	go func() {
+		batch, err := b.Next(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- batch
+	}()
+	waitForTimers(clock, 1)
+	q.Put(ctx, 7)
+	q.Put(ctx, 8)
+	if batch := <-done; !reflect.DeepEqual(batch, []interface{}{6, 7, 8}) {
+		t.Error("Incorrect batch")
+		t.Log("\tExpected: [6 7 8]")
+		t.Log("\tReceived:", batch)
+	}
+}
+
+func TestBatcherWait(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newBlocking(t, 0)
+	b := newBatcher(t, q, 500, clock)
+	ctx := context.Background()
+
+	q.Put(ctx, "a")
+	q.Put(ctx, "b")
+
+	// The batch isn't full, so it should come out once the wait has passed.
+	done := make(chan []interface{})
+	go func() {
+		batch, err := b.Next(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- batch
+	}()
+	waitForTimers(clock, 1)
+	q.Put(ctx, "c")
+	clock.Advance(199 * time.Millisecond)
+	select {
+	case batch := <-done:
+		t.Fatal("Batch came out early:", batch)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Millisecond)
+	if batch := <-done; !reflect.DeepEqual(batch, []interface{}{"a", "b", "c"}) {
+		t.Error("Incorrect batch")
+		t.Log("\tExpected: [a b c]")
+		t.Log("\tReceived:", batch)
+	}
+
+	// Waiting for the first item stops when the context is done.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if batch, err := b.Next(cctx); batch != nil || !errors.Is(err, context.Canceled) {
+		t.Error("Next() did not stop when context was cancelled")
+		t.Log("\tExpected: [] context canceled")
+		t.Log("\tReceived:", batch, err)
+	}
+
+	// If the context is done partway through a batch, then the items so far are still returned.
+	q.Put(ctx, "d")
+	cctx, cancel = context.WithCancel(ctx)
+	go func() {
+		waitForTimers(clock, 1)
+		cancel()
+	}()
+	if batch, err := b.Next(cctx); !reflect.DeepEqual(batch, []interface{}{"d"}) || !errors.Is(err, context.Canceled) {
+		t.Error("Next() did not return partial batch when context was cancelled")
+		t.Log("\tExpected: [d] context canceled")
+		t.Log("\tReceived:", batch, err)
+	}
+}
+
+func TestBatcherClose(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newBlocking(t, 0)
+	b := newBatcher(t, q, 10, clock)
+	ctx := context.Background()
+
+	q.Put(ctx, 1)
+	q.Put(ctx, 2)
+	q.Close()
+
+	// The last items come out without waiting, and then the queue's error is returned.
+	checkBatch(t, b, []interface{}{1, 2})
+	if _, err := b.Next(ctx); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("Next() did not receive ErrClosed after draining")
+		t.Log("\tExpected:", hqueue.ErrClosed)
+		t.Log("\tReceived:", err)
+	}
+}
+
+func newBatcher(t *testing.T, q hqueue.Taker, size int, clock hqueue.Clock) *hqueue.Batcher {
+	b, err := hqueue.NewBatcher(q, size, 200*time.Millisecond, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func checkBatch(t *testing.T, b *hqueue.Batcher, want []interface{}) {
+	batch, err := b.Next(context.Background())
+	if err != nil || !reflect.DeepEqual(batch, want) {
+		t.Error("Incorrect batch")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", batch, err)
+	}
+}
@@ -0,0 +1,243 @@
+package hqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrClosed is returned when adding to a closed queue, or taking from a closed queue that has no
+	// items left.
+	ErrClosed = fmt.Errorf("queue is closed")
+	// ErrFull is returned when a queue doesn't have room for another item.
+	ErrFull = fmt.Errorf("queue is full")
+	// ErrEmpty is returned when a queue doesn't have any items.
+	ErrEmpty = fmt.Errorf("queue is empty")
+
+	// This is the standard error message when trying to use an invalid blocking queue.
+	errBadBlockingQueue = fmt.Errorf("must create queue with NewBlocking() first")
+)
+
+// BlockingQueue is a first-in/first-out queue that is safe for use by many goroutines at once. Taking
+// from an empty queue waits until an item is available, and putting into a full queue waits until
+// there is room. Waiting can be cut short with a context.
+//
+// Once a queue is closed, no more items can be added, but the items already in the queue can still
+// be taken. After the last one is taken, Take returns ErrClosed.
+type BlockingQueue struct {
+	mu       sync.Mutex
+	queue    *Queue
+	capacity int
+	closed   bool
+
+	// These channels are closed whenever an item is added or removed, to wake up everyone who is
+	// waiting for that to happen. They are only created when someone starts waiting.
+	added   chan struct{}
+	removed chan struct{}
+}
+
+// NewBlocking creates a new blocking queue that holds at most capacity items. If capacity is 0, then
+// the queue can grow without limit, and Put never waits.
+func NewBlocking(capacity int) (*BlockingQueue, error) {
+	if capacity < 0 {
+		return nil, fmt.Errorf("capacity cannot be negative")
+	}
+
+	q := new(BlockingQueue)
+	q.queue = New()
+	q.capacity = capacity
+
+	return q, nil
+}
+
+// Put adds an item to the back of the queue. If the queue is full, then this waits until there is
+// room, the queue is closed, or ctx is done. If ctx is done first, then ctx's error is returned.
+func (q *BlockingQueue) Put(ctx context.Context, item interface{}) error {
+	if q == nil || q.queue == nil {
+		return errBadBlockingQueue
+	}
+
+	for {
+		q.mu.Lock()
+		if err := q.put(item); !errors.Is(err, ErrFull) {
+			q.mu.Unlock()
+			return err
+		}
+		wait := waitOn(&q.removed)
+		q.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryPut adds an item to the back of the queue without waiting. This returns ErrFull if the queue is
+// full, or ErrClosed if it is closed.
+func (q *BlockingQueue) TryPut(item interface{}) error {
+	if q == nil || q.queue == nil {
+		return errBadBlockingQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.put(item)
+}
+
+// Take removes the first item from the queue and returns it. If the queue is empty, then this waits
+// until an item is added, the queue is closed, or ctx is done. If ctx is done first, then ctx's error
+// is returned.
+func (q *BlockingQueue) Take(ctx context.Context) (interface{}, error) {
+	if q == nil || q.queue == nil {
+		return nil, errBadBlockingQueue
+	}
+
+	for {
+		q.mu.Lock()
+		item, err := q.take()
+		if !errors.Is(err, ErrEmpty) {
+			q.mu.Unlock()
+			return item, err
+		}
+		wait := waitOn(&q.added)
+		q.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// TryTake removes the first item from the queue and returns it without waiting. This returns ErrEmpty
+// if the queue is empty, or ErrClosed if it is empty and closed.
+func (q *BlockingQueue) TryTake() (interface{}, error) {
+	if q == nil || q.queue == nil {
+		return nil, errBadBlockingQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.take()
+}
+
+// Close stops the queue from accepting any more items. Items already in the queue can still be taken.
+// Everyone waiting to put an item is woken up and receives ErrClosed, and so is everyone waiting to
+// take an item once the queue is empty.
+func (q *BlockingQueue) Close() error {
+	if q == nil || q.queue == nil {
+		return errBadBlockingQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	q.closed = true
+	broadcast(&q.added)
+	broadcast(&q.removed)
+
+	return nil
+}
+
+// Closed checks whether or not the queue has been closed.
+func (q *BlockingQueue) Closed() bool {
+	if q == nil || q.queue == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
+
+// Count gets the current number of items in the queue.
+func (q *BlockingQueue) Count() int {
+	if q == nil || q.queue == nil {
+		return -1
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.Count()
+}
+
+// Capacity gets the maximum number of items that the queue can hold, or 0 if there is no limit.
+func (q *BlockingQueue) Capacity() int {
+	if q == nil || q.queue == nil {
+		return -1
+	}
+
+	return q.capacity
+}
+
+// String displays the queue's contents, from the front to the back.
+func (q *BlockingQueue) String() string {
+	if q == nil || q.queue == nil {
+		return "<nil>"
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.String()
+}
+
+// put adds the item if there's room. The caller must hold the lock.
+func (q *BlockingQueue) put(item interface{}) error {
+	if q.closed {
+		return ErrClosed
+	} else if q.capacity > 0 && q.queue.Count() >= q.capacity {
+		return ErrFull
+	}
+
+	if err := q.queue.Add(item); err != nil {
+		return err
+	}
+	broadcast(&q.added)
+
+	return nil
+}
+
+// take removes the first item if there is one. The caller must hold the lock.
+func (q *BlockingQueue) take() (interface{}, error) {
+	if q.queue.Count() == 0 {
+		if q.closed {
+			return nil, ErrClosed
+		}
+		return nil, ErrEmpty
+	}
+
+	item := q.queue.Pop()
+	broadcast(&q.removed)
+
+	return item, nil
+}
+
+// waitOn returns a channel that will be closed the next time broadcast is called with ch.
+func waitOn(ch *chan struct{}) <-chan struct{} {
+	if *ch == nil {
+		*ch = make(chan struct{})
+	}
+
+	return *ch
+}
+
+// broadcast wakes up everyone waiting on the channel.
+func broadcast(ch *chan struct{}) {
+	if *ch != nil {
+		close(*ch)
+		*ch = nil
+	}
+}
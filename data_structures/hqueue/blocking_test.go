@@ -0,0 +1,343 @@
+package hqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestBlockingBadPtr(t *testing.T) {
+	var q *hqueue.BlockingQueue
+	ctx := context.Background()
+
+	if err := q.Put(ctx, 1); err == nil {
+		t.Error("unexpectedly passed Put() test with bad pointer")
+	}
+	if err := q.TryPut(1); err == nil {
+		t.Error("unexpectedly passed TryPut() test with bad pointer")
+	}
+	if _, err := q.Take(ctx); err == nil {
+		t.Error("unexpectedly passed Take() test with bad pointer")
+	}
+	if _, err := q.TryTake(); err == nil {
+		t.Error("unexpectedly passed TryTake() test with bad pointer")
+	}
+	if err := q.Close(); err == nil {
+		t.Error("unexpectedly passed Close() test with bad pointer")
+	}
+	if q.Closed() {
+		t.Error("unexpectedly passed Closed() test with bad pointer")
+	}
+	if n := q.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if n := q.Capacity(); n != -1 {
+		t.Error("unexpectedly passed Capacity() test with bad pointer")
+	}
+	if s := q.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+	}
+
+	// A queue that wasn't created with NewBlocking() should be rejected too, not treated as holding a
+	// nil item.
+	var zero hqueue.BlockingQueue
+	if err := zero.Put(ctx, 1); err == nil {
+		t.Error("unexpectedly passed Put() test with zero-value queue")
+	}
+	if err := zero.TryPut(1); err == nil {
+		t.Error("unexpectedly passed TryPut() test with zero-value queue")
+	}
+	if _, err := zero.Take(ctx); err == nil {
+		t.Error("unexpectedly passed Take() test with zero-value queue")
+	}
+	if _, err := zero.TryTake(); err == nil {
+		t.Error("unexpectedly passed TryTake() test with zero-value queue")
+	}
+	if err := zero.Close(); err == nil {
+		t.Error("unexpectedly passed Close() test with zero-value queue")
+	}
+	if zero.Closed() {
+		t.Error("unexpectedly passed Closed() test with zero-value queue")
+	}
+	if n := zero.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with zero-value queue")
+	}
+	if n := zero.Capacity(); n != -1 {
+		t.Error("unexpectedly passed Capacity() test with zero-value queue")
+	}
+	if s := zero.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with zero-value queue")
+	}
+
+	if _, err := hqueue.NewBlocking(-1); err == nil {
+		t.Error("unexpectedly passed NewBlocking() test with negative capacity")
+	}
+}
+
+func TestBlockingTry(t *testing.T) {
+	q := newBlocking(t, 2)
+
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly passed TryTake() test for empty queue")
+		t.Log("\tExpected:", hqueue.ErrEmpty)
+		t.Log("\tReceived:", err)
+	}
+
+	if err := q.TryPut("a"); err != nil {
+		t.Error(err)
+	}
+	if err := q.TryPut("b"); err != nil {
+		t.Error(err)
+	}
+	if err := q.TryPut("c"); !errors.Is(err, hqueue.ErrFull) {
+		t.Error("unexpectedly passed TryPut() test for full queue")
+		t.Log("\tExpected:", hqueue.ErrFull)
+		t.Log("\tReceived:", err)
+	}
+	checkBlocking(t, q, "a, b", 2)
+
+	if v, err := q.TryTake(); v != "a" || err != nil {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: a <nil>")
+		t.Log("\tReceived:", v, err)
+	}
+	checkBlocking(t, q, "b", 1)
+}
+
+func TestBlockingWait(t *testing.T) {
+	q := newBlocking(t, 1)
+	ctx := context.Background()
+
+	// Take should wait until an item is added.
+	got := make(chan interface{})
+	go func() {
+		v, err := q.Take(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- v
+	}()
+
+	select {
+	case <-got:
+		t.Fatal("Take() did not wait on empty queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	q.Put(ctx, "first")
+	if v := <-got; v != "first" {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: first")
+		t.Log("\tReceived:", v)
+	}
+
+	// Put should wait until there is room.
+	q.Put(ctx, "second")
+	done := make(chan error)
+	go func() {
+		done <- q.Put(ctx, "third")
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Put() did not wait on full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if v, _ := q.Take(ctx); v != "second" {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: second")
+		t.Log("\tReceived:", v)
+	}
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	checkBlocking(t, q, "third", 1)
+}
+
+func TestBlockingCancel(t *testing.T) {
+	q := newBlocking(t, 1)
+
+	// Waiting to take should stop when the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.Take(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Take() did not stop when context was done")
+		t.Log("\tExpected:", context.DeadlineExceeded)
+		t.Log("\tReceived:", err)
+	}
+
+	// Waiting to put should stop when the context is cancelled.
+	q.TryPut(1)
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if err := q.Put(ctx, 2); !errors.Is(err, context.Canceled) {
+		t.Error("Put() did not stop when context was cancelled")
+		t.Log("\tExpected:", context.Canceled)
+		t.Log("\tReceived:", err)
+	}
+
+	// The queue should be unchanged.
+	checkBlocking(t, q, "1", 1)
+}
+
+func TestBlockingClose(t *testing.T) {
+	q := newBlocking(t, 2)
+	ctx := context.Background()
+
+	q.Put(ctx, 1)
+	q.Put(ctx, 2)
+
+	// Someone waiting to put should be woken up by closing.
+	done := make(chan error)
+	go func() {
+		done <- q.Put(ctx, 3)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := q.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := <-done; !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("Waiting Put() did not receive ErrClosed")
+		t.Log("\tExpected:", hqueue.ErrClosed)
+		t.Log("\tReceived:", err)
+	}
+	if !q.Closed() {
+		t.Error("Queue is not closed")
+	}
+	if err := q.Close(); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed Close() test for closed queue")
+	}
+	if err := q.TryPut(4); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed TryPut() test for closed queue")
+	}
+
+	// The remaining items should still come out.
+	for _, want := range []int{1, 2} {
+		if v, err := q.Take(ctx); v != want || err != nil {
+			t.Error("Incorrect item taken after closing")
+			t.Log("\tExpected:", want)
+			t.Log("\tReceived:", v, err)
+		}
+	}
+	if _, err := q.Take(ctx); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("Take() did not receive ErrClosed after draining")
+	}
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("TryTake() did not receive ErrClosed after draining")
+	}
+
+	// Someone waiting to take from an empty queue should be woken up by closing.
+	q = newBlocking(t, 0)
+	go func() {
+		_, err := q.Take(ctx)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	if err := <-done; !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("Waiting Take() did not receive ErrClosed")
+	}
+}
+
+func TestBlockingConcurrent(t *testing.T) {
+	q := newBlocking(t, 8)
+	ctx := context.Background()
+
+	const producers = 4
+	const perProducer = 1000
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				if err := q.Put(ctx, p*perProducer+i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(p)
+	}
+
+	// Close the queue once every producer is done. The consumers should then drain everything.
+	go func() {
+		wg.Wait()
+		q.Close()
+	}()
+
+	var mu sync.Mutex
+	seen := make(map[interface{}]bool)
+	var consumers sync.WaitGroup
+	for c := 0; c < 4; c++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			last := make(map[int]int)
+			for {
+				v, err := q.Take(ctx)
+				if errors.Is(err, hqueue.ErrClosed) {
+					return
+				} else if err != nil {
+					t.Error(err)
+					return
+				}
+
+				// Items from each producer should come out in the order that they were put in.
+				n := v.(int)
+				if prev, ok := last[n/perProducer]; ok && prev >= n {
+					t.Error("Items out of order:", prev, n)
+				}
+				last[n/perProducer] = n
+
+				mu.Lock()
+				if seen[v] {
+					t.Error("Received duplicate item:", v)
+				}
+				seen[v] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	consumers.Wait()
+
+	if len(seen) != producers*perProducer {
+		t.Error("Incorrect number of items")
+		t.Log("\tExpected:", producers*perProducer)
+		t.Log("\tReceived:", len(seen))
+	}
+}
+
+func newBlocking(t *testing.T, capacity int) *hqueue.BlockingQueue {
+	q, err := hqueue.NewBlocking(capacity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := q.Capacity(); n != capacity {
+		t.Error("Incorrect capacity")
+		t.Log("\tExpected:", capacity)
+		t.Log("\tReceived:", n)
+	}
+
+	return q
+}
+
+func checkBlocking(t *testing.T, q *hqueue.BlockingQueue, want string, count int) {
+	if q.String() != want {
+		t.Error("Queue contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", q)
+	}
+	if n := q.Count(); n != count {
+		t.Error("Incorrect count")
+		t.Log("\tExpected:", count)
+		t.Log("\tReceived:", n)
+	}
+}
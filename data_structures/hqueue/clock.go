@@ -0,0 +1,149 @@
+package hqueue
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and makes timers. Queues that wait for deadlines take a Clock so that tests can
+// control the passing of time with a FakeClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer creates a timer that sends the current time on its channel after d has passed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-use timer made by a Clock.
+type Timer interface {
+	// C returns the channel that the time is sent on when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. It returns false if the timer has already fired or been
+	// stopped.
+	Stop() bool
+}
+
+// SystemClock returns a Clock that uses the real time.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+// systemClock is the Clock returned by SystemClock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+// systemTimer wraps the standard library's timer.
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// FakeClock is a Clock whose time only moves when told to. Timers fire when the clock is advanced to or
+// past their deadlines. It is safe for use by many goroutines at once.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a timer made by a FakeClock.
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	ch    chan time.Time
+}
+
+// NewFakeClock creates a new fake clock that starts at the given time.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer creates a timer that fires once the clock has been advanced by d. If d is not positive, then
+// the timer fires right away.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- c.now
+	} else {
+		c.timers = append(c.timers, t)
+	}
+
+	return t
+}
+
+// Advance moves the clock forward by d and fires every timer whose deadline has been reached.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+		} else {
+			t.ch <- c.now
+		}
+	}
+	for i := len(pending); i < len(c.timers); i++ {
+		c.timers[i] = nil
+	}
+	c.timers = pending
+}
+
+// Timers gets the number of timers that are waiting to fire. Tests can use this to find out when
+// another goroutine has started waiting on the clock.
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, v := range c.timers {
+		if v == t {
+			last := len(c.timers) - 1
+			copy(c.timers[i:], c.timers[i+1:])
+			c.timers[last] = nil
+			c.timers = c.timers[:last]
+			return true
+		}
+	}
+
+	return false
+}
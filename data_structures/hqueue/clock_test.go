@@ -0,0 +1,89 @@
+package hqueue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestFakeClock(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	if now := clock.Now(); !now.Equal(epoch) {
+		t.Error("Incorrect starting time")
+		t.Log("\tExpected:", epoch)
+		t.Log("\tReceived:", now)
+	}
+
+	short := clock.NewTimer(time.Second)
+	long := clock.NewTimer(time.Minute)
+	stopped := clock.NewTimer(time.Second)
+	if n := clock.Timers(); n != 3 {
+		t.Error("Incorrect number of timers")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", n)
+	}
+
+	if !stopped.Stop() {
+		t.Error("Failed to stop pending timer")
+	}
+	if stopped.Stop() {
+		t.Error("Stopped timer twice")
+	}
+
+	// Only the short timer should fire.
+	clock.Advance(time.Second)
+	select {
+	case at := <-short.C():
+		if !at.Equal(epoch.Add(time.Second)) {
+			t.Error("Incorrect time sent by timer:", at)
+		}
+	default:
+		t.Error("Timer did not fire")
+	}
+	select {
+	case <-long.C():
+		t.Error("Timer fired early")
+	case <-stopped.C():
+		t.Error("Stopped timer fired")
+	default:
+	}
+	if short.Stop() {
+		t.Error("Stopped timer that already fired")
+	}
+
+	clock.Advance(time.Hour)
+	select {
+	case <-long.C():
+	default:
+		t.Error("Timer did not fire")
+	}
+	if n := clock.Timers(); n != 0 {
+		t.Error("Incorrect number of timers")
+		t.Log("\tExpected: 0")
+		t.Log("\tReceived:", n)
+	}
+
+	// Timers that are already due fire right away.
+	select {
+	case <-clock.NewTimer(0).C():
+	default:
+		t.Error("Timer with no duration did not fire")
+	}
+}
+
+func TestSystemClock(t *testing.T) {
+	clock := hqueue.SystemClock()
+
+	start := clock.Now()
+	timer := clock.NewTimer(time.Millisecond)
+	<-timer.C()
+	if elapsed := time.Since(start); elapsed < time.Millisecond {
+		t.Error("Timer fired too early:", elapsed)
+	}
+
+	timer = clock.NewTimer(time.Hour)
+	if !timer.Stop() {
+		t.Error("Failed to stop pending timer")
+	}
+}
@@ -0,0 +1,246 @@
+package hqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This is the standard error message when trying to use an invalid delay queue.
+var errBadDelayQueue = fmt.Errorf("must create queue with NewDelay() first")
+
+// DelayQueue is a queue whose items can't be taken until their deadlines have passed. Items come out in
+// order of their deadlines, and items with the same deadline come out in the order that they were put
+// in. It is safe for use by many goroutines at once.
+//
+// Like BlockingQueue, a closed delay queue accepts no more items, but the items already in it are still
+// delivered once they are due.
+type DelayQueue struct {
+	mu     sync.Mutex
+	clock  Clock
+	queue  *PriorityQueue
+	closed bool
+
+	// changed is closed whenever the queue changes, to wake up everyone waiting for an item.
+	changed chan struct{}
+}
+
+// Delayed refers to an item in a delay queue. It is returned when the item is put into the queue, and
+// can be used to cancel the item before it is taken.
+type Delayed struct {
+	queue  *DelayQueue
+	handle *Handle
+	item   interface{}
+	at     time.Time
+}
+
+// NewDelay creates a new delay queue that uses the clock to tell when items are due. If clock is nil,
+// then the system clock is used.
+func NewDelay(clock Clock) *DelayQueue {
+	if clock == nil {
+		clock = SystemClock()
+	}
+
+	q := new(DelayQueue)
+	q.clock = clock
+	q.queue = NewPriority()
+
+	return q
+}
+
+// Put adds an item to the queue that can be taken once the time at has been reached.
+func (q *DelayQueue) Put(item interface{}, at time.Time) (*Delayed, error) {
+	if q == nil || q.queue == nil {
+		return nil, errBadDelayQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, ErrClosed
+	}
+
+	d := &Delayed{queue: q, item: item, at: at}
+	d.handle = q.queue.pushAt(d, at)
+	broadcast(&q.changed)
+
+	return d, nil
+}
+
+// Take removes the earliest item from the queue and returns it, waiting until its deadline has passed.
+// If an item with an earlier deadline is put in while waiting, then that one is taken instead. This
+// stops waiting if the queue is closed and empty, or if ctx is done. If ctx is done first, then ctx's
+// error is returned.
+func (q *DelayQueue) Take(ctx context.Context) (interface{}, error) {
+	if q == nil || q.queue == nil {
+		return nil, errBadDelayQueue
+	}
+
+	for {
+		q.mu.Lock()
+		item, wait, err := q.take()
+		if !errors.Is(err, ErrEmpty) {
+			q.mu.Unlock()
+			return item, err
+		}
+		changed := waitOn(&q.changed)
+		q.mu.Unlock()
+
+		// If there is an item, then also wait for it to come due.
+		var due <-chan time.Time
+		var timer Timer
+		if wait > 0 {
+			timer = q.clock.NewTimer(wait)
+			due = timer.C()
+		}
+
+		select {
+		case <-changed:
+		case <-due:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// TryTake removes the earliest item from the queue and returns it without waiting. This returns ErrEmpty
+// if no item is due yet, or ErrClosed if the queue is empty and closed.
+func (q *DelayQueue) TryTake() (interface{}, error) {
+	if q == nil || q.queue == nil {
+		return nil, errBadDelayQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, _, err := q.take()
+
+	return item, err
+}
+
+// Cancel removes an item from the queue before it is taken. This returns ErrBadHandle if the item has
+// already been taken or cancelled, or belongs to another queue.
+func (q *DelayQueue) Cancel(d *Delayed) error {
+	if q == nil || q.queue == nil {
+		return errBadDelayQueue
+	} else if d == nil || d.queue != q {
+		return ErrBadHandle
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.queue.Remove(d.handle); err != nil {
+		return err
+	}
+	broadcast(&q.changed)
+
+	return nil
+}
+
+// Close stops the queue from accepting any more items. Items already in the queue can still be taken
+// when they are due. Everyone waiting to take an item is woken up and receives ErrClosed once the queue
+// is empty.
+func (q *DelayQueue) Close() error {
+	if q == nil || q.queue == nil {
+		return errBadDelayQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	q.closed = true
+	broadcast(&q.changed)
+
+	return nil
+}
+
+// Count gets the current number of items in the queue, whether or not they are due.
+func (q *DelayQueue) Count() int {
+	if q == nil || q.queue == nil {
+		return -1
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.Count()
+}
+
+// String displays the queue's contents in order of their deadlines.
+func (q *DelayQueue) String() string {
+	if q == nil || q.queue == nil {
+		return "<nil>"
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queue.Count() == 0 {
+		return "<empty>"
+	}
+
+	handles := make([]*Handle, len(q.queue.heap))
+	copy(handles, q.queue.heap)
+	sortHandles(handles)
+
+	builder := new(strings.Builder)
+	for _, h := range handles {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", h.item.(*Delayed).item))
+	}
+
+	return builder.String()
+}
+
+// Item gets the item that was put into the queue.
+func (d *Delayed) Item() interface{} {
+	if d == nil {
+		return nil
+	}
+
+	return d.item
+}
+
+// At gets the time when the item becomes due.
+func (d *Delayed) At() time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+
+	return d.at
+}
+
+// take removes the earliest item if it is due. If it isn't, then this returns ErrEmpty and how long it
+// will be until the earliest item is due, or 0 if there are no items. The caller must hold the lock.
+func (q *DelayQueue) take() (interface{}, time.Duration, error) {
+	v, _, err := q.queue.Peek()
+	if err != nil {
+		if q.closed {
+			return nil, 0, ErrClosed
+		}
+		return nil, 0, ErrEmpty
+	}
+
+	d := v.(*Delayed)
+	if wait := d.at.Sub(q.clock.Now()); wait > 0 {
+		return nil, wait, ErrEmpty
+	}
+	q.queue.Pop()
+
+	return d.item, 0, nil
+}
@@ -0,0 +1,307 @@
+package hqueue_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+var epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestDelayBadPtr(t *testing.T) {
+	var q *hqueue.DelayQueue
+
+	if _, err := q.Put(1, epoch); err == nil {
+		t.Error("unexpectedly passed Put() test with bad pointer")
+	}
+	if _, err := q.Take(context.Background()); err == nil {
+		t.Error("unexpectedly passed Take() test with bad pointer")
+	}
+	if _, err := q.TryTake(); err == nil {
+		t.Error("unexpectedly passed TryTake() test with bad pointer")
+	}
+	if err := q.Cancel(nil); err == nil {
+		t.Error("unexpectedly passed Cancel() test with bad pointer")
+	}
+	if err := q.Close(); err == nil {
+		t.Error("unexpectedly passed Close() test with bad pointer")
+	}
+	if n := q.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if s := q.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+	}
+
+	// A queue that wasn't created with NewDelay() should be rejected too.
+	var zero hqueue.DelayQueue
+	if _, err := zero.Put(1, epoch); err == nil {
+		t.Error("unexpectedly passed Put() test with zero-value queue")
+	}
+	if _, err := zero.Take(context.Background()); err == nil {
+		t.Error("unexpectedly passed Take() test with zero-value queue")
+	}
+	if _, err := zero.TryTake(); err == nil {
+		t.Error("unexpectedly passed TryTake() test with zero-value queue")
+	}
+	if err := zero.Cancel(nil); err == nil {
+		t.Error("unexpectedly passed Cancel() test with zero-value queue")
+	}
+	if err := zero.Close(); err == nil {
+		t.Error("unexpectedly passed Close() test with zero-value queue")
+	}
+	if n := zero.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with zero-value queue")
+	}
+	if s := zero.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with zero-value queue")
+	}
+
+	var d *hqueue.Delayed
+	if d.Item() != nil || !d.At().IsZero() {
+		t.Error("unexpectedly passed Delayed test with bad pointer")
+	}
+}
+
+func TestDelayOrder(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := hqueue.NewDelay(clock)
+
+	q.Put("c", epoch.Add(3*time.Second))
+	q.Put("a", epoch.Add(time.Second))
+	q.Put("b1", epoch.Add(2*time.Second))
+	q.Put("b2", epoch.Add(2*time.Second))
+	checkDelay(t, q, "a, b1, b2, c", 4)
+
+	// Nothing is due yet.
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly took item before it was due")
+		t.Log("\tExpected:", hqueue.ErrEmpty)
+		t.Log("\tReceived:", err)
+	}
+
+	clock.Advance(time.Second)
+	if v, err := q.TryTake(); v != "a" || err != nil {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: a <nil>")
+		t.Log("\tReceived:", v, err)
+	}
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly took item before it was due")
+	}
+
+	// Once time has moved past several deadlines, they all come out in order.
+	clock.Advance(time.Hour)
+	for _, want := range []string{"b1", "b2", "c"} {
+		if v, err := q.TryTake(); v != want || err != nil {
+			t.Error("Incorrect item taken")
+			t.Log("\tExpected:", want)
+			t.Log("\tReceived:", v, err)
+		}
+	}
+	checkDelay(t, q, "<empty>", 0)
+}
+
+func TestDelayFarDeadlines(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := hqueue.NewDelay(clock)
+
+	// Deadlines far in the future must still be kept apart down to the nanosecond.
+	far := epoch.Add(3 * 365 * 24 * time.Hour)
+	q.Put("c", far.Add(3))
+	q.Put("b", far.Add(2))
+	q.Put("a", far.Add(1))
+	checkDelay(t, q, "a, b, c", 3)
+
+	clock.Advance(far.Add(2).Sub(epoch))
+	for _, want := range []string{"a", "b"} {
+		if v, err := q.TryTake(); v != want || err != nil {
+			t.Error("Incorrect item taken")
+			t.Log("\tExpected:", want)
+			t.Log("\tReceived:", v, err)
+		}
+	}
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly took item before it was due")
+	}
+}
+
+func TestDelayTake(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := hqueue.NewDelay(clock)
+	ctx := context.Background()
+
+	q.Put("late", epoch.Add(time.Minute))
+
+	got := make(chan interface{})
+	take := func() {
+		v, err := q.Take(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- v
+	}
+
+	// Take should wait for the item to come due.
+	go take()
+	waitForTimers(clock, 1)
+	clock.Advance(59 * time.Second)
+	select {
+	case v := <-got:
+		t.Fatal("Took item before it was due:", v)
+	default:
+	}
+	clock.Advance(time.Second)
+	if v := <-got; v != "late" {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: late")
+		t.Log("\tReceived:", v)
+	}
+
+	// An earlier item put in while waiting should be taken first.
+	q.Put("late", epoch.Add(2*time.Minute))
+	go take()
+	waitForTimers(clock, 1)
+	q.Put("early", clock.Now().Add(time.Second))
+	waitForTimers(clock, 1)
+	clock.Advance(time.Second)
+	if v := <-got; v != "early" {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: early")
+		t.Log("\tReceived:", v)
+	}
+
+	// Items that are already due are taken right away.
+	q.Put("now", clock.Now())
+	go take()
+	if v := <-got; v != "now" {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: now")
+		t.Log("\tReceived:", v)
+	}
+	checkDelay(t, q, "late", 1)
+}
+
+func TestDelayCancel(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := hqueue.NewDelay(clock)
+
+	a, _ := q.Put("a", epoch.Add(time.Second))
+	q.Put("b", epoch.Add(2*time.Second))
+	if a.Item() != "a" || !a.At().Equal(epoch.Add(time.Second)) {
+		t.Error("Incorrect handle")
+		t.Log("\tExpected: a", epoch.Add(time.Second))
+		t.Log("\tReceived:", a.Item(), a.At())
+	}
+
+	if err := q.Cancel(a); err != nil {
+		t.Error(err)
+	}
+	checkDelay(t, q, "b", 1)
+	if err := q.Cancel(a); !errors.Is(err, hqueue.ErrBadHandle) {
+		t.Error("unexpectedly passed Cancel() test with cancelled item")
+	}
+	other, _ := hqueue.NewDelay(clock).Put("x", epoch)
+	if err := q.Cancel(other); !errors.Is(err, hqueue.ErrBadHandle) {
+		t.Error("unexpectedly passed Cancel() test with item from another queue")
+	}
+
+	clock.Advance(time.Hour)
+	if v, _ := q.TryTake(); v != "b" {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: b")
+		t.Log("\tReceived:", v)
+	}
+
+	// Waiting should stop when the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := q.Take(ctx)
+		done <- err
+	}()
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Error("Take() did not stop when context was cancelled")
+		t.Log("\tExpected:", context.Canceled)
+		t.Log("\tReceived:", err)
+	}
+}
+
+func TestDelayClose(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := hqueue.NewDelay(clock)
+	ctx := context.Background()
+
+	q.Put("a", epoch.Add(time.Second))
+	if err := q.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := q.Close(); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed Close() test for closed queue")
+	}
+	if _, err := q.Put("b", epoch); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed Put() test for closed queue")
+	}
+
+	// The remaining item is still delivered when it is due.
+	done := make(chan error)
+	go func() {
+		v, err := q.Take(ctx)
+		if v != "a" {
+			t.Error("Incorrect item taken after closing:", v)
+		}
+		done <- err
+	}()
+	waitForTimers(clock, 1)
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+
+	if _, err := q.Take(ctx); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("Take() did not receive ErrClosed after draining")
+	}
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("TryTake() did not receive ErrClosed after draining")
+	}
+}
+
+func TestDelaySystemClock(t *testing.T) {
+	q := hqueue.NewDelay(nil)
+	q.Put("a", time.Now().Add(10*time.Millisecond))
+
+	start := time.Now()
+	if v, err := q.Take(context.Background()); v != "a" || err != nil {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: a <nil>")
+		t.Log("\tReceived:", v, err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Error("Item was taken too early:", elapsed)
+	}
+}
+
+// waitForTimers waits until another goroutine has started n timers on the clock.
+func waitForTimers(clock *hqueue.FakeClock, n int) {
+	for clock.Timers() != n {
+		runtime.Gosched()
+	}
+}
+
+func checkDelay(t *testing.T, q *hqueue.DelayQueue, want string, count int) {
+	if q.String() != want {
+		t.Error("Queue contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", q)
+	}
+	if n := q.Count(); n != count {
+		t.Error("Incorrect count")
+		t.Log("\tExpected:", count)
+		t.Log("\tReceived:", n)
+	}
+}
@@ -0,0 +1,243 @@
+package hqueue
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrOutOfRange is returned when an index is past either end of a deque.
+	ErrOutOfRange = fmt.Errorf("index out of range")
+
+	// This is the standard error message when trying to use an invalid deque.
+	errBadDeque = fmt.Errorf("must create deque with NewDeque() first")
+)
+
+// blockSize is the number of items held by each of a deque's blocks.
+const blockSize = 64
+
+// Deque is a double-ended queue. Items can be added and removed at both the front and the back in
+// constant time, and any item can be read by its position.
+//
+// The items are stored in fixed-size blocks, and the deque keeps a list of pointers to those blocks
+// with spare room at both ends. Adding to either end only fills in the next slot of the end block, or
+// allocates a new block when that one is full, so items never have to be moved. Only the list of
+// pointers is ever copied, and that is small and rarely needs to grow.
+type Deque struct {
+	blocks [][]interface{}
+
+	// Items are numbered across all of the blocks as if they were one long buffer. The first item is at
+	// position first, and the rest follow it.
+	first int
+	count int
+}
+
+// NewDeque creates a new deque.
+func NewDeque() *Deque {
+	return new(Deque)
+}
+
+// PushFront adds one or more items to the front of the deque. The items keep the order provided, so
+// the first argument ends up at the very front.
+func (d *Deque) PushFront(items ...interface{}) error {
+	if d == nil {
+		return errBadDeque
+	}
+
+	for i := len(items) - 1; i >= 0; i-- {
+		if d.first == 0 {
+			d.makeRoom()
+		}
+		d.first--
+		d.count++
+		d.set(d.first, items[i])
+	}
+
+	return nil
+}
+
+// PushBack adds one or more items to the back of the deque. The items will be added in the order
+// provided.
+func (d *Deque) PushBack(items ...interface{}) error {
+	if d == nil {
+		return errBadDeque
+	}
+
+	for _, item := range items {
+		if d.first+d.count == len(d.blocks)*blockSize {
+			d.makeRoom()
+		}
+		d.set(d.first+d.count, item)
+		d.count++
+	}
+
+	return nil
+}
+
+// PopFront removes the first item in the deque and returns its value. This returns ErrEmpty if there
+// are no items in the deque.
+func (d *Deque) PopFront() (interface{}, error) {
+	if d == nil {
+		return nil, errBadDeque
+	} else if d.count == 0 {
+		return nil, ErrEmpty
+	}
+
+	item := d.clear(d.first)
+	d.first++
+	d.count--
+	if d.count == 0 {
+		*d = Deque{}
+	}
+
+	return item, nil
+}
+
+// PopBack removes the last item in the deque and returns its value. This returns ErrEmpty if there are
+// no items in the deque.
+func (d *Deque) PopBack() (interface{}, error) {
+	if d == nil {
+		return nil, errBadDeque
+	} else if d.count == 0 {
+		return nil, ErrEmpty
+	}
+
+	item := d.clear(d.first + d.count - 1)
+	d.count--
+	if d.count == 0 {
+		*d = Deque{}
+	}
+
+	return item, nil
+}
+
+// PeekFront returns the first item in the deque without removing it. This returns ErrEmpty if there are
+// no items in the deque.
+func (d *Deque) PeekFront() (interface{}, error) {
+	if d == nil {
+		return nil, errBadDeque
+	} else if d.count == 0 {
+		return nil, ErrEmpty
+	}
+
+	return d.get(d.first), nil
+}
+
+// PeekBack returns the last item in the deque without removing it. This returns ErrEmpty if there are
+// no items in the deque.
+func (d *Deque) PeekBack() (interface{}, error) {
+	if d == nil {
+		return nil, errBadDeque
+	} else if d.count == 0 {
+		return nil, ErrEmpty
+	}
+
+	return d.get(d.first + d.count - 1), nil
+}
+
+// At returns the item at index i, where the front of the deque is at index 0. This returns
+// ErrOutOfRange if there is no item at that index.
+func (d *Deque) At(i int) (interface{}, error) {
+	if d == nil {
+		return nil, errBadDeque
+	} else if i < 0 || i >= d.count {
+		return nil, ErrOutOfRange
+	}
+
+	return d.get(d.first + i), nil
+}
+
+// Count gets the current number of items in the deque.
+func (d *Deque) Count() int {
+	if d == nil {
+		return -1
+	}
+
+	return d.count
+}
+
+// Clear resets the deque to its initial state.
+func (d *Deque) Clear() error {
+	if d == nil {
+		return errBadDeque
+	}
+
+	*d = Deque{}
+
+	return nil
+}
+
+// String displays the deque's contents, from the front to the back.
+func (d *Deque) String() string {
+	if d == nil {
+		return "<nil>"
+	} else if d.count == 0 {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for i := 0; i < d.count; i++ {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", d.get(d.first+i)))
+	}
+
+	return builder.String()
+}
+
+// get returns the item at position p.
+func (d *Deque) get(p int) interface{} {
+	return d.blocks[p/blockSize][p%blockSize]
+}
+
+// set stores the item at position p, allocating the block that holds it if needed.
+func (d *Deque) set(p int, item interface{}) {
+	b := p / blockSize
+	if d.blocks[b] == nil {
+		d.blocks[b] = make([]interface{}, blockSize)
+	}
+	d.blocks[b][p%blockSize] = item
+}
+
+// clear removes and returns the item at position p, releasing its block if it is no longer needed.
+// Position p must be at one of the ends of the deque.
+func (d *Deque) clear(p int) interface{} {
+	b := p / blockSize
+	item := d.blocks[b][p%blockSize]
+
+	// Drop the reference so the item can be garbage collected.
+	d.blocks[b][p%blockSize] = nil
+
+	// If no other item lives in this block, then the whole block can go.
+	if d.count == 1 || (p == d.first && p%blockSize == blockSize-1) || (p != d.first && p%blockSize == 0) {
+		d.blocks[b] = nil
+	}
+
+	return item
+}
+
+// makeRoom moves the block pointers so that there are free blocks on both sides of the items. If the
+// blocks in use take up more than half of the list, then the list is doubled first.
+func (d *Deque) makeRoom() {
+	var start, used int
+	if d.count > 0 {
+		start = d.first / blockSize
+		used = (d.first+d.count-1)/blockSize - start + 1
+	}
+
+	size := len(d.blocks)
+	if size < 4 {
+		size = 4
+	}
+	for used*2 > size {
+		size *= 2
+	}
+
+	blocks := make([][]interface{}, size)
+	offset := (size - used) / 2
+	copy(blocks[offset:], d.blocks[start:start+used])
+
+	d.blocks = blocks
+	d.first = offset*blockSize + d.first%blockSize
+}
@@ -0,0 +1,229 @@
+package hqueue_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestDequeBadPtr(t *testing.T) {
+	var d *hqueue.Deque
+
+	if err := d.PushFront(1); err == nil {
+		t.Error("unexpectedly passed PushFront() test with bad pointer")
+	}
+	if err := d.PushBack(1); err == nil {
+		t.Error("unexpectedly passed PushBack() test with bad pointer")
+	}
+	if _, err := d.PopFront(); err == nil {
+		t.Error("unexpectedly passed PopFront() test with bad pointer")
+	}
+	if _, err := d.PopBack(); err == nil {
+		t.Error("unexpectedly passed PopBack() test with bad pointer")
+	}
+	if _, err := d.PeekFront(); err == nil {
+		t.Error("unexpectedly passed PeekFront() test with bad pointer")
+	}
+	if _, err := d.PeekBack(); err == nil {
+		t.Error("unexpectedly passed PeekBack() test with bad pointer")
+	}
+	if _, err := d.At(0); err == nil {
+		t.Error("unexpectedly passed At() test with bad pointer")
+	}
+	if n := d.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if err := d.Clear(); err == nil {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+	if s := d.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+	}
+}
+
+func TestDequeEnds(t *testing.T) {
+	d := hqueue.NewDeque()
+	checkDeque(t, d, "<empty>", 0)
+
+	if _, err := d.PopFront(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly passed PopFront() test for empty deque")
+	}
+	if _, err := d.PopBack(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly passed PopBack() test for empty deque")
+	}
+	if _, err := d.PeekFront(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly passed PeekFront() test for empty deque")
+	}
+	if _, err := d.PeekBack(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly passed PeekBack() test for empty deque")
+	}
+
+	d.PushBack(3, 4)
+	d.PushFront(1, 2)
+	d.PushBack(5)
+	d.PushFront(0)
+	checkDeque(t, d, "0, 1, 2, 3, 4, 5", 6)
+
+	if v, err := d.PeekFront(); v != 0 || err != nil {
+		t.Error("Incorrect front item")
+		t.Log("\tExpected: 0 <nil>")
+		t.Log("\tReceived:", v, err)
+	}
+	if v, err := d.PeekBack(); v != 5 || err != nil {
+		t.Error("Incorrect back item")
+		t.Log("\tExpected: 5 <nil>")
+		t.Log("\tReceived:", v, err)
+	}
+	if v, err := d.At(3); v != 3 || err != nil {
+		t.Error("Incorrect item at index 3")
+		t.Log("\tExpected: 3 <nil>")
+		t.Log("\tReceived:", v, err)
+	}
+	if _, err := d.At(6); !errors.Is(err, hqueue.ErrOutOfRange) {
+		t.Error("unexpectedly passed At() test with index past the end")
+	}
+	if _, err := d.At(-1); !errors.Is(err, hqueue.ErrOutOfRange) {
+		t.Error("unexpectedly passed At() test with negative index")
+	}
+
+	if v, _ := d.PopFront(); v != 0 {
+		t.Error("Incorrect item popped from front")
+		t.Log("\tExpected: 0")
+		t.Log("\tReceived:", v)
+	}
+	if v, _ := d.PopBack(); v != 5 {
+		t.Error("Incorrect item popped from back")
+		t.Log("\tExpected: 5")
+		t.Log("\tReceived:", v)
+	}
+	checkDeque(t, d, "1, 2, 3, 4", 4)
+
+	if err := d.Clear(); err != nil {
+		t.Error(err)
+	}
+	checkDeque(t, d, "<empty>", 0)
+}
+
+func TestDequeRandom(t *testing.T) {
+	// Compare the deque against a plain slice while randomly pushing and popping at both ends. Runs of
+	// the same operation make sure that the deque grows across many blocks in both directions.
+	d := hqueue.NewDeque()
+	var model []interface{}
+
+	for i := 0; i < 20000; i++ {
+		op := (i / 500) % 4
+		if rand.Intn(3) == 0 {
+			op = rand.Intn(4)
+		}
+
+		switch op {
+		case 0:
+			d.PushFront(i)
+			model = append([]interface{}{i}, model...)
+		case 1:
+			d.PushBack(i)
+			model = append(model, i)
+		case 2:
+			v, err := d.PopFront()
+			if len(model) == 0 {
+				if !errors.Is(err, hqueue.ErrEmpty) {
+					t.Fatal("Expected ErrEmpty, received", v, err)
+				}
+				continue
+			}
+			if v != model[0] {
+				t.Fatal("Incorrect item popped from front:", v, "instead of", model[0])
+			}
+			model = model[1:]
+		case 3:
+			v, err := d.PopBack()
+			if len(model) == 0 {
+				if !errors.Is(err, hqueue.ErrEmpty) {
+					t.Fatal("Expected ErrEmpty, received", v, err)
+				}
+				continue
+			}
+			if v != model[len(model)-1] {
+				t.Fatal("Incorrect item popped from back:", v, "instead of", model[len(model)-1])
+			}
+			model = model[:len(model)-1]
+		}
+
+		if d.Count() != len(model) {
+			t.Fatal("Incorrect count:", d.Count(), "instead of", len(model))
+		}
+		if len(model) > 0 {
+			j := rand.Intn(len(model))
+			if v, _ := d.At(j); v != model[j] {
+				t.Fatal("Incorrect item at index", j, ":", v, "instead of", model[j])
+			}
+		}
+	}
+}
+
+func TestDequeSlidingWindow(t *testing.T) {
+	// Find the maximum of every window of 3 items, which needs removal from both ends.
+	values := []int{1, 3, -1, -3, 5, 3, 6, 7}
+	want := []int{3, 3, 5, 5, 6, 7}
+
+	d := hqueue.NewDeque()
+	var got []int
+	for i, v := range values {
+		// Drop indexes that have left the window from the front, and smaller values from the back.
+		if front, err := d.PeekFront(); err == nil && front.(int) <= i-3 {
+			d.PopFront()
+		}
+		for {
+			back, err := d.PeekBack()
+			if err != nil || values[back.(int)] > v {
+				break
+			}
+			d.PopBack()
+		}
+		d.PushBack(i)
+
+		if i >= 2 {
+			front, _ := d.PeekFront()
+			got = append(got, values[front.(int)])
+		}
+	}
+
+	if len(got) != len(want) {
+		t.Fatal("Incorrect number of windows:", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Error("Incorrect window maximums")
+			t.Log("\tExpected:", want)
+			t.Log("\tReceived:", got)
+			break
+		}
+	}
+}
+
+func BenchmarkDequePushPop(b *testing.B) {
+	d := hqueue.NewDeque()
+	for i := 0; i < b.N; i++ {
+		d.PushBack(i)
+		d.PushFront(i)
+	}
+	for i := 0; i < b.N; i++ {
+		d.PopFront()
+		d.PopBack()
+	}
+}
+
+func checkDeque(t *testing.T, d *hqueue.Deque, want string, count int) {
+	if d.String() != want {
+		t.Error("Deque contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", d)
+	}
+	if n := d.Count(); n != count {
+		t.Error("Incorrect count")
+		t.Log("\tExpected:", count)
+		t.Log("\tReceived:", n)
+	}
+}
@@ -0,0 +1,683 @@
+package hqueue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCorrupt is returned when a durable queue's files have been damaged somewhere other than at the
+	// very end of the log, where damage is expected after a crash and is cleaned up automatically.
+	ErrCorrupt = fmt.Errorf("queue data is corrupt")
+
+	// This is the standard error message when trying to use an invalid durable queue.
+	errBadDurableQueue = fmt.Errorf("must open queue with OpenDurable() first")
+)
+
+const (
+	// defaultSegmentSize is how large a segment file can grow before a new one is started.
+	defaultSegmentSize = 4 << 20
+
+	// maxRecordSize is the largest encoded item that can be stored. Anything claiming to be larger is
+	// treated as damage.
+	maxRecordSize = 1 << 30
+
+	// headerSize is the length of the header before each record: the length of the data and its
+	// checksum.
+	headerSize = 8
+
+	// segmentExt is the extension of the segment files, which are named after the sequence number of
+	// their first record.
+	segmentExt = ".log"
+
+	// offsetFile is the name of the file that holds the committed read offset.
+	offsetFile = "offset"
+)
+
+// Codec converts items to and from bytes so that a durable queue can store them.
+type Codec interface {
+	Encode(item interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// JSONCodec stores items as JSON. Decoded items have the types that encoding/json gives to values
+// decoded into an interface{}: numbers come back as float64, objects as map[string]interface{}, and so
+// on. This is the default codec for durable queues.
+type JSONCodec struct{}
+
+// Encode converts the item into JSON.
+func (JSONCodec) Encode(item interface{}) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+// Decode converts JSON back into an item.
+func (JSONCodec) Decode(data []byte) (interface{}, error) {
+	var item interface{}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// SyncPolicy controls how often a durable queue flushes its writes to disk.
+type SyncPolicy int
+
+const (
+	// SyncNever leaves flushing up to the operating system, except when the queue is closed. This is
+	// the fastest, but items put in shortly before a machine crash can be lost.
+	SyncNever SyncPolicy = iota
+	// SyncAlways flushes after every write, so that no acknowledged item is ever lost.
+	SyncAlways
+	// SyncInterval flushes on the first write after DurableOptions.Interval has passed since the last
+	// flush. At most that much time's worth of items can be lost in a crash.
+	SyncInterval
+)
+
+// DurableOptions holds the settings for a durable queue. The zero value of each field picks its
+// default.
+type DurableOptions struct {
+	// Codec converts items to and from bytes. The default is JSONCodec.
+	Codec Codec
+	// SegmentSize is how many bytes a segment file can hold before a new one is started. Segments are
+	// deleted once all of their items have been committed. The default is 4 MiB.
+	SegmentSize int64
+	// Sync controls how often writes are flushed to disk. The default is SyncNever.
+	Sync SyncPolicy
+	// Interval is the longest time between flushes when Sync is SyncInterval.
+	Interval time.Duration
+}
+
+// DurableQueue is a first-in/first-out queue that is stored on disk, so its items survive restarts and
+// crashes. It is safe for use by many goroutines at once.
+//
+// Items are appended to a write-ahead log that is split into segment files. Reading from the queue
+// moves a read offset forward, but that offset is only saved to disk when it is committed. If the
+// process stops before committing, then the items read since the last commit are delivered again when
+// the queue is reopened. This means that every item is delivered at least once, as long as it is only
+// committed after it has been dealt with.
+//
+// Each record in the log carries a checksum. When the queue is opened, the last segment is checked, and
+// everything from the first damaged or partly written record onward is removed. This cleans up after a
+// process that stopped in the middle of a write.
+type DurableQueue struct {
+	mu     sync.Mutex
+	dir    string
+	opts   DurableOptions
+	closed bool
+
+	segments []segment
+
+	// writer is the last segment, which new items are appended to. writeSeq is the sequence number
+	// that the next item will get.
+	writer   *os.File
+	size     int64
+	writeSeq uint64
+	lastSync time.Time
+
+	// reader is open to the segment holding the next item to read, which is segments[readIndex].
+	// readSeq is the sequence number of the next item to read, and committed is the last saved read
+	// offset.
+	reader    *os.File
+	readIndex int
+	readSeq   uint64
+	committed uint64
+}
+
+// segment describes one file of the log.
+type segment struct {
+	base uint64
+	path string
+}
+
+// OpenDurable opens the durable queue stored in the directory, creating the directory and an empty queue
+// if needed. If opts is nil, then the defaults are used.
+func OpenDurable(dir string, opts *DurableOptions) (*DurableQueue, error) {
+	q := new(DurableQueue)
+	q.dir = dir
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.Codec == nil {
+		q.opts.Codec = JSONCodec{}
+	}
+	if q.opts.SegmentSize <= 0 {
+		q.opts.SegmentSize = defaultSegmentSize
+	}
+	if q.opts.Sync == SyncInterval && q.opts.Interval <= 0 {
+		return nil, fmt.Errorf("sync interval must be positive")
+	} else if q.opts.Sync < SyncNever || q.opts.Sync > SyncInterval {
+		return nil, fmt.Errorf("invalid sync policy")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := q.recover(); err != nil {
+		q.closeFiles()
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Put adds an item to the back of the queue.
+func (q *DurableQueue) Put(item interface{}) error {
+	if q == nil || q.opts.Codec == nil {
+		return errBadDurableQueue
+	}
+
+	data, err := q.opts.Codec.Encode(item)
+	if err != nil {
+		return err
+	} else if len(data) > maxRecordSize {
+		return fmt.Errorf("encoded item is too large")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	// Start a new segment if this one is full.
+	if q.size >= q.opts.SegmentSize && q.writeSeq > q.segments[len(q.segments)-1].base {
+		if err := q.roll(); err != nil {
+			return err
+		}
+	}
+
+	record := make([]byte, headerSize+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(data))
+	copy(record[headerSize:], data)
+
+	if _, err := q.writer.Write(record); err != nil {
+		// Cut off whatever part of the record made it out, so that the log stays whole.
+		q.writer.Truncate(q.size)
+		q.writer.Seek(q.size, io.SeekStart)
+		return err
+	}
+	q.size += int64(len(record))
+	q.writeSeq++
+
+	return q.maybeSync()
+}
+
+// Next reads the next item from the queue and moves the read offset past it. The item stays on disk
+// until the read offset is committed. This returns ErrEmpty if every item has been read. If the item
+// can't be decoded, then it is skipped and the codec's error is returned.
+func (q *DurableQueue) Next() (interface{}, error) {
+	if q == nil || q.opts.Codec == nil {
+		return nil, errBadDurableQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, ErrClosed
+	} else if q.readSeq == q.writeSeq {
+		return nil, ErrEmpty
+	}
+
+	data, _, err := readRecord(q.reader)
+	if err != nil {
+		// Put the reader back where it was, in case the problem goes away.
+		q.openReader(q.readSeq)
+		return nil, fmt.Errorf("reading item %d: %w", q.readSeq, err)
+	}
+
+	q.readSeq++
+	if err := q.seekReader(); err != nil {
+		return nil, err
+	}
+
+	return q.opts.Codec.Decode(data)
+}
+
+// Commit saves the read offset, so that the items read so far will not be delivered again. Segments
+// whose items have all been committed are deleted.
+func (q *DurableQueue) Commit() error {
+	if q == nil || q.opts.Codec == nil {
+		return errBadDurableQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	} else if q.readSeq == q.committed {
+		return nil
+	}
+
+	if err := q.writeOffset(q.readSeq); err != nil {
+		return err
+	}
+	q.committed = q.readSeq
+
+	return q.compact()
+}
+
+// Rewind moves the read offset back to the last committed offset, so that the items read since then
+// will be read again.
+func (q *DurableQueue) Rewind() error {
+	if q == nil || q.opts.Codec == nil {
+		return errBadDurableQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	return q.openReader(q.committed)
+}
+
+// Count gets the number of items that haven't been read yet.
+func (q *DurableQueue) Count() int {
+	if q == nil || q.opts.Codec == nil {
+		return -1
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return int(q.writeSeq - q.readSeq)
+}
+
+// Sync flushes all writes to disk.
+func (q *DurableQueue) Sync() error {
+	if q == nil || q.opts.Codec == nil {
+		return errBadDurableQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	return q.sync()
+}
+
+// Close flushes all writes to disk and closes the queue's files. The read offset is not committed.
+func (q *DurableQueue) Close() error {
+	if q == nil || q.opts.Codec == nil {
+		return errBadDurableQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	q.closed = true
+
+	err := q.sync()
+	if cerr := q.closeFiles(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// String displays the queue's directory and how many items are waiting to be read.
+func (q *DurableQueue) String() string {
+	if q == nil || q.opts.Codec == nil {
+		return "<nil>"
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return fmt.Sprintf("%s (%d unread)", q.dir, q.writeSeq-q.readSeq)
+}
+
+// recover loads the state of the queue from disk. It finds all of the segments, checks the records in the
+// last one and cuts off any that are damaged, and positions the reader at the committed offset.
+func (q *DurableQueue) recover() error {
+	infos, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, segment{base, filepath.Join(q.dir, name)})
+	}
+	sort.Slice(q.segments, func(i, j int) bool {
+		return q.segments[i].base < q.segments[j].base
+	})
+
+	committed, err := q.readOffset()
+	if err != nil {
+		return err
+	}
+
+	if len(q.segments) == 0 {
+		// Start a fresh log where the last one left off, if there was one.
+		if err := q.createSegment(committed); err != nil {
+			return err
+		}
+	} else if err := q.openWriter(); err != nil {
+		return err
+	}
+
+	if committed < q.segments[0].base || committed > q.writeSeq {
+		return fmt.Errorf("committed offset %d is outside of the log: %w", committed, ErrCorrupt)
+	}
+	q.committed = committed
+
+	return q.openReader(committed)
+}
+
+// openWriter opens the last segment for appending. Any damaged or partly written records at its end are
+// cut off.
+func (q *DurableQueue) openWriter() error {
+	last := q.segments[len(q.segments)-1]
+	f, err := os.OpenFile(last.path, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	q.writer = f
+
+	var size int64
+	count := uint64(0)
+	for {
+		_, n, err := readRecord(f)
+		if err != nil {
+			break
+		}
+		size += n
+		count++
+	}
+
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+	q.size = size
+	q.writeSeq = last.base + count
+
+	return nil
+}
+
+// openReader positions the reader at the item with sequence number seq.
+func (q *DurableQueue) openReader(seq uint64) error {
+	i := sort.Search(len(q.segments), func(i int) bool {
+		return q.segments[i].base > seq
+	}) - 1
+	if i < 0 {
+		return fmt.Errorf("item %d has been deleted: %w", seq, ErrCorrupt)
+	}
+
+	if q.reader != nil {
+		q.reader.Close()
+		q.reader = nil
+	}
+	f, err := os.Open(q.segments[i].path)
+	if err != nil {
+		return err
+	}
+	q.reader = f
+	q.readIndex = i
+	q.readSeq = q.segments[i].base
+
+	// Skip over the items before the one we want.
+	for q.readSeq < seq {
+		if _, _, err := readRecord(f); err != nil {
+			return fmt.Errorf("reading segment %s: %w", q.segments[i].path, err)
+		}
+		q.readSeq++
+	}
+
+	return q.seekReader()
+}
+
+// seekReader moves the reader to the next segment if it has read everything in its current one. This
+// way, the reader never holds on to a segment that could be deleted.
+func (q *DurableQueue) seekReader() error {
+	if q.readIndex+1 >= len(q.segments) || q.readSeq < q.segments[q.readIndex+1].base {
+		return nil
+	}
+
+	q.reader.Close()
+	q.reader = nil
+	f, err := os.Open(q.segments[q.readIndex+1].path)
+	if err != nil {
+		return err
+	}
+	q.reader = f
+	q.readIndex++
+
+	return nil
+}
+
+// roll finishes the current segment and starts a new one.
+func (q *DurableQueue) roll() error {
+	if q.opts.Sync != SyncNever {
+		if err := q.writer.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := q.writer.Close(); err != nil {
+		return err
+	}
+	q.writer = nil
+
+	return q.createSegment(q.writeSeq)
+}
+
+// createSegment creates an empty segment whose first item will have sequence number base, and makes it
+// the one that new items are appended to.
+func (q *DurableQueue) createSegment(base uint64) error {
+	path := filepath.Join(q.dir, fmt.Sprintf("%020d%s", base, segmentExt))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	q.segments = append(q.segments, segment{base, path})
+	q.writer = f
+	q.size = 0
+	q.writeSeq = base
+
+	// The reader might have been waiting at the end of the previous segment.
+	if q.reader != nil {
+		if err := q.seekReader(); err != nil {
+			return err
+		}
+	}
+
+	if q.opts.Sync != SyncNever {
+		return syncDir(q.dir)
+	}
+
+	return nil
+}
+
+// compact deletes the segments whose items have all been committed. The last segment is always kept.
+func (q *DurableQueue) compact() error {
+	n := 0
+	for n+1 < len(q.segments) && q.segments[n+1].base <= q.committed {
+		if err := os.Remove(q.segments[n].path); err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+
+	q.segments = append(q.segments[:0], q.segments[n:]...)
+	q.readIndex -= n
+
+	if q.opts.Sync != SyncNever {
+		return syncDir(q.dir)
+	}
+
+	return nil
+}
+
+// readOffset reads the committed offset from disk. If it hasn't been saved yet, then the offset is the
+// start of the first segment.
+func (q *DurableQueue) readOffset() (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(q.dir, offsetFile))
+	if errors.Is(err, os.ErrNotExist) {
+		if len(q.segments) > 0 {
+			return q.segments[0].base, nil
+		}
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if len(data) != 12 || crc32.ChecksumIEEE(data[:8]) != binary.BigEndian.Uint32(data[8:]) {
+		return 0, fmt.Errorf("bad offset file: %w", ErrCorrupt)
+	}
+
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// writeOffset saves the committed offset to disk. The offset is written to a temporary file that then
+// replaces the old one, so that a crash can't leave a half-written offset behind.
+func (q *DurableQueue) writeOffset(seq uint64) error {
+	data := make([]byte, 12)
+	binary.BigEndian.PutUint64(data, seq)
+	binary.BigEndian.PutUint32(data[8:], crc32.ChecksumIEEE(data[:8]))
+
+	path := filepath.Join(q.dir, offsetFile)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if q.opts.Sync != SyncNever {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	if q.opts.Sync != SyncNever {
+		return syncDir(q.dir)
+	}
+
+	return nil
+}
+
+// maybeSync flushes the last write to disk if the sync policy calls for it.
+func (q *DurableQueue) maybeSync() error {
+	switch q.opts.Sync {
+	case SyncAlways:
+		return q.sync()
+	case SyncInterval:
+		if time.Since(q.lastSync) >= q.opts.Interval {
+			return q.sync()
+		}
+	case SyncNever:
+	}
+
+	return nil
+}
+
+// sync flushes the segment being written to disk.
+func (q *DurableQueue) sync() error {
+	if err := q.writer.Sync(); err != nil {
+		return err
+	}
+	q.lastSync = time.Now()
+
+	return nil
+}
+
+// closeFiles closes the reader and writer.
+func (q *DurableQueue) closeFiles() error {
+	var err error
+	if q.reader != nil {
+		err = q.reader.Close()
+		q.reader = nil
+	}
+	if q.writer != nil {
+		if werr := q.writer.Close(); err == nil {
+			err = werr
+		}
+		q.writer = nil
+	}
+
+	return err
+}
+
+// readRecord reads one record from the file and checks it. It returns the record's data and how many
+// bytes it took up.
+func readRecord(r io.Reader) ([]byte, int64, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header)
+	if length > maxRecordSize {
+		return nil, 0, fmt.Errorf("bad record length %d: %w", length, ErrCorrupt)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, 0, fmt.Errorf("bad record checksum: %w", ErrCorrupt)
+	}
+
+	return data, int64(headerSize + length), nil
+}
+
+// syncDir flushes the directory's list of files to disk, so that new, renamed, and deleted files are not
+// lost in a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,367 @@
+package hqueue_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestDurableBadPtr(t *testing.T) {
+	var q *hqueue.DurableQueue
+
+	if err := q.Put(1); err == nil {
+		t.Error("unexpectedly passed Put() test with bad pointer")
+	}
+	if _, err := q.Next(); err == nil {
+		t.Error("unexpectedly passed Next() test with bad pointer")
+	}
+	if err := q.Commit(); err == nil {
+		t.Error("unexpectedly passed Commit() test with bad pointer")
+	}
+	if err := q.Rewind(); err == nil {
+		t.Error("unexpectedly passed Rewind() test with bad pointer")
+	}
+	if n := q.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if err := q.Sync(); err == nil {
+		t.Error("unexpectedly passed Sync() test with bad pointer")
+	}
+	if err := q.Close(); err == nil {
+		t.Error("unexpectedly passed Close() test with bad pointer")
+	}
+	if s := q.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+	}
+
+	// A queue that wasn't opened with OpenDurable() should be rejected too.
+	var zero hqueue.DurableQueue
+	if err := zero.Put(1); err == nil {
+		t.Error("unexpectedly passed Put() test with zero-value queue")
+	}
+	if _, err := zero.Next(); err == nil {
+		t.Error("unexpectedly passed Next() test with zero-value queue")
+	}
+	if err := zero.Commit(); err == nil {
+		t.Error("unexpectedly passed Commit() test with zero-value queue")
+	}
+	if err := zero.Rewind(); err == nil {
+		t.Error("unexpectedly passed Rewind() test with zero-value queue")
+	}
+	if n := zero.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with zero-value queue")
+	}
+	if err := zero.Sync(); err == nil {
+		t.Error("unexpectedly passed Sync() test with zero-value queue")
+	}
+	if err := zero.Close(); err == nil {
+		t.Error("unexpectedly passed Close() test with zero-value queue")
+	}
+	if s := zero.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with zero-value queue")
+	}
+}
+
+func TestDurableBadArgs(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := hqueue.OpenDurable(dir, &hqueue.DurableOptions{Sync: hqueue.SyncInterval}); err == nil {
+		t.Error("unexpectedly passed OpenDurable() test with missing sync interval")
+	}
+	if _, err := hqueue.OpenDurable(dir, &hqueue.DurableOptions{Sync: -1}); err == nil {
+		t.Error("unexpectedly passed OpenDurable() test with invalid sync policy")
+	}
+
+	// The directory can't be a file.
+	file := filepath.Join(dir, "file")
+	ioutil.WriteFile(file, nil, 0644)
+	if _, err := hqueue.OpenDurable(file, nil); err == nil {
+		t.Error("unexpectedly passed OpenDurable() test with file for directory")
+	}
+}
+
+func TestDurableBasic(t *testing.T) {
+	dir := t.TempDir()
+	q := openDurable(t, dir, nil)
+
+	if _, err := q.Next(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly passed Next() test for empty queue")
+		t.Log("\tExpected:", hqueue.ErrEmpty)
+		t.Log("\tReceived:", err)
+	}
+
+	for _, v := range []interface{}{"a", 2, map[string]interface{}{"k": "v"}} {
+		if err := q.Put(v); err != nil {
+			t.Error(err)
+		}
+	}
+	checkDurableCount(t, q, 3)
+
+	// JSON gives numbers back as float64.
+	checkNext(t, q, "a")
+	checkNext(t, q, float64(2))
+	checkDurableCount(t, q, 1)
+
+	// Rewinding goes back to the last commit, which is the start.
+	if err := q.Rewind(); err != nil {
+		t.Error(err)
+	}
+	checkDurableCount(t, q, 3)
+	checkNext(t, q, "a")
+	if err := q.Commit(); err != nil {
+		t.Error(err)
+	}
+	checkNext(t, q, float64(2))
+
+	if err := q.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := q.Put(1); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed Put() test for closed queue")
+	}
+	if _, err := q.Next(); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed Next() test for closed queue")
+	}
+	if err := q.Close(); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed Close() test for closed queue")
+	}
+
+	// After reopening, everything after the commit should be delivered again.
+	q = openDurable(t, dir, nil)
+	defer q.Close()
+	checkDurableCount(t, q, 2)
+	checkNext(t, q, float64(2))
+	if v, err := q.Next(); err != nil || fmt.Sprint(v) != "map[k:v]" {
+		t.Error("Incorrect item read")
+		t.Log("\tExpected: map[k:v]")
+		t.Log("\tReceived:", v, err)
+	}
+	if _, err := q.Next(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly read past the end of the queue")
+	}
+}
+
+func TestDurableSegments(t *testing.T) {
+	dir := t.TempDir()
+	opts := &hqueue.DurableOptions{SegmentSize: 32, Sync: hqueue.SyncAlways}
+	q := openDurable(t, dir, opts)
+
+	// Each item takes up 9 or 10 bytes, so this makes several segments.
+	for i := 0; i < 20; i++ {
+		q.Put(i)
+	}
+	segments := countSegments(t, dir)
+	if segments < 5 {
+		t.Error("Too few segments:", segments)
+	}
+
+	// Reading doesn't delete anything until it is committed.
+	for i := 0; i < 10; i++ {
+		checkNext(t, q, float64(i))
+	}
+	if n := countSegments(t, dir); n != segments {
+		t.Error("Segments were deleted before being committed")
+	}
+
+	if err := q.Commit(); err != nil {
+		t.Error(err)
+	}
+	if n := countSegments(t, dir); n >= segments {
+		t.Error("Committed segments were not deleted")
+		t.Log("\tBefore:", segments)
+		t.Log("\tAfter:", n)
+	}
+
+	// Everything that is left is still there after reopening, and new items go after it.
+	q.Close()
+	q = openDurable(t, dir, opts)
+	q.Put(20)
+	for i := 10; i <= 20; i++ {
+		checkNext(t, q, float64(i))
+	}
+	q.Commit()
+	if n := countSegments(t, dir); n != 1 {
+		t.Error("Incorrect number of segments after committing everything")
+		t.Log("\tExpected: 1")
+		t.Log("\tReceived:", n)
+	}
+	q.Close()
+
+	// A queue that was fully read and compacted keeps counting from where it was.
+	q = openDurable(t, dir, opts)
+	defer q.Close()
+	checkDurableCount(t, q, 0)
+	q.Put("new")
+	checkNext(t, q, "new")
+}
+
+func TestDurableCorrupt(t *testing.T) {
+	// Damage anywhere other than the end of the log can't be fixed.
+	dir := t.TempDir()
+	opts := &hqueue.DurableOptions{SegmentSize: 16}
+	q := openDurable(t, dir, opts)
+	for i := 0; i < 5; i++ {
+		q.Put(i)
+	}
+	q.Close()
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(matches) < 3 {
+		t.Fatal("Too few segments:", len(matches))
+	}
+	data, _ := ioutil.ReadFile(matches[0])
+	data[len(data)-1] ^= 0xff
+	ioutil.WriteFile(matches[0], data, 0644)
+
+	q = openDurable(t, dir, opts)
+	defer q.Close()
+	checkNext(t, q, 0)
+	if _, err := q.Next(); !errors.Is(err, hqueue.ErrCorrupt) {
+		t.Error("Damaged record was not detected")
+		t.Log("\tExpected:", hqueue.ErrCorrupt)
+		t.Log("\tReceived:", err)
+	}
+
+	// The reader should stay where it was.
+	checkDurableCount(t, q, 4)
+	if _, err := q.Next(); !errors.Is(err, hqueue.ErrCorrupt) {
+		t.Error("Damaged record was not detected again")
+	}
+}
+
+func TestDurableRecovery(t *testing.T) {
+	dir := t.TempDir()
+	q := openDurable(t, dir, nil)
+	q.Put([]interface{}{"a", "b"})
+	q.Put("b")
+	q.Close()
+
+	// Simulate a crash in the middle of writing a record by adding part of one to the end of the log.
+	last := lastSegment(t, dir)
+	f, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 10, 1, 2, 3, 4, '"', 'x'})
+	f.Close()
+
+	// The partial record should be dropped, and new items should go after the good ones.
+	q = openDurable(t, dir, nil)
+	checkDurableCount(t, q, 2)
+	q.Put("c")
+	checkNext(t, q, []interface{}{"a", "b"})
+	checkNext(t, q, "b")
+	checkNext(t, q, "c")
+	q.Close()
+
+	// A damaged offset file is detected.
+	ioutil.WriteFile(filepath.Join(dir, "offset"), []byte("garbage"), 0644)
+	if _, err := hqueue.OpenDurable(dir, nil); !errors.Is(err, hqueue.ErrCorrupt) {
+		t.Error("Damaged offset file was not detected")
+		t.Log("\tExpected:", hqueue.ErrCorrupt)
+		t.Log("\tReceived:", err)
+	}
+}
+
+// intCodec stores ints as decimal strings.
+type intCodec struct{}
+
+func (intCodec) Encode(item interface{}) ([]byte, error) {
+	n, ok := item.(int)
+	if !ok {
+		return nil, fmt.Errorf("not an int: %v", item)
+	}
+	return []byte(strconv.Itoa(n)), nil
+}
+
+func (intCodec) Decode(data []byte) (interface{}, error) {
+	return strconv.Atoi(string(data))
+}
+
+func TestDurableCodec(t *testing.T) {
+	dir := t.TempDir()
+	opts := &hqueue.DurableOptions{Codec: intCodec{}, Sync: hqueue.SyncInterval, Interval: time.Millisecond}
+	q := openDurable(t, dir, opts)
+	defer q.Close()
+
+	if err := q.Put("not an int"); err == nil {
+		t.Error("unexpectedly passed Put() test with item that can't be encoded")
+	}
+	q.Put(1)
+	q.Put(-20)
+	if err := q.Sync(); err != nil {
+		t.Error(err)
+	}
+
+	checkNext(t, q, 1)
+	checkNext(t, q, -20)
+	if s := q.String(); s != dir+" (0 unread)" {
+		t.Error("Incorrect string")
+		t.Log("\tExpected:", dir+" (0 unread)")
+		t.Log("\tReceived:", s)
+	}
+}
+
+func BenchmarkDurablePut(b *testing.B) {
+	q, err := hqueue.OpenDurable(b.TempDir(), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer q.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Put(i)
+	}
+}
+
+func openDurable(t *testing.T, dir string, opts *hqueue.DurableOptions) *hqueue.DurableQueue {
+	q, err := hqueue.OpenDurable(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return q
+}
+
+func checkNext(t *testing.T, q *hqueue.DurableQueue, want interface{}) {
+	v, err := q.Next()
+	if err != nil || fmt.Sprint(v) != fmt.Sprint(want) {
+		t.Error("Incorrect item read")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", v, err)
+	}
+}
+
+func checkDurableCount(t *testing.T, q *hqueue.DurableQueue, count int) {
+	if n := q.Count(); n != count {
+		t.Error("Incorrect count")
+		t.Log("\tExpected:", count)
+		t.Log("\tReceived:", n)
+	}
+}
+
+func countSegments(t *testing.T, dir string) int {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(matches)
+}
+
+func lastSegment(t *testing.T, dir string) string {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil || len(matches) == 0 {
+		t.Fatal("No segments found:", err)
+	}
+
+	return matches[len(matches)-1]
+}
@@ -1,24 +1,34 @@
-// Package hqueue provides a simple and lean first-in/first-out queue.
+// Package hqueue provides a simple and lean first-in/first-out queue, along with a few specialized
+// queues built for concurrent use, priorities, and access at both ends.
 package hqueue
 
 import (
 	"fmt"
-
-	"github.com/snhilde/dsa/data_structures/hlist"
+	"strings"
+	"time"
 )
 
 // This is the standard error message when trying to use an invalid queue.
 var errBadQueue = fmt.Errorf("must create queue with New() first")
 
+// minCapacity is the smallest size that the queue's buffer will have once items are added.
+const minCapacity = 16
+
 // Queue is the main type for this package. It holds the internal information about the queue.
 type Queue struct {
-	list *hlist.List
+	// The items are stored in a ring buffer. The first item in the queue is at index head, and the
+	// rest follow it, wrapping around to the beginning of the buffer when they reach the end.
+	items []interface{}
+	head  int
+	count int
+
+	// stats is only set once Instrument has been called.
+	stats *queueStats
 }
 
 // New creates a new queue.
 func New() *Queue {
 	q := new(Queue)
-	q.list = hlist.New()
 	return q
 }
 
@@ -38,16 +48,46 @@ func (q *Queue) Add(items ...interface{}) error {
 		}
 	}
 
-	return q.list.Append(items...)
+	if q.count+len(items) > len(q.items) {
+		q.resize(q.count + len(items))
+	}
+
+	now := q.now()
+	for _, item := range items {
+		i := q.index(q.count)
+		q.items[i] = item
+		q.count++
+		if q.stats != nil {
+			q.stats.enqueue(i, now, q.count)
+		}
+	}
+
+	return nil
 }
 
 // Pop removes the first item in the queue and returns its value.
 func (q *Queue) Pop() interface{} {
-	if q == nil {
+	if q == nil || q.count == 0 {
 		return nil
 	}
 
-	return q.list.Remove(0)
+	item := q.items[q.head]
+
+	// Drop the reference so the item can be garbage collected.
+	i := q.head
+	q.items[i] = nil
+	q.head = q.index(1)
+	q.count--
+	if q.stats != nil {
+		q.stats.dequeue(i, q.now(), q.count)
+	}
+
+	// If the queue has shrunk a lot, then give back some of the memory.
+	if len(q.items) > minCapacity && q.count <= len(q.items)/4 {
+		q.resize(len(q.items) / 2)
+	}
+
+	return item
 }
 
 // Count gets the current number of items in the queue.
@@ -56,28 +96,28 @@ func (q *Queue) Count() int {
 		return -1
 	}
 
-	return q.list.Length()
+	return q.count
 }
 
-// Copy makes an exact copy of the queue.
+// Copy makes an exact copy of the queue. The copy does not collect statistics, even if the queue does.
 func (q *Queue) Copy() (*Queue, error) {
 	if q == nil {
 		return nil, errBadQueue
 	}
 
-	nl, err := q.list.Copy()
-	if err != nil {
-		return nil, err
-	}
-
 	nq := New()
-	nq.list = nl
+	if q.count > 0 {
+		nq.items = make([]interface{}, q.count)
+		q.copyTo(nq.items)
+		nq.count = q.count
+	}
 
 	return nq, nil
 }
 
 // Merge adds a queue behind the current queue, preserving order. This will take ownership of and
-// clear the provided queue.
+// clear the provided queue. If either queue is instrumented, then the items count as removed from the
+// provided queue and added to this one.
 func (q *Queue) Merge(nq *Queue) error {
 	if q == nil {
 		return errBadQueue
@@ -86,20 +126,57 @@ func (q *Queue) Merge(nq *Queue) error {
 		return nil
 	}
 
-	if err := q.list.Merge(nq.list); err != nil {
-		return err
+	// If we have the same queue, then we need to duplicate it first, or else the queue will get
+	// cleared at the end.
+	if q.Same(nq) {
+		dup, err := q.Copy()
+		if err != nil {
+			return err
+		}
+		nq = dup
+	}
+
+	if q.count+nq.count > len(q.items) {
+		q.resize(q.count + nq.count)
+	}
+	now := q.now()
+	for i := 0; i < nq.count; i++ {
+		j := q.index(q.count)
+		q.items[j] = nq.items[nq.index(i)]
+		q.count++
+		if q.stats != nil {
+			q.stats.enqueue(j, now, q.count)
+		}
+	}
+
+	// The items were moved, not thrown away, so they are counted as removed before the queue is reset.
+	if s := nq.stats; s != nil {
+		now = nq.now()
+		for i := 0; i < nq.count; i++ {
+			s.dequeue(nq.index(i), now, nq.count-i-1)
+		}
+		nq.count = 0
 	}
 
 	return nq.Clear()
 }
 
-// Clear resets the queue to its initial state.
+// Clear resets the queue to its initial state. If the queue is instrumented, then it keeps collecting
+// statistics, and the items that were in it count as dropped.
 func (q *Queue) Clear() error {
 	if q == nil {
 		return fmt.Errorf("queue does not exist")
 	}
 
-	return q.list.Clear()
+	n, s := q.count, q.stats
+	*q = Queue{}
+	if s != nil {
+		s.times = nil
+		q.stats = s
+		s.drop(n)
+	}
+
+	return nil
 }
 
 // Same checks whether or not the two queues point to the same underlying data.
@@ -108,14 +185,67 @@ func (q *Queue) Same(queue2 *Queue) bool {
 		return false
 	}
 
-	return q.list.Same(queue2.list)
+	return q == queue2
 }
 
 // String displays the queue's contents, from the top to the bottom.
 func (q *Queue) String() string {
 	if q == nil {
 		return "<nil>"
+	} else if q.count == 0 {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for i := 0; i < q.count; i++ {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", q.items[q.index(i)]))
+	}
+
+	return builder.String()
+}
+
+// index gets the position in the buffer of the item that is i items from the front of the queue.
+func (q *Queue) index(i int) int {
+	i += q.head
+	if i >= len(q.items) {
+		i -= len(q.items)
 	}
 
-	return q.list.String()
+	return i
+}
+
+// resize moves the items into a new buffer that can hold at least n items. The buffer's size is
+// always a power of two so that it can keep doubling as it grows.
+func (q *Queue) resize(n int) {
+	size := minCapacity
+	for size < n {
+		size *= 2
+	}
+
+	items := make([]interface{}, size)
+	q.copyTo(items)
+	if q.stats != nil {
+		times := make([]time.Time, size)
+		q.copyTimesTo(times)
+		q.stats.times = times
+	}
+	q.items = items
+	q.head = 0
+}
+
+// copyTo copies the items in order into the buffer, which must be large enough to hold them all.
+func (q *Queue) copyTo(buf []interface{}) {
+	if q.count == 0 {
+		return
+	}
+
+	if end := q.head + q.count; end <= len(q.items) {
+		copy(buf, q.items[q.head:end])
+	} else {
+		n := copy(buf, q.items[q.head:])
+		copy(buf[n:], q.items[:end-len(q.items)])
+	}
 }
@@ -1,6 +1,7 @@
 package hqueue_test
 
 import (
+	"math/rand"
 	"reflect"
 	"testing"
 
@@ -419,6 +420,98 @@ func TestSame(t *testing.T) {
 	}
 }
 
+func TestWrapAround(t *testing.T) {
+	// Compare many random additions and removals against a slice. This keeps the queue's buffer
+	// wrapping around, growing, and shrinking.
+	q := hqueue.New()
+	var want []interface{}
+
+	for i := 0; i < 20000; i++ {
+		if rand.Intn(2) == 0 && len(want) > 0 {
+			n := rand.Intn(len(want)) + 1
+			if i%1000 < 500 {
+				// Favor adding items for a while so that the queue grows.
+				n = 1
+			}
+			for j := 0; j < n; j++ {
+				if v := q.Pop(); v != want[0] {
+					t.Fatal("Popped incorrect item")
+				}
+				want = want[1:]
+			}
+		} else {
+			n := rand.Intn(5) + 1
+			for j := 0; j < n; j++ {
+				want = append(want, i*10+j)
+			}
+			if err := q.Add(want[len(want)-n:]...); err != nil {
+				t.Fatal(err)
+			}
+		}
+		checkCount(t, q, len(want))
+
+		if i%997 == 0 {
+			// Copies and merges should keep the order.
+			cp, err := q.Copy()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkString(t, cp, q.String())
+			other := hqueue.New()
+			other.Add("x", "y")
+			other.Merge(cp)
+			if other.Count() != len(want)+2 || other.Pop() != "x" || other.Pop() != "y" {
+				t.Fatal("Merge did not keep the order")
+			}
+			for _, v := range want {
+				if w := other.Pop(); w != v {
+					t.Fatal("Merge did not keep the order")
+				}
+			}
+		}
+	}
+
+	for _, v := range want {
+		if w := q.Pop(); w != v {
+			t.Fatal("Popped incorrect item")
+		}
+	}
+	checkCount(t, q, 0)
+	checkString(t, q, "<empty>")
+}
+
+func BenchmarkAdd1000(b *testing.B) {
+	benchmarkAdd(b, 1000)
+}
+
+func BenchmarkAdd100000(b *testing.B) {
+	benchmarkAdd(b, 100000)
+}
+
+func BenchmarkAddPop(b *testing.B) {
+	b.ReportAllocs()
+	q := hqueue.New()
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Add(i)
+		q.Pop()
+	}
+}
+
+// benchmarkAdd fills a queue with n items one at a time.
+func benchmarkAdd(b *testing.B, n int) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q := hqueue.New()
+		for j := 0; j < n; j++ {
+			q.Add(j)
+		}
+	}
+}
+
 func checkString(t *testing.T, q *hqueue.Queue, want string) {
 	if q.String() != want {
 		t.Error("queue contents are incorrect")
@@ -0,0 +1,434 @@
+package hqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLeaseExpired is returned when acting on a lease that is no longer held, because it was already
+	// acknowledged or because its visibility timeout passed.
+	ErrLeaseExpired = fmt.Errorf("lease is no longer held")
+
+	// This is the standard error message when trying to use an invalid lease queue.
+	errBadLeaseQueue = fmt.Errorf("must create queue with NewLease() first")
+)
+
+// defaultVisibility is how long a lease lasts if no visibility timeout is given.
+const defaultVisibility = 30 * time.Second
+
+// LeaseOptions holds the settings for a lease queue. The zero value of each field picks its default.
+type LeaseOptions struct {
+	// Visibility is how long a consumer has to acknowledge an item before it is given to someone else.
+	// The default is 30 seconds.
+	Visibility time.Duration
+	// MaxAttempts is how many times an item can be handed out before it is moved to the dead-letter
+	// queue instead. The default of 0 means that items are retried forever.
+	MaxAttempts int
+	// Clock is used to tell when leases run out. The default is the system clock.
+	Clock Clock
+}
+
+// LeaseQueue is a first-in/first-out queue for sharing work among many consumers, where every item must
+// be acknowledged once it has been dealt with. It is safe for use by many goroutines at once.
+//
+// Taking an item gives the consumer a lease on it. The item is hidden from other consumers until the
+// lease runs out after the visibility timeout. If the consumer calls Ack before then, then the item is
+// done and removed for good. If the consumer calls Nack, or if the lease runs out first, then the item
+// goes back to the end of the queue to be handed out again. Once an item has been handed out the maximum
+// number of times, it is moved to the dead-letter queue instead.
+type LeaseQueue struct {
+	mu          sync.Mutex
+	clock       Clock
+	visibility  time.Duration
+	maxAttempts int
+	closed      bool
+
+	// ready holds the items waiting to be taken. leased holds the items that have been taken, ordered
+	// by when their leases run out.
+	ready  *Queue
+	leased *PriorityQueue
+	dead   *BlockingQueue
+
+	// changed is closed whenever items are added or leases end, to wake up everyone waiting for an
+	// item.
+	changed chan struct{}
+}
+
+// Lease is a consumer's hold on an item from a lease queue.
+type Lease struct {
+	queue    *LeaseQueue
+	msg      *message
+	attempt  int
+	deadline time.Time
+}
+
+// message is an internal type for an item in a lease queue, along with its delivery history.
+type message struct {
+	item     interface{}
+	attempts int
+
+	// lease is the current lease on the item, and handle is its place in the queue's leased items. Both
+	// are nil while the item isn't leased.
+	lease  *Lease
+	handle *Handle
+}
+
+// NewLease creates a new lease queue. If opts is nil, then the defaults are used.
+func NewLease(opts *LeaseOptions) (*LeaseQueue, error) {
+	var o LeaseOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Visibility < 0 {
+		return nil, fmt.Errorf("visibility timeout cannot be negative")
+	} else if o.Visibility == 0 {
+		o.Visibility = defaultVisibility
+	}
+	if o.MaxAttempts < 0 {
+		return nil, fmt.Errorf("max attempts cannot be negative")
+	}
+	if o.Clock == nil {
+		o.Clock = SystemClock()
+	}
+
+	dead, err := NewBlocking(0)
+	if err != nil {
+		return nil, err
+	}
+
+	q := new(LeaseQueue)
+	q.clock = o.Clock
+	q.visibility = o.Visibility
+	q.maxAttempts = o.MaxAttempts
+	q.ready = New()
+	q.leased = NewPriority()
+	q.dead = dead
+
+	return q, nil
+}
+
+// Put adds an item to the back of the queue.
+func (q *LeaseQueue) Put(item interface{}) error {
+	if q == nil || q.ready == nil {
+		return errBadLeaseQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	if err := q.ready.Add(&message{item: item}); err != nil {
+		return err
+	}
+	broadcast(&q.changed)
+
+	return nil
+}
+
+// Take leases the first item in the queue. If no items are ready, then this waits until one is added,
+// one is returned by a consumer, or a lease runs out. This stops waiting if the queue is closed and has
+// no items ready or leased, or if ctx is done. If ctx is done first, then ctx's error is returned.
+func (q *LeaseQueue) Take(ctx context.Context) (*Lease, error) {
+	if q == nil || q.ready == nil {
+		return nil, errBadLeaseQueue
+	}
+
+	for {
+		q.mu.Lock()
+		l, wait, err := q.take()
+		if !errors.Is(err, ErrEmpty) {
+			q.mu.Unlock()
+			return l, err
+		}
+		changed := waitOn(&q.changed)
+		q.mu.Unlock()
+
+		// If items are leased, then also wait for the first lease to run out.
+		var expired <-chan time.Time
+		var timer Timer
+		if wait > 0 {
+			timer = q.clock.NewTimer(wait)
+			expired = timer.C()
+		}
+
+		select {
+		case <-changed:
+		case <-expired:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// TryTake leases the first item in the queue without waiting. This returns ErrEmpty if no items are
+// ready, or ErrClosed if the queue is closed and has no items ready or leased.
+func (q *LeaseQueue) TryTake() (*Lease, error) {
+	if q == nil || q.ready == nil {
+		return nil, errBadLeaseQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	l, _, err := q.take()
+
+	return l, err
+}
+
+// Ack marks the leased item as done and removes it from the queue for good. This returns
+// ErrLeaseExpired if the lease is no longer held.
+func (q *LeaseQueue) Ack(l *Lease) error {
+	if q == nil || q.ready == nil {
+		return errBadLeaseQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.check(l); err != nil {
+		return err
+	}
+	q.unlease(l.msg)
+
+	return nil
+}
+
+// Nack gives up the lease on the item and returns it to the back of the queue right away, or moves it to
+// the dead-letter queue if it has been handed out the maximum number of times. This returns
+// ErrLeaseExpired if the lease is no longer held.
+func (q *LeaseQueue) Nack(l *Lease) error {
+	if q == nil || q.ready == nil {
+		return errBadLeaseQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.check(l); err != nil {
+		return err
+	}
+	q.unlease(l.msg)
+	q.release(l.msg)
+
+	return nil
+}
+
+// Extend pushes back the end of the lease so that it runs out d from now. Consumers can use this to hold
+// on to items that take longer than the visibility timeout to deal with. This returns ErrLeaseExpired if
+// the lease is no longer held.
+func (q *LeaseQueue) Extend(l *Lease, d time.Duration) error {
+	if q == nil || q.ready == nil {
+		return errBadLeaseQueue
+	} else if d <= 0 {
+		return fmt.Errorf("lease must be extended into the future")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.check(l); err != nil {
+		return err
+	}
+	l.deadline = q.clock.Now().Add(d)
+	q.leased.updateAt(l.msg.handle, l.deadline)
+
+	return nil
+}
+
+// Close stops the queue from accepting any more items. Items that are ready or leased can still be
+// taken, including leased items that are returned. Everyone waiting to take an item is woken up and
+// receives ErrClosed once there are no items ready or leased.
+func (q *LeaseQueue) Close() error {
+	if q == nil || q.ready == nil {
+		return errBadLeaseQueue
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	q.closed = true
+	broadcast(&q.changed)
+
+	return nil
+}
+
+// DeadLetter gets the queue of items that were handed out the maximum number of times without being
+// acknowledged.
+func (q *LeaseQueue) DeadLetter() *BlockingQueue {
+	if q == nil || q.ready == nil {
+		return nil
+	}
+
+	return q.dead
+}
+
+// Count gets the number of items that are ready to be taken.
+func (q *LeaseQueue) Count() int {
+	if q == nil || q.ready == nil {
+		return -1
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire()
+
+	return q.ready.Count()
+}
+
+// InFlight gets the number of items that are currently leased.
+func (q *LeaseQueue) InFlight() int {
+	if q == nil || q.ready == nil {
+		return -1
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire()
+
+	return q.leased.Count()
+}
+
+// String displays the items that are ready to be taken, from the front to the back.
+func (q *LeaseQueue) String() string {
+	if q == nil || q.ready == nil {
+		return "<nil>"
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire()
+	if q.ready.Count() == 0 {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for i := 0; i < q.ready.Count(); i++ {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", q.ready.items[q.ready.index(i)].(*message).item))
+	}
+
+	return builder.String()
+}
+
+// Item gets the leased item.
+func (l *Lease) Item() interface{} {
+	if l == nil {
+		return nil
+	}
+
+	return l.msg.item
+}
+
+// Attempt gets how many times the item has been handed out, including this time.
+func (l *Lease) Attempt() int {
+	if l == nil {
+		return 0
+	}
+
+	return l.attempt
+}
+
+// Deadline gets the time when the lease runs out, unless it is extended.
+func (l *Lease) Deadline() time.Time {
+	if l == nil {
+		return time.Time{}
+	}
+
+	return l.deadline
+}
+
+// take leases the first ready item. If there isn't one, then this returns ErrEmpty and how long it will
+// be until the first lease runs out, or 0 if nothing is leased. The caller must hold the lock.
+func (q *LeaseQueue) take() (*Lease, time.Duration, error) {
+	q.expire()
+
+	if q.ready.Count() == 0 {
+		if q.leased.Count() == 0 {
+			if q.closed {
+				return nil, 0, ErrClosed
+			}
+			return nil, 0, ErrEmpty
+		}
+		v, _, _ := q.leased.Peek()
+		return nil, v.(*message).lease.deadline.Sub(q.clock.Now()), ErrEmpty
+	}
+
+	msg := q.ready.Pop().(*message)
+	msg.attempts++
+
+	l := &Lease{queue: q, msg: msg, attempt: msg.attempts, deadline: q.clock.Now().Add(q.visibility)}
+	msg.lease = l
+	msg.handle = q.leased.pushAt(msg, l.deadline)
+
+	return l, 0, nil
+}
+
+// check makes sure that the lease is from this queue and is still held. The caller must hold the lock.
+func (q *LeaseQueue) check(l *Lease) error {
+	if l == nil || l.queue != q {
+		return ErrBadHandle
+	}
+
+	q.expire()
+	if l.msg.lease != l {
+		return ErrLeaseExpired
+	}
+
+	return nil
+}
+
+// expire returns every item whose lease has run out. The caller must hold the lock.
+func (q *LeaseQueue) expire() {
+	now := q.clock.Now()
+	for {
+		v, _, err := q.leased.Peek()
+		if err != nil || v.(*message).lease.deadline.After(now) {
+			return
+		}
+
+		msg := v.(*message)
+		q.unlease(msg)
+		q.release(msg)
+	}
+}
+
+// unlease ends the current lease on the item. The caller must hold the lock.
+func (q *LeaseQueue) unlease(msg *message) {
+	q.leased.Remove(msg.handle)
+	msg.lease = nil
+	msg.handle = nil
+
+	// If the queue is closed, then waiting consumers might be able to stop now.
+	broadcast(&q.changed)
+}
+
+// release puts an item that is no longer leased back into the queue, or into the dead-letter queue if
+// it has used up its attempts. The caller must hold the lock.
+func (q *LeaseQueue) release(msg *message) {
+	if q.maxAttempts > 0 && msg.attempts >= q.maxAttempts {
+		q.dead.TryPut(msg.item)
+		return
+	}
+
+	q.ready.Add(msg)
+}
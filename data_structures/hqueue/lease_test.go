@@ -0,0 +1,395 @@
+package hqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestLeaseBadPtr(t *testing.T) {
+	var q *hqueue.LeaseQueue
+
+	if err := q.Put(1); err == nil {
+		t.Error("unexpectedly passed Put() test with bad pointer")
+	}
+	if _, err := q.Take(context.Background()); err == nil {
+		t.Error("unexpectedly passed Take() test with bad pointer")
+	}
+	if _, err := q.TryTake(); err == nil {
+		t.Error("unexpectedly passed TryTake() test with bad pointer")
+	}
+	if err := q.Ack(nil); err == nil {
+		t.Error("unexpectedly passed Ack() test with bad pointer")
+	}
+	if err := q.Nack(nil); err == nil {
+		t.Error("unexpectedly passed Nack() test with bad pointer")
+	}
+	if err := q.Extend(nil, time.Second); err == nil {
+		t.Error("unexpectedly passed Extend() test with bad pointer")
+	}
+	if err := q.Close(); err == nil {
+		t.Error("unexpectedly passed Close() test with bad pointer")
+	}
+	if q.DeadLetter() != nil {
+		t.Error("unexpectedly passed DeadLetter() test with bad pointer")
+	}
+	if n := q.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if n := q.InFlight(); n != -1 {
+		t.Error("unexpectedly passed InFlight() test with bad pointer")
+	}
+	if s := q.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+	}
+
+	// A queue that wasn't created with NewLease can't be used either.
+	var zero hqueue.LeaseQueue
+	if err := zero.Put(1); err == nil {
+		t.Error("unexpectedly passed Put() test with zero-value queue")
+	}
+	if _, err := zero.TryTake(); err == nil {
+		t.Error("unexpectedly passed TryTake() test with zero-value queue")
+	}
+	if _, err := zero.Take(context.Background()); err == nil {
+		t.Error("unexpectedly passed Take() test with zero-value queue")
+	}
+	if n := zero.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with zero-value queue")
+	}
+	if s := zero.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with zero-value queue")
+	}
+
+	var l *hqueue.Lease
+	if l.Item() != nil || l.Attempt() != 0 || !l.Deadline().IsZero() {
+		t.Error("unexpectedly passed Lease test with bad pointer")
+	}
+}
+
+func TestLeaseBadArgs(t *testing.T) {
+	if _, err := hqueue.NewLease(&hqueue.LeaseOptions{Visibility: -1}); err == nil {
+		t.Error("unexpectedly passed NewLease() test with negative visibility")
+	}
+	if _, err := hqueue.NewLease(&hqueue.LeaseOptions{MaxAttempts: -1}); err == nil {
+		t.Error("unexpectedly passed NewLease() test with negative max attempts")
+	}
+
+	q, err := hqueue.NewLease(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Put(1)
+	l, _ := q.TryTake()
+	if err := q.Extend(l, 0); err == nil {
+		t.Error("unexpectedly passed Extend() test with no duration")
+	}
+	if err := q.Ack(nil); !errors.Is(err, hqueue.ErrBadHandle) {
+		t.Error("unexpectedly passed Ack() test with nil lease")
+	}
+
+	other, _ := hqueue.NewLease(nil)
+	if err := other.Ack(l); !errors.Is(err, hqueue.ErrBadHandle) {
+		t.Error("unexpectedly passed Ack() test with lease from another queue")
+	}
+}
+
+func TestLeaseAck(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newLease(t, clock, 0)
+
+	q.Put("a")
+	q.Put("b")
+	checkLease(t, q, "a, b", 2, 0)
+
+	l, err := q.TryTake()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Item() != "a" || l.Attempt() != 1 || !l.Deadline().Equal(epoch.Add(time.Minute)) {
+		t.Error("Incorrect lease")
+		t.Log("\tExpected: a 1", epoch.Add(time.Minute))
+		t.Log("\tReceived:", l.Item(), l.Attempt(), l.Deadline())
+	}
+	checkLease(t, q, "b", 1, 1)
+
+	if err := q.Ack(l); err != nil {
+		t.Error(err)
+	}
+	checkLease(t, q, "b", 1, 0)
+
+	// The item is gone for good.
+	clock.Advance(time.Hour)
+	checkLease(t, q, "b", 1, 0)
+	if err := q.Ack(l); !errors.Is(err, hqueue.ErrLeaseExpired) {
+		t.Error("unexpectedly passed Ack() test with acknowledged lease")
+		t.Log("\tExpected:", hqueue.ErrLeaseExpired)
+		t.Log("\tReceived:", err)
+	}
+	if err := q.Nack(l); !errors.Is(err, hqueue.ErrLeaseExpired) {
+		t.Error("unexpectedly passed Nack() test with acknowledged lease")
+	}
+}
+
+func TestLeaseRedeliver(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newLease(t, clock, 0)
+
+	q.Put("a")
+	q.Put("b")
+
+	// An item whose lease runs out goes to the back of the queue.
+	first, _ := q.TryTake()
+	clock.Advance(59 * time.Second)
+	checkLease(t, q, "b", 1, 1)
+	clock.Advance(time.Second)
+	checkLease(t, q, "b, a", 2, 0)
+
+	// The old lease can't be used anymore.
+	if err := q.Ack(first); !errors.Is(err, hqueue.ErrLeaseExpired) {
+		t.Error("unexpectedly passed Ack() test with expired lease")
+		t.Log("\tExpected:", hqueue.ErrLeaseExpired)
+		t.Log("\tReceived:", err)
+	}
+	if err := q.Extend(first, time.Minute); !errors.Is(err, hqueue.ErrLeaseExpired) {
+		t.Error("unexpectedly passed Extend() test with expired lease")
+	}
+
+	// A nacked item goes back right away.
+	b, _ := q.TryTake()
+	if err := q.Nack(b); err != nil {
+		t.Error(err)
+	}
+	checkLease(t, q, "a, b", 2, 0)
+
+	a, _ := q.TryTake()
+	if a.Item() != "a" || a.Attempt() != 2 {
+		t.Error("Incorrect redelivery")
+		t.Log("\tExpected: a 2")
+		t.Log("\tReceived:", a.Item(), a.Attempt())
+	}
+
+	// Extending the lease keeps it held.
+	clock.Advance(30 * time.Second)
+	if err := q.Extend(a, 2*time.Minute); err != nil {
+		t.Error(err)
+	}
+	clock.Advance(time.Minute)
+	if err := q.Ack(a); err != nil {
+		t.Error("Extended lease ran out early:", err)
+	}
+}
+
+func TestLeaseDeadLetter(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newLease(t, clock, 3)
+
+	q.Put("poison")
+	q.Put("ok")
+	for attempt := 1; attempt <= 3; attempt++ {
+		l, _ := q.TryTake()
+		if l.Item() != "poison" || l.Attempt() != attempt {
+			t.Fatal("Incorrect item taken:", l.Item(), l.Attempt())
+		}
+		q.Nack(l)
+
+		l, _ = q.TryTake()
+		q.Nack(l)
+	}
+
+	// The first item has used up its attempts, and so has the second one, which came after it.
+	checkLease(t, q, "<empty>", 0, 0)
+	dead := q.DeadLetter()
+	if s := dead.String(); s != "poison, ok" {
+		t.Error("Incorrect dead-letter queue")
+		t.Log("\tExpected: poison, ok")
+		t.Log("\tReceived:", s)
+	}
+
+	// Leases running out count as attempts too.
+	q.Put("slow")
+	for i := 0; i < 3; i++ {
+		q.TryTake()
+		clock.Advance(time.Minute)
+	}
+	checkLease(t, q, "<empty>", 0, 0)
+	if n := dead.Count(); n != 3 {
+		t.Error("Incorrect number of dead items")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", n)
+	}
+}
+
+func TestLeaseTake(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newLease(t, clock, 0)
+	ctx := context.Background()
+
+	got := make(chan *hqueue.Lease)
+	take := func() {
+		l, err := q.Take(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- l
+	}
+
+	// Take waits for an item to be put in.
+	go take()
+	q.Put("a")
+	first := <-got
+
+	// Take waits for a lease to run out.
+	go take()
+	waitForTimers(clock, 1)
+	clock.Advance(time.Minute)
+	if l := <-got; l.Item() != "a" || l.Attempt() != 2 {
+		t.Error("Incorrect redelivery")
+		t.Log("\tExpected: a 2")
+		t.Log("\tReceived:", l.Item(), l.Attempt())
+	} else {
+		q.Ack(l)
+	}
+	if err := q.Ack(first); !errors.Is(err, hqueue.ErrLeaseExpired) {
+		t.Error("unexpectedly passed Ack() test with expired lease")
+	}
+
+	// Waiting stops when the context is done.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := q.Take(cctx); !errors.Is(err, context.Canceled) {
+		t.Error("Take() did not stop when context was cancelled")
+		t.Log("\tExpected:", context.Canceled)
+		t.Log("\tReceived:", err)
+	}
+}
+
+func TestLeaseClose(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newLease(t, clock, 0)
+	ctx := context.Background()
+
+	q.Put("a")
+	l, _ := q.TryTake()
+	if err := q.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := q.Close(); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed Close() test for closed queue")
+	}
+	if err := q.Put("b"); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("unexpectedly passed Put() test for closed queue")
+	}
+
+	// A leased item might still come back, so Take keeps waiting.
+	done := make(chan error)
+	go func() {
+		l, err := q.Take(ctx)
+		if err == nil {
+			q.Ack(l)
+			_, err = q.Take(ctx)
+		}
+		done <- err
+	}()
+	q.Nack(l)
+	if err := <-done; !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("Take() did not receive ErrClosed after draining")
+		t.Log("\tExpected:", hqueue.ErrClosed)
+		t.Log("\tReceived:", err)
+	}
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrClosed) {
+		t.Error("TryTake() did not receive ErrClosed after draining")
+	}
+}
+
+func TestLeaseWorkers(t *testing.T) {
+	// Several workers share the items. Every item should be acknowledged exactly once, even though
+	// workers nack some items the first time that they see them.
+	q, err := hqueue.NewLease(&hqueue.LeaseOptions{Visibility: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const items = 2000
+	for i := 0; i < items; i++ {
+		q.Put(i)
+	}
+	q.Close()
+
+	var mu sync.Mutex
+	acked := make(map[interface{}]int)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				l, err := q.Take(context.Background())
+				if errors.Is(err, hqueue.ErrClosed) {
+					return
+				} else if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if l.Attempt() == 1 && l.Item().(int)%5 == 0 {
+					q.Nack(l)
+					continue
+				}
+				if err := q.Ack(l); err != nil {
+					t.Error(err)
+				}
+				mu.Lock()
+				acked[l.Item()]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(acked) != items {
+		t.Error("Incorrect number of items acknowledged")
+		t.Log("\tExpected:", items)
+		t.Log("\tReceived:", len(acked))
+	}
+	for item, n := range acked {
+		if n != 1 {
+			t.Error("Item acknowledged", n, "times:", item)
+		}
+	}
+}
+
+func newLease(t *testing.T, clock hqueue.Clock, maxAttempts int) *hqueue.LeaseQueue {
+	q, err := hqueue.NewLease(&hqueue.LeaseOptions{
+		Visibility:  time.Minute,
+		MaxAttempts: maxAttempts,
+		Clock:       clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return q
+}
+
+func checkLease(t *testing.T, q *hqueue.LeaseQueue, want string, count int, inFlight int) {
+	if q.String() != want {
+		t.Error("Queue contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", q)
+	}
+	if n := q.Count(); n != count {
+		t.Error("Incorrect count")
+		t.Log("\tExpected:", count)
+		t.Log("\tReceived:", n)
+	}
+	if n := q.InFlight(); n != inFlight {
+		t.Error("Incorrect number in flight")
+		t.Log("\tExpected:", inFlight)
+		t.Log("\tReceived:", n)
+	}
+}
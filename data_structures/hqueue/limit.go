@@ -0,0 +1,203 @@
+package hqueue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLimited is returned when an item can't be taken right away because the rate limit has been
+	// reached.
+	ErrLimited = fmt.Errorf("rate limit reached")
+
+	// These are the standard error messages when trying to use an invalid token bucket or limited queue.
+	errBadTokenBucket  = fmt.Errorf("must create token bucket with NewTokenBucket() first")
+	errBadLimitedQueue = fmt.Errorf("must create queue with NewLimited() first")
+)
+
+// TokenBucket is a rate limiter. The bucket holds up to burst tokens and is refilled at a steady rate.
+// Each action takes one token, so actions can happen in bursts of up to burst at once, but only rate
+// times per second over the long run. It is safe for use by many goroutines at once.
+type TokenBucket struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a new token bucket that refills at rate tokens per second and holds at most
+// burst tokens. The bucket starts out full. If clock is nil, then the system clock is used.
+func NewTokenBucket(rate float64, burst int, clock Clock) (*TokenBucket, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("rate must be positive")
+	} else if burst < 1 {
+		return nil, fmt.Errorf("burst must be positive")
+	}
+	if clock == nil {
+		clock = SystemClock()
+	}
+
+	tb := new(TokenBucket)
+	tb.clock = clock
+	tb.rate = rate
+	tb.burst = float64(burst)
+	tb.tokens = tb.burst
+	tb.last = clock.Now()
+
+	return tb, nil
+}
+
+// Allow takes a token if one is available, and reports whether or not it did.
+func (tb *TokenBucket) Allow() bool {
+	if tb == nil || tb.clock == nil {
+		return false
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	return tb.take() == 0
+}
+
+// Wait takes a token, waiting until one is available. If ctx is done first, then ctx's error is returned
+// and no token is taken.
+func (tb *TokenBucket) Wait(ctx context.Context) error {
+	if tb == nil || tb.clock == nil {
+		return errBadTokenBucket
+	}
+
+	for {
+		tb.mu.Lock()
+		wait := tb.take()
+		tb.mu.Unlock()
+		if wait == 0 {
+			return nil
+		}
+
+		timer := tb.clock.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Tokens gets the number of tokens currently in the bucket.
+func (tb *TokenBucket) Tokens() float64 {
+	if tb == nil || tb.clock == nil {
+		return -1
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+
+	return tb.tokens
+}
+
+// take takes a token if there is one. If there isn't, then this returns how long it will be until there
+// is. The caller must hold the lock.
+func (tb *TokenBucket) take() time.Duration {
+	tb.refill()
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return 0
+	}
+
+	wait := time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+	if wait <= 0 {
+		// Rounding left us just short of a whole token.
+		wait = 1
+	}
+
+	return wait
+}
+
+// refill adds the tokens that have built up since the last refill. The caller must hold the lock.
+func (tb *TokenBucket) refill() {
+	now := tb.clock.Now()
+	if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens += elapsed.Seconds() * tb.rate
+		if tb.tokens > tb.burst {
+			tb.tokens = tb.burst
+		}
+	}
+	tb.last = now
+}
+
+// giveBack returns a token that was taken but not used.
+func (tb *TokenBucket) giveBack() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	tb.tokens++
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+}
+
+// LimitedQueue wraps a queue so that items can only be taken from it as fast as a token bucket allows.
+// It is safe for use by many goroutines at once if the wrapped queue is.
+type LimitedQueue struct {
+	queue   Taker
+	limiter *TokenBucket
+}
+
+// NewLimited creates a new queue that takes items from queue at the rate allowed by limiter.
+func NewLimited(queue Taker, limiter *TokenBucket) (*LimitedQueue, error) {
+	if queue == nil {
+		return nil, fmt.Errorf("missing queue")
+	} else if limiter == nil {
+		return nil, fmt.Errorf("missing limiter")
+	}
+
+	return &LimitedQueue{queue: queue, limiter: limiter}, nil
+}
+
+// Take waits for a token from the limiter and then takes an item from the queue, waiting for one if
+// needed. If no item is taken, then the token is given back.
+func (q *LimitedQueue) Take(ctx context.Context) (interface{}, error) {
+	if q == nil || q.limiter == nil {
+		return nil, errBadLimitedQueue
+	}
+
+	if err := q.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	item, err := q.queue.Take(ctx)
+	if err != nil {
+		q.limiter.giveBack()
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// TryTake takes an item from the queue without waiting. This returns ErrLimited if the limiter has no
+// tokens, or the queue's error if it has no items ready.
+func (q *LimitedQueue) TryTake() (interface{}, error) {
+	if q == nil || q.limiter == nil {
+		return nil, errBadLimitedQueue
+	}
+
+	if !q.limiter.Allow() {
+		return nil, ErrLimited
+	}
+
+	item, err := q.queue.TryTake()
+	if err != nil {
+		q.limiter.giveBack()
+		return nil, err
+	}
+
+	return item, nil
+}
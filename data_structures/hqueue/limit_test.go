@@ -0,0 +1,216 @@
+package hqueue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestTokenBucketBadPtr(t *testing.T) {
+	var tb *hqueue.TokenBucket
+
+	if tb.Allow() {
+		t.Error("unexpectedly passed Allow() test with bad pointer")
+	}
+	if err := tb.Wait(context.Background()); err == nil {
+		t.Error("unexpectedly passed Wait() test with bad pointer")
+	}
+	if n := tb.Tokens(); n != -1 {
+		t.Error("unexpectedly passed Tokens() test with bad pointer")
+	}
+
+	// A bucket that wasn't created with NewTokenBucket() should be rejected too.
+	var zero hqueue.TokenBucket
+	if zero.Allow() {
+		t.Error("unexpectedly passed Allow() test with zero-value bucket")
+	}
+	if err := zero.Wait(context.Background()); err == nil {
+		t.Error("unexpectedly passed Wait() test with zero-value bucket")
+	}
+	if n := zero.Tokens(); n != -1 {
+		t.Error("unexpectedly passed Tokens() test with zero-value bucket")
+	}
+
+	if _, err := hqueue.NewTokenBucket(0, 1, nil); err == nil {
+		t.Error("unexpectedly passed NewTokenBucket() test with no rate")
+	}
+	if _, err := hqueue.NewTokenBucket(1, 0, nil); err == nil {
+		t.Error("unexpectedly passed NewTokenBucket() test with no burst")
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	tb, err := hqueue.NewTokenBucket(10, 3, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The bucket starts full, so a burst is allowed right away.
+	for i := 0; i < 3; i++ {
+		if !tb.Allow() {
+			t.Fatal("Burst was not allowed")
+		}
+	}
+	if tb.Allow() {
+		t.Error("Allowed more than the burst")
+	}
+
+	// At 10 per second, a new token comes every 100ms.
+	clock.Advance(50 * time.Millisecond)
+	if tb.Allow() {
+		t.Error("Allowed before a token was added")
+	}
+	clock.Advance(50 * time.Millisecond)
+	if !tb.Allow() {
+		t.Error("Not allowed after a token was added")
+	}
+
+	// The bucket never holds more than the burst.
+	clock.Advance(time.Hour)
+	if n := tb.Tokens(); n != 3 {
+		t.Error("Incorrect number of tokens")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", n)
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	tb, _ := hqueue.NewTokenBucket(10, 1, clock)
+	ctx := context.Background()
+
+	if err := tb.Wait(ctx); err != nil {
+		t.Error(err)
+	}
+
+	// The next token is 100ms away.
+	done := make(chan error)
+	go func() {
+		done <- tb.Wait(ctx)
+	}()
+	waitForTimers(clock, 1)
+	clock.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	if n := tb.Tokens(); n != 0 {
+		t.Error("Incorrect number of tokens")
+		t.Log("\tExpected: 0")
+		t.Log("\tReceived:", n)
+	}
+
+	// Waiting stops when the context is done, and no token is taken.
+	cctx, cancel := context.WithCancel(ctx)
+	go func() {
+		waitForTimers(clock, 1)
+		cancel()
+	}()
+	if err := tb.Wait(cctx); !errors.Is(err, context.Canceled) {
+		t.Error("Wait() did not stop when context was cancelled")
+		t.Log("\tExpected:", context.Canceled)
+		t.Log("\tReceived:", err)
+	}
+	clock.Advance(100 * time.Millisecond)
+	if !tb.Allow() {
+		t.Error("Token was taken by cancelled Wait()")
+	}
+}
+
+func TestLimitedBadPtr(t *testing.T) {
+	var q *hqueue.LimitedQueue
+
+	if _, err := q.Take(context.Background()); err == nil {
+		t.Error("unexpectedly passed Take() test with bad pointer")
+	}
+	if _, err := q.TryTake(); err == nil {
+		t.Error("unexpectedly passed TryTake() test with bad pointer")
+	}
+
+	// A queue that wasn't created with NewLimited() should be rejected, not reported as limited.
+	var zero hqueue.LimitedQueue
+	if _, err := zero.Take(context.Background()); err == nil {
+		t.Error("unexpectedly passed Take() test with zero-value queue")
+	}
+	if _, err := zero.TryTake(); err == nil || errors.Is(err, hqueue.ErrLimited) {
+		t.Error("unexpectedly passed TryTake() test with zero-value queue")
+		t.Log("\tReceived:", err)
+	}
+
+	tb, _ := hqueue.NewTokenBucket(1, 1, nil)
+	if _, err := hqueue.NewLimited(nil, tb); err == nil {
+		t.Error("unexpectedly passed NewLimited() test with no queue")
+	}
+	if _, err := hqueue.NewLimited(newBlocking(t, 0), nil); err == nil {
+		t.Error("unexpectedly passed NewLimited() test with no limiter")
+	}
+}
+
+func TestLimited(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	tb, _ := hqueue.NewTokenBucket(10, 2, clock)
+	bq := newBlocking(t, 0)
+	q, err := hqueue.NewLimited(bq, tb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Taking from an empty queue doesn't use up a token.
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly passed TryTake() test for empty queue")
+		t.Log("\tExpected:", hqueue.ErrEmpty)
+		t.Log("\tReceived:", err)
+	}
+	if n := tb.Tokens(); n != 2 {
+		t.Error("Token was used up by empty queue:", n)
+	}
+
+	for i := 0; i < 5; i++ {
+		bq.Put(ctx, i)
+	}
+	for i := 0; i < 2; i++ {
+		if v, err := q.TryTake(); v != i || err != nil {
+			t.Error("Incorrect item taken")
+			t.Log("\tExpected:", i)
+			t.Log("\tReceived:", v, err)
+		}
+	}
+	if _, err := q.TryTake(); !errors.Is(err, hqueue.ErrLimited) {
+		t.Error("unexpectedly passed TryTake() test past the rate limit")
+		t.Log("\tExpected:", hqueue.ErrLimited)
+		t.Log("\tReceived:", err)
+	}
+
+	// Take waits for the next token.
+	done := make(chan interface{})
+	go func() {
+		v, err := q.Take(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- v
+	}()
+	waitForTimers(clock, 1)
+	clock.Advance(100 * time.Millisecond)
+	if v := <-done; v != 2 {
+		t.Error("Incorrect item taken")
+		t.Log("\tExpected: 2")
+		t.Log("\tReceived:", v)
+	}
+
+	// A limited queue can be batched. Once the bucket is full again, the rest of the items can be
+	// taken right away. After that, the batch waits for another token before finding out that the queue
+	// is closed.
+	b := newBatcher(t, q, 10, clock)
+	bq.Close()
+	clock.Advance(time.Hour)
+	go func() {
+		waitForTimers(clock, 2)
+		clock.Advance(100 * time.Millisecond)
+	}()
+	checkBatch(t, b, []interface{}{3, 4})
+}
@@ -0,0 +1,147 @@
+package hqueue
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// cacheLine is the size of a CPU cache line. Counters that are changed by different goroutines are
+// padded out to this size so that they don't share a line and slow each other down.
+const cacheLine = 64
+
+// MPMCQueue is a bounded first-in/first-out queue that any number of goroutines can add to and remove
+// from at once without locking. It is based on Dmitry Vyukov's bounded MPMC queue.
+//
+// The items are kept in a ring of cells, and each cell has a sequence number that says whose turn it is
+// to use the cell. Producers claim the next position by moving the enqueue counter forward with an atomic
+// compare-and-swap, write the item, and then bump the cell's sequence number to hand it to consumers.
+// Consumers do the same with the dequeue counter, and then bump the sequence number a full lap ahead to
+// hand the cell back to producers. Because a cell only changes hands through its sequence number,
+// producers and consumers only contend with each other when the queue is nearly empty or full.
+type MPMCQueue struct {
+	// The counters must come first so that they are 64-bit aligned on 32-bit platforms.
+	enqueuePos uint64
+	_          [cacheLine - 8]byte
+	dequeuePos uint64
+	_          [cacheLine - 8]byte
+
+	mask  uint64
+	cells []mpmcCell
+}
+
+// mpmcCell is an internal type for one slot in the ring.
+type mpmcCell struct {
+	seq  uint64
+	item interface{}
+}
+
+// NewMPMC creates a new lock-free queue that can hold at least capacity items. The capacity is rounded
+// up to a power of two, with a minimum of 2.
+func NewMPMC(capacity int) (*MPMCQueue, error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("capacity must be positive")
+	}
+
+	size := 2
+	for size < capacity {
+		size *= 2
+	}
+
+	q := new(MPMCQueue)
+	q.mask = uint64(size - 1)
+	q.cells = make([]mpmcCell, size)
+	for i := range q.cells {
+		q.cells[i].seq = uint64(i)
+	}
+
+	return q, nil
+}
+
+// Enqueue adds an item to the back of the queue. It returns false if the queue is full.
+func (q *MPMCQueue) Enqueue(item interface{}) bool {
+	if q == nil || len(q.cells) == 0 {
+		return false
+	}
+
+	pos := atomic.LoadUint64(&q.enqueuePos)
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := atomic.LoadUint64(&cell.seq)
+
+		switch diff := int64(seq - pos); {
+		case diff == 0:
+			// The cell is free for this position. Try to claim it.
+			if atomic.CompareAndSwapUint64(&q.enqueuePos, pos, pos+1) {
+				cell.item = item
+				atomic.StoreUint64(&cell.seq, pos+1)
+				return true
+			}
+			pos = atomic.LoadUint64(&q.enqueuePos)
+		case diff < 0:
+			// The cell still holds the item from a lap ago, so the queue is full.
+			return false
+		default:
+			// Another producer claimed this position first.
+			pos = atomic.LoadUint64(&q.enqueuePos)
+		}
+	}
+}
+
+// Dequeue removes the first item in the queue and returns its value. The second return value is false if
+// the queue is empty.
+func (q *MPMCQueue) Dequeue() (interface{}, bool) {
+	if q == nil || len(q.cells) == 0 {
+		return nil, false
+	}
+
+	pos := atomic.LoadUint64(&q.dequeuePos)
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := atomic.LoadUint64(&cell.seq)
+
+		switch diff := int64(seq - (pos + 1)); {
+		case diff == 0:
+			// The cell holds the item for this position. Try to claim it.
+			if atomic.CompareAndSwapUint64(&q.dequeuePos, pos, pos+1) {
+				item := cell.item
+				cell.item = nil
+				atomic.StoreUint64(&cell.seq, pos+q.mask+1)
+				return item, true
+			}
+			pos = atomic.LoadUint64(&q.dequeuePos)
+		case diff < 0:
+			// No item has been written to the cell yet, so the queue is empty.
+			return nil, false
+		default:
+			// Another consumer claimed this position first.
+			pos = atomic.LoadUint64(&q.dequeuePos)
+		}
+	}
+}
+
+// Count gets the current number of items in the queue. If other goroutines are adding and removing items
+// at the same time, then the count might be slightly out of date by the time it is returned.
+func (q *MPMCQueue) Count() int {
+	if q == nil {
+		return -1
+	}
+
+	// Read the dequeue counter first so that the difference can't be negative.
+	dequeued := atomic.LoadUint64(&q.dequeuePos)
+	enqueued := atomic.LoadUint64(&q.enqueuePos)
+	n := int(enqueued - dequeued)
+	if n > len(q.cells) {
+		n = len(q.cells)
+	}
+
+	return n
+}
+
+// Capacity gets the maximum number of items that the queue can hold.
+func (q *MPMCQueue) Capacity() int {
+	if q == nil {
+		return -1
+	}
+
+	return len(q.cells)
+}
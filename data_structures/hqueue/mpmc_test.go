@@ -0,0 +1,183 @@
+package hqueue_test
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestMPMCBadPtr(t *testing.T) {
+	var q *hqueue.MPMCQueue
+
+	if q.Enqueue(1) {
+		t.Error("unexpectedly passed Enqueue() test with bad pointer")
+	}
+	if _, ok := q.Dequeue(); ok {
+		t.Error("unexpectedly passed Dequeue() test with bad pointer")
+	}
+	if n := q.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if n := q.Capacity(); n != -1 {
+		t.Error("unexpectedly passed Capacity() test with bad pointer")
+	}
+
+	if _, err := hqueue.NewMPMC(0); err == nil {
+		t.Error("unexpectedly passed NewMPMC() test with no capacity")
+	}
+
+	// A queue that wasn't created with NewMPMC has no room for anything.
+	var zero hqueue.MPMCQueue
+	if zero.Enqueue(1) {
+		t.Error("unexpectedly passed Enqueue() test with zero-value queue")
+	}
+	if _, ok := zero.Dequeue(); ok {
+		t.Error("unexpectedly passed Dequeue() test with zero-value queue")
+	}
+}
+
+func TestMPMCSequential(t *testing.T) {
+	// The capacity is rounded up to a power of two.
+	q, err := hqueue.NewMPMC(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := q.Capacity(); n != 8 {
+		t.Error("Incorrect capacity")
+		t.Log("\tExpected: 8")
+		t.Log("\tReceived:", n)
+	}
+
+	if _, ok := q.Dequeue(); ok {
+		t.Error("unexpectedly dequeued item from empty queue")
+	}
+
+	// Go around the ring a few times, filling it up and then removing some items each time.
+	put, next := 0, 0
+	for round := 0; round < 10; round++ {
+		for q.Enqueue(put) {
+			put++
+		}
+		if n := q.Count(); n != 8 {
+			t.Fatal("Queue is not full:", n)
+		}
+		for j := 0; j < 5; j++ {
+			v, ok := q.Dequeue()
+			if !ok || v != next {
+				t.Fatal("Incorrect item dequeued:", v, ok, "instead of", next)
+			}
+			next++
+		}
+	}
+
+	for q.Count() > 0 {
+		v, _ := q.Dequeue()
+		if v != next {
+			t.Fatal("Incorrect item dequeued:", v, "instead of", next)
+		}
+		next++
+	}
+	if _, ok := q.Dequeue(); ok {
+		t.Error("unexpectedly dequeued item from empty queue")
+	}
+}
+
+func TestMPMCStress(t *testing.T) {
+	q, err := hqueue.NewMPMC(64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const producers = 4
+	const consumers = 4
+	const perProducer = 5000
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				for !q.Enqueue(p*perProducer + i) {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+
+	// Each consumer checks that it sees each producer's items in order, and records every item that it
+	// sees.
+	var received int64
+	seen := make([][]int, consumers)
+	var cwg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		cwg.Add(1)
+		go func(c int) {
+			defer cwg.Done()
+			last := make(map[int]int)
+			for atomic.LoadInt64(&received) < producers*perProducer {
+				v, ok := q.Dequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				atomic.AddInt64(&received, 1)
+
+				n := v.(int)
+				if prev, ok := last[n/perProducer]; ok && prev >= n {
+					t.Error("Items out of order:", prev, n)
+				}
+				last[n/perProducer] = n
+				seen[c] = append(seen[c], n)
+			}
+		}(c)
+	}
+	wg.Wait()
+	cwg.Wait()
+
+	counts := make([]int, producers*perProducer)
+	for _, items := range seen {
+		for _, n := range items {
+			counts[n]++
+		}
+	}
+	for n, count := range counts {
+		if count != 1 {
+			t.Fatal("Item", n, "was received", count, "times")
+		}
+	}
+	if n := q.Count(); n != 0 {
+		t.Error("Queue is not empty:", n)
+	}
+}
+
+// The benchmarks below compare the lock-free queues against a Queue protected by a mutex.
+
+func BenchmarkMPMC(b *testing.B) {
+	q, _ := hqueue.NewMPMC(1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Enqueue(1)
+			q.Dequeue()
+		}
+	})
+}
+
+func BenchmarkMutexQueue(b *testing.B) {
+	var mu sync.Mutex
+	q := hqueue.New()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.Lock()
+			q.Add(1)
+			mu.Unlock()
+
+			mu.Lock()
+			q.Pop()
+			mu.Unlock()
+		}
+	})
+}
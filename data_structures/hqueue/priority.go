@@ -0,0 +1,325 @@
+package hqueue
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrBadHandle is returned when a handle is nil, belongs to a different priority queue, or refers
+	// to an item that has already left the queue.
+	ErrBadHandle = fmt.Errorf("handle is not in this queue")
+
+	// This is the standard error message when trying to use an invalid priority queue.
+	errBadPriorityQueue = fmt.Errorf("must create queue with NewPriority() first")
+	// This is the standard error message when trying to use a priority that can't be ordered.
+	errBadPriority = fmt.Errorf("priority must not be NaN")
+)
+
+// PriorityQueue is a queue that always gives back the item with the lowest priority value first.
+// Items with the same priority come out in the order that they were pushed. To take the highest
+// priority first instead, negate the priorities when pushing them.
+//
+// The queue is backed by a binary heap, so pushing, popping, and changing or removing an item all take
+// O(log n) time.
+type PriorityQueue struct {
+	heap []*Handle
+	seq  uint64
+}
+
+// Handle refers to an item in a priority queue. It is returned when the item is pushed, and can be used
+// to change the item's priority or remove it from the queue later.
+type Handle struct {
+	queue    *PriorityQueue
+	item     interface{}
+	priority float64
+
+	// at orders items that were pushed by time instead of by priority. It is the zero time for items
+	// pushed with a priority.
+	at time.Time
+
+	// seq is the order in which the item was pushed, for breaking ties. index is the item's position
+	// in the heap, or -1 once it has left the queue.
+	seq   uint64
+	index int
+}
+
+// NewPriority creates a new priority queue.
+func NewPriority() *PriorityQueue {
+	return new(PriorityQueue)
+}
+
+// Push adds an item to the queue with the given priority and returns a handle to it. The priority must
+// not be NaN, since NaN can't be ordered against other priorities.
+func (pq *PriorityQueue) Push(item interface{}, priority float64) (*Handle, error) {
+	if pq == nil {
+		return nil, errBadPriorityQueue
+	} else if math.IsNaN(priority) {
+		return nil, errBadPriority
+	}
+
+	return pq.push(item, priority, time.Time{}), nil
+}
+
+// Pop removes the item with the lowest priority from the queue and returns it along with its priority.
+// This returns ErrEmpty if there are no items in the queue.
+func (pq *PriorityQueue) Pop() (interface{}, float64, error) {
+	if pq == nil {
+		return nil, 0, errBadPriorityQueue
+	} else if len(pq.heap) == 0 {
+		return nil, 0, ErrEmpty
+	}
+
+	h := pq.heap[0]
+	pq.remove(0)
+
+	return h.item, h.priority, nil
+}
+
+// Peek returns the item with the lowest priority and its priority without removing it from the queue.
+// This returns ErrEmpty if there are no items in the queue.
+func (pq *PriorityQueue) Peek() (interface{}, float64, error) {
+	if pq == nil {
+		return nil, 0, errBadPriorityQueue
+	} else if len(pq.heap) == 0 {
+		return nil, 0, ErrEmpty
+	}
+
+	h := pq.heap[0]
+
+	return h.item, h.priority, nil
+}
+
+// Update changes the priority of an item that is still in the queue. Its place among items with the
+// same priority is kept. As with Push, the priority must not be NaN.
+func (pq *PriorityQueue) Update(h *Handle, priority float64) error {
+	if pq == nil {
+		return errBadPriorityQueue
+	} else if !pq.owns(h) {
+		return ErrBadHandle
+	} else if math.IsNaN(priority) {
+		return errBadPriority
+	}
+
+	h.priority = priority
+	pq.fix(h.index)
+
+	return nil
+}
+
+// Remove takes an item out of the queue before it is popped.
+func (pq *PriorityQueue) Remove(h *Handle) error {
+	if pq == nil {
+		return errBadPriorityQueue
+	} else if !pq.owns(h) {
+		return ErrBadHandle
+	}
+
+	pq.remove(h.index)
+
+	return nil
+}
+
+// Count gets the current number of items in the queue.
+func (pq *PriorityQueue) Count() int {
+	if pq == nil {
+		return -1
+	}
+
+	return len(pq.heap)
+}
+
+// Clear removes all items from the queue. Any handles to them will no longer be valid.
+func (pq *PriorityQueue) Clear() error {
+	if pq == nil {
+		return errBadPriorityQueue
+	}
+
+	for _, h := range pq.heap {
+		h.index = -1
+	}
+	pq.heap = nil
+
+	return nil
+}
+
+// String displays the queue's contents in the order that they would be popped.
+func (pq *PriorityQueue) String() string {
+	if pq == nil {
+		return "<nil>"
+	} else if len(pq.heap) == 0 {
+		return "<empty>"
+	}
+
+	handles := make([]*Handle, len(pq.heap))
+	copy(handles, pq.heap)
+	sortHandles(handles)
+
+	builder := new(strings.Builder)
+	for _, h := range handles {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", h.item))
+	}
+
+	return builder.String()
+}
+
+// Item gets the item that the handle refers to.
+func (h *Handle) Item() interface{} {
+	if h == nil {
+		return nil
+	}
+
+	return h.item
+}
+
+// Priority gets the current priority of the item that the handle refers to.
+func (h *Handle) Priority() float64 {
+	if h == nil {
+		return 0
+	}
+
+	return h.priority
+}
+
+// Queued checks whether or not the item is still in the queue.
+func (h *Handle) Queued() bool {
+	if h == nil {
+		return false
+	}
+
+	return h.index >= 0
+}
+
+// pushAt adds an item that is ordered by the time instead of by a priority. This is for queues that
+// order their items by deadline, since a time can't be turned into a float64 priority without losing
+// precision once it is more than about 104 days (2^53 nanoseconds) away from the zero point. Items
+// pushed this way should not be mixed with items pushed with a priority.
+func (pq *PriorityQueue) pushAt(item interface{}, at time.Time) *Handle {
+	return pq.push(item, 0, at)
+}
+
+// updateAt changes the time of an item that was pushed with pushAt and is still in the queue.
+func (pq *PriorityQueue) updateAt(h *Handle, at time.Time) error {
+	if !pq.owns(h) {
+		return ErrBadHandle
+	}
+
+	h.at = at
+	pq.fix(h.index)
+
+	return nil
+}
+
+// push adds an item to the heap and returns its handle.
+func (pq *PriorityQueue) push(item interface{}, priority float64, at time.Time) *Handle {
+	h := &Handle{
+		queue:    pq,
+		item:     item,
+		priority: priority,
+		at:       at,
+		seq:      pq.seq,
+		index:    len(pq.heap),
+	}
+	pq.seq++
+
+	pq.heap = append(pq.heap, h)
+	pq.up(h.index)
+
+	return h
+}
+
+// owns checks that the handle refers to an item that is currently in this queue.
+func (pq *PriorityQueue) owns(h *Handle) bool {
+	return h != nil && h.queue == pq && h.index >= 0
+}
+
+// remove takes out the item at position i in the heap and restores the heap's order.
+func (pq *PriorityQueue) remove(i int) {
+	h := pq.heap[i]
+	last := len(pq.heap) - 1
+
+	if i != last {
+		pq.swap(i, last)
+	}
+	pq.heap[last] = nil
+	pq.heap = pq.heap[:last]
+	h.index = -1
+
+	if i != last {
+		pq.fix(i)
+	}
+}
+
+// fix restores the heap's order after the item at position i has changed.
+func (pq *PriorityQueue) fix(i int) {
+	if !pq.up(i) {
+		pq.down(i)
+	}
+}
+
+// up moves the item at position i toward the root until its parent comes before it. This reports
+// whether or not the item moved.
+func (pq *PriorityQueue) up(i int) bool {
+	start := i
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !before(pq.heap[i], pq.heap[parent]) {
+			break
+		}
+		pq.swap(i, parent)
+		i = parent
+	}
+
+	return i != start
+}
+
+// down moves the item at position i away from the root until it comes before both of its children.
+func (pq *PriorityQueue) down(i int) {
+	n := len(pq.heap)
+	for {
+		child := 2*i + 1
+		if child >= n {
+			return
+		}
+		if right := child + 1; right < n && before(pq.heap[right], pq.heap[child]) {
+			child = right
+		}
+		if !before(pq.heap[child], pq.heap[i]) {
+			return
+		}
+		pq.swap(i, child)
+		i = child
+	}
+}
+
+// swap exchanges the items at positions i and j in the heap and updates their indexes.
+func (pq *PriorityQueue) swap(i, j int) {
+	pq.heap[i], pq.heap[j] = pq.heap[j], pq.heap[i]
+	pq.heap[i].index = i
+	pq.heap[j].index = j
+}
+
+// sortHandles sorts the handles into the order that they would be popped.
+func sortHandles(handles []*Handle) {
+	sort.Slice(handles, func(i, j int) bool {
+		return before(handles[i], handles[j])
+	})
+}
+
+// before checks whether or not a should be popped before b. Items with equal priorities are ordered by
+// their times, and then by when they were pushed.
+func before(a, b *Handle) bool {
+	if a.priority != b.priority {
+		return a.priority < b.priority
+	} else if !a.at.Equal(b.at) {
+		return a.at.Before(b.at)
+	}
+
+	return a.seq < b.seq
+}
@@ -0,0 +1,291 @@
+package hqueue_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestPriorityBadPtr(t *testing.T) {
+	var pq *hqueue.PriorityQueue
+
+	if _, err := pq.Push(1, 1); err == nil {
+		t.Error("unexpectedly passed Push() test with bad pointer")
+	}
+	if _, _, err := pq.Pop(); err == nil {
+		t.Error("unexpectedly passed Pop() test with bad pointer")
+	}
+	if _, _, err := pq.Peek(); err == nil {
+		t.Error("unexpectedly passed Peek() test with bad pointer")
+	}
+	if err := pq.Update(nil, 1); err == nil {
+		t.Error("unexpectedly passed Update() test with bad pointer")
+	}
+	if err := pq.Remove(nil); err == nil {
+		t.Error("unexpectedly passed Remove() test with bad pointer")
+	}
+	if n := pq.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if err := pq.Clear(); err == nil {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+	if s := pq.String(); s != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+	}
+
+	var h *hqueue.Handle
+	if h.Item() != nil || h.Priority() != 0 || h.Queued() {
+		t.Error("unexpectedly passed Handle test with bad pointer")
+	}
+}
+
+func TestPriorityBadArgs(t *testing.T) {
+	pq := hqueue.NewPriority()
+
+	// NaN can't be ordered, so it would break the heap.
+	if _, err := pq.Push("nan", math.NaN()); err == nil {
+		t.Error("unexpectedly passed Push() test with NaN priority")
+	}
+	h, _ := pq.Push("b", 2)
+	pq.Push("a", 1)
+	pq.Push("c", 3)
+	if err := pq.Update(h, math.NaN()); err == nil {
+		t.Error("unexpectedly passed Update() test with NaN priority")
+	}
+	if p := h.Priority(); p != 2 {
+		t.Error("Priority changed by rejected update")
+		t.Log("\tExpected: 2")
+		t.Log("\tReceived:", p)
+	}
+	checkPriority(t, pq, "a, b, c", 3)
+
+	// Infinities can still be ordered.
+	pq.Push("first", math.Inf(-1))
+	pq.Push("last", math.Inf(1))
+	checkPriority(t, pq, "first, a, b, c, last", 5)
+}
+
+func TestPriorityOrder(t *testing.T) {
+	pq := hqueue.NewPriority()
+	checkPriority(t, pq, "<empty>", 0)
+	if _, _, err := pq.Pop(); !errors.Is(err, hqueue.ErrEmpty) {
+		t.Error("unexpectedly passed Pop() test for empty queue")
+		t.Log("\tExpected:", hqueue.ErrEmpty)
+		t.Log("\tReceived:", err)
+	}
+
+	// Items with the same priority should come out in the order that they went in.
+	pq.Push("c", 3)
+	pq.Push("a1", 1)
+	pq.Push("b", 2)
+	pq.Push("a2", 1)
+	pq.Push("neg", -5)
+	pq.Push("a3", 1)
+	checkPriority(t, pq, "neg, a1, a2, a3, b, c", 6)
+
+	if v, p, err := pq.Peek(); v != "neg" || p != -5 || err != nil {
+		t.Error("Incorrect item peeked")
+		t.Log("\tExpected: neg -5 <nil>")
+		t.Log("\tReceived:", v, p, err)
+	}
+
+	want := []interface{}{"neg", "a1", "a2", "a3", "b", "c"}
+	for _, w := range want {
+		if v, _, err := pq.Pop(); v != w || err != nil {
+			t.Error("Incorrect item popped")
+			t.Log("\tExpected:", w)
+			t.Log("\tReceived:", v, err)
+		}
+	}
+	checkPriority(t, pq, "<empty>", 0)
+}
+
+func TestPriorityUpdate(t *testing.T) {
+	pq := hqueue.NewPriority()
+
+	a, _ := pq.Push("a", 10)
+	b, _ := pq.Push("b", 20)
+	c, _ := pq.Push("c", 30)
+	d, _ := pq.Push("d", 20)
+	checkPriority(t, pq, "a, b, d, c", 4)
+
+	// Decrease a key.
+	if err := pq.Update(c, 5); err != nil {
+		t.Error(err)
+	}
+	checkPriority(t, pq, "c, a, b, d", 4)
+	if c.Priority() != 5 {
+		t.Error("Incorrect priority after update")
+		t.Log("\tExpected: 5")
+		t.Log("\tReceived:", c.Priority())
+	}
+
+	// Increase a key. d was pushed after b, so it should stay behind it.
+	if err := pq.Update(a, 20); err != nil {
+		t.Error(err)
+	}
+	checkPriority(t, pq, "c, a, b, d", 4)
+	if err := pq.Update(b, 21); err != nil {
+		t.Error(err)
+	}
+	checkPriority(t, pq, "c, a, d, b", 4)
+
+	// Remove from the middle.
+	if err := pq.Remove(a); err != nil {
+		t.Error(err)
+	}
+	checkPriority(t, pq, "c, d, b", 3)
+	if a.Queued() {
+		t.Error("Removed item is still queued")
+	}
+	if a.Item() != "a" {
+		t.Error("Handle lost its item after removal")
+	}
+
+	// Handles for items that are no longer in the queue, or that belong to another queue, are invalid.
+	if err := pq.Remove(a); !errors.Is(err, hqueue.ErrBadHandle) {
+		t.Error("unexpectedly passed Remove() test with removed handle")
+	}
+	if err := pq.Update(a, 1); !errors.Is(err, hqueue.ErrBadHandle) {
+		t.Error("unexpectedly passed Update() test with removed handle")
+	}
+	other, _ := hqueue.NewPriority().Push("x", 1)
+	if err := pq.Update(other, 1); !errors.Is(err, hqueue.ErrBadHandle) {
+		t.Error("unexpectedly passed Update() test with handle from another queue")
+	}
+	if err := pq.Remove(nil); !errors.Is(err, hqueue.ErrBadHandle) {
+		t.Error("unexpectedly passed Remove() test with nil handle")
+	}
+
+	pq.Pop()
+	if c.Queued() {
+		t.Error("Popped item is still queued")
+	}
+
+	if err := pq.Clear(); err != nil {
+		t.Error(err)
+	}
+	checkPriority(t, pq, "<empty>", 0)
+	if d.Queued() || b.Queued() {
+		t.Error("Cleared items are still queued")
+	}
+}
+
+func TestPriorityRandom(t *testing.T) {
+	// Push, update, and remove many random items, and check that they come out sorted.
+	pq := hqueue.NewPriority()
+
+	type entry struct {
+		handle   *hqueue.Handle
+		priority float64
+		seq      int
+	}
+	var entries []*entry
+	for i := 0; i < 2000; i++ {
+		p := float64(rand.Intn(100))
+		h, _ := pq.Push(i, p)
+		entries = append(entries, &entry{h, p, i})
+	}
+	for i := 0; i < 500; i++ {
+		e := entries[rand.Intn(len(entries))]
+		e.priority = float64(rand.Intn(100))
+		pq.Update(e.handle, e.priority)
+	}
+
+	var kept []*entry
+	for _, e := range entries {
+		if rand.Intn(4) == 0 {
+			pq.Remove(e.handle)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].priority < kept[j].priority
+	})
+
+	if n := pq.Count(); n != len(kept) {
+		t.Fatal("Incorrect count:", n, len(kept))
+	}
+	for _, e := range kept {
+		v, p, err := pq.Pop()
+		if v != e.seq || p != e.priority || err != nil {
+			t.Fatal("Incorrect item popped:", v, p, "instead of", e.seq, e.priority)
+		}
+	}
+}
+
+func TestPriorityDijkstra(t *testing.T) {
+	// Find the shortest distances in a small graph, which is what decrease-key is for.
+	edges := map[string]map[string]float64{
+		"a": {"b": 7, "c": 9, "f": 14},
+		"b": {"a": 7, "c": 10, "d": 15},
+		"c": {"a": 9, "b": 10, "d": 11, "f": 2},
+		"d": {"b": 15, "c": 11, "e": 6},
+		"e": {"d": 6, "f": 9},
+		"f": {"a": 14, "c": 2, "e": 9},
+	}
+
+	pq := hqueue.NewPriority()
+	handles := make(map[string]*hqueue.Handle)
+	for node := range edges {
+		dist := 1e9
+		if node == "a" {
+			dist = 0
+		}
+		handles[node], _ = pq.Push(node, dist)
+	}
+
+	dists := make(map[string]float64)
+	for pq.Count() > 0 {
+		v, dist, _ := pq.Pop()
+		node := v.(string)
+		dists[node] = dist
+		for next, weight := range edges[node] {
+			h := handles[next]
+			if h.Queued() && dist+weight < h.Priority() {
+				pq.Update(h, dist+weight)
+			}
+		}
+	}
+
+	want := map[string]float64{"a": 0, "b": 7, "c": 9, "d": 20, "e": 20, "f": 11}
+	for node, dist := range want {
+		if dists[node] != dist {
+			t.Error("Incorrect distance for", node)
+			t.Log("\tExpected:", dist)
+			t.Log("\tReceived:", dists[node])
+		}
+	}
+}
+
+func BenchmarkPriorityPushPop(b *testing.B) {
+	pq := hqueue.NewPriority()
+	for i := 0; i < 1000; i++ {
+		pq.Push(i, rand.Float64())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pq.Push(i, rand.Float64())
+		pq.Pop()
+	}
+}
+
+func checkPriority(t *testing.T, pq *hqueue.PriorityQueue, want string, count int) {
+	if pq.String() != want {
+		t.Error("Queue contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", pq)
+	}
+	if n := pq.Count(); n != count {
+		t.Error("Incorrect count")
+		t.Log("\tExpected:", count)
+		t.Log("\tReceived:", n)
+	}
+}
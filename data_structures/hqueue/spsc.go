@@ -0,0 +1,120 @@
+package hqueue
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// SPSCQueue is a bounded first-in/first-out queue for exactly one producer goroutine and one consumer
+// goroutine, without locking. It is faster than MPMCQueue because each counter is only ever changed by
+// one side, so no compare-and-swap is needed, only atomic loads and stores.
+//
+// Enqueue must only be called from one goroutine at a time, and Dequeue must only be called from one
+// goroutine at a time. Using either from more than one goroutine at once will lose or duplicate items.
+type SPSCQueue struct {
+	// head is the position of the next item to remove. It is only changed by the consumer, which also
+	// keeps its own copy of tail so that it doesn't have to read the producer's counter every time.
+	head       uint64
+	cachedTail uint64
+	_          [cacheLine - 16]byte
+
+	// tail is the position of the next item to add. It is only changed by the producer, which also
+	// keeps its own copy of head.
+	tail       uint64
+	cachedHead uint64
+	_          [cacheLine - 16]byte
+
+	mask  uint64
+	items []interface{}
+}
+
+// NewSPSC creates a new single-producer/single-consumer queue that can hold at least capacity items. The
+// capacity is rounded up to a power of two.
+func NewSPSC(capacity int) (*SPSCQueue, error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("capacity must be positive")
+	}
+
+	size := 1
+	for size < capacity {
+		size *= 2
+	}
+
+	q := new(SPSCQueue)
+	q.mask = uint64(size - 1)
+	q.items = make([]interface{}, size)
+
+	return q, nil
+}
+
+// Enqueue adds an item to the back of the queue. It returns false if the queue is full. This must only be
+// called by the producer.
+func (q *SPSCQueue) Enqueue(item interface{}) bool {
+	if q == nil {
+		return false
+	}
+
+	tail := q.tail
+	if tail-q.cachedHead == uint64(len(q.items)) {
+		// The queue looked full. See how far the consumer has gotten since we last checked.
+		q.cachedHead = atomic.LoadUint64(&q.head)
+		if tail-q.cachedHead == uint64(len(q.items)) {
+			return false
+		}
+	}
+
+	q.items[tail&q.mask] = item
+	atomic.StoreUint64(&q.tail, tail+1)
+
+	return true
+}
+
+// Dequeue removes the first item in the queue and returns its value. The second return value is false if
+// the queue is empty. This must only be called by the consumer.
+func (q *SPSCQueue) Dequeue() (interface{}, bool) {
+	if q == nil {
+		return nil, false
+	}
+
+	head := q.head
+	if head == q.cachedTail {
+		// The queue looked empty. See how far the producer has gotten since we last checked.
+		q.cachedTail = atomic.LoadUint64(&q.tail)
+		if head == q.cachedTail {
+			return nil, false
+		}
+	}
+
+	item := q.items[head&q.mask]
+	q.items[head&q.mask] = nil
+	atomic.StoreUint64(&q.head, head+1)
+
+	return item, true
+}
+
+// Count gets the current number of items in the queue. If the producer or consumer is using the queue at
+// the same time, then the count might be slightly out of date by the time it is returned.
+func (q *SPSCQueue) Count() int {
+	if q == nil {
+		return -1
+	}
+
+	// Read head first so that the difference can't be negative.
+	head := atomic.LoadUint64(&q.head)
+	tail := atomic.LoadUint64(&q.tail)
+	n := int(tail - head)
+	if n > len(q.items) {
+		n = len(q.items)
+	}
+
+	return n
+}
+
+// Capacity gets the maximum number of items that the queue can hold.
+func (q *SPSCQueue) Capacity() int {
+	if q == nil {
+		return -1
+	}
+
+	return len(q.items)
+}
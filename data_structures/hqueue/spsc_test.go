@@ -0,0 +1,149 @@
+package hqueue_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+func TestSPSCBadPtr(t *testing.T) {
+	var q *hqueue.SPSCQueue
+
+	if q.Enqueue(1) {
+		t.Error("unexpectedly passed Enqueue() test with bad pointer")
+	}
+	if _, ok := q.Dequeue(); ok {
+		t.Error("unexpectedly passed Dequeue() test with bad pointer")
+	}
+	if n := q.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if n := q.Capacity(); n != -1 {
+		t.Error("unexpectedly passed Capacity() test with bad pointer")
+	}
+
+	if _, err := hqueue.NewSPSC(0); err == nil {
+		t.Error("unexpectedly passed NewSPSC() test with no capacity")
+	}
+}
+
+func TestSPSCSequential(t *testing.T) {
+	q, err := hqueue.NewSPSC(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := q.Capacity(); n != 4 {
+		t.Error("Incorrect capacity")
+		t.Log("\tExpected: 4")
+		t.Log("\tReceived:", n)
+	}
+
+	for i := 0; i < 4; i++ {
+		if !q.Enqueue(i) {
+			t.Fatal("Failed to enqueue item", i)
+		}
+	}
+	if q.Enqueue(4) {
+		t.Error("unexpectedly enqueued item into full queue")
+	}
+	if n := q.Count(); n != 4 {
+		t.Error("Incorrect count")
+		t.Log("\tExpected: 4")
+		t.Log("\tReceived:", n)
+	}
+
+	for i := 0; i < 4; i++ {
+		if v, ok := q.Dequeue(); v != i || !ok {
+			t.Error("Incorrect item dequeued")
+			t.Log("\tExpected:", i)
+			t.Log("\tReceived:", v, ok)
+		}
+	}
+	if _, ok := q.Dequeue(); ok {
+		t.Error("unexpectedly dequeued item from empty queue")
+	}
+}
+
+func TestSPSCStress(t *testing.T) {
+	q, err := hqueue.NewSPSC(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const items = 20000
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < items; i++ {
+			for !q.Enqueue(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	for i := 0; i < items; i++ {
+		v, ok := q.Dequeue()
+		for !ok {
+			runtime.Gosched()
+			v, ok = q.Dequeue()
+		}
+		if v != i {
+			t.Fatal("Incorrect item dequeued:", v, "instead of", i)
+		}
+	}
+	wg.Wait()
+
+	if n := q.Count(); n != 0 {
+		t.Error("Queue is not empty:", n)
+	}
+}
+
+func BenchmarkSPSC(b *testing.B) {
+	q, _ := hqueue.NewSPSC(1024)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < b.N; i++ {
+			for _, ok := q.Dequeue(); !ok; _, ok = q.Dequeue() {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	for i := 0; i < b.N; i++ {
+		for !q.Enqueue(i) {
+			runtime.Gosched()
+		}
+	}
+	<-done
+}
+
+func BenchmarkSPSCMutexQueue(b *testing.B) {
+	var mu sync.Mutex
+	q := hqueue.New()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < b.N; {
+			mu.Lock()
+			if q.Count() > 0 {
+				q.Pop()
+				i++
+			}
+			mu.Unlock()
+		}
+	}()
+
+	for i := 0; i < b.N; i++ {
+		mu.Lock()
+		q.Add(i)
+		mu.Unlock()
+	}
+	<-done
+}
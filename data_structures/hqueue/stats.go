@@ -0,0 +1,265 @@
+package hqueue
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNotInstrumented is returned when asking for the statistics of a queue that isn't collecting them.
+var ErrNotInstrumented = fmt.Errorf("queue is not instrumented")
+
+// DefaultWaitBuckets are the upper bounds of the buckets that time-in-queue measurements are sorted into
+// if no others are given.
+var DefaultWaitBuckets = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// Hook is told about everything that happens to an instrumented queue as it happens, so that the
+// numbers can be passed on to a metrics library. The hook is called while the queue is being changed, so
+// it must not use the queue itself.
+type Hook interface {
+	// Enqueued is called after an item is added. depth is the number of items now in the queue.
+	Enqueued(depth int)
+	// Dequeued is called after an item is removed. wait is how long the item was in the queue.
+	Dequeued(depth int, wait time.Duration)
+	// Dropped is called after n items are thrown away without being removed, like when the queue is
+	// cleared.
+	Dropped(n int)
+}
+
+// StatsOptions holds the settings for collecting a queue's statistics. The zero value of each field
+// picks its default.
+type StatsOptions struct {
+	// Clock is used to measure how long items spend in the queue. The default is the system clock.
+	Clock Clock
+	// Hook, if given, is told about every change to the queue.
+	Hook Hook
+	// Buckets are the upper bounds of the time-in-queue histogram's buckets, in increasing order. The
+	// default is DefaultWaitBuckets.
+	Buckets []time.Duration
+}
+
+// Stats is a snapshot of a queue's statistics.
+type Stats struct {
+	// Enqueued, Dequeued, and Dropped are how many items have been added, removed, and thrown away.
+	Enqueued uint64
+	Dequeued uint64
+	Dropped  uint64
+	// Depth is the number of items in the queue, and HighWater is the most that it has ever held.
+	Depth     int
+	HighWater int
+	// Wait is how long removed items spent in the queue.
+	Wait Histogram
+}
+
+// Histogram counts measurements of time in buckets. Counts[i] is the number of measurements that were
+// no more than Bounds[i] and more than the bound before it. The last count, which has no bound, is the
+// number of measurements larger than every bound.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+	Max    time.Duration
+}
+
+// queueStats is an internal type for the statistics that an instrumented queue collects.
+type queueStats struct {
+	clock Clock
+	hook  Hook
+
+	enqueued  uint64
+	dequeued  uint64
+	dropped   uint64
+	highWater int
+	wait      Histogram
+
+	// times holds when each item was added. It is laid out the same way as the queue's items.
+	times []time.Time
+}
+
+// Instrument starts collecting statistics for the queue, which can then be read with Stats. If opts is
+// nil, then the defaults are used. Items already in the queue are treated as though they were added now.
+// Calling this again starts the statistics over.
+func (q *Queue) Instrument(opts *StatsOptions) error {
+	if q == nil {
+		return errBadQueue
+	}
+
+	var o StatsOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Clock == nil {
+		o.Clock = SystemClock()
+	}
+	if o.Buckets == nil {
+		o.Buckets = DefaultWaitBuckets
+	}
+	for i, bound := range o.Buckets {
+		if bound <= 0 || (i > 0 && bound <= o.Buckets[i-1]) {
+			return fmt.Errorf("buckets must be positive and increasing")
+		}
+	}
+
+	s := new(queueStats)
+	s.clock = o.Clock
+	s.hook = o.Hook
+	s.highWater = q.count
+	s.wait.Bounds = append([]time.Duration(nil), o.Buckets...)
+	s.wait.Counts = make([]uint64, len(o.Buckets)+1)
+
+	s.times = make([]time.Time, len(q.items))
+	now := s.clock.Now()
+	for i := 0; i < q.count; i++ {
+		s.times[q.index(i)] = now
+	}
+	q.stats = s
+
+	return nil
+}
+
+// Stats gets a snapshot of the queue's statistics. This returns ErrNotInstrumented if Instrument hasn't
+// been called.
+func (q *Queue) Stats() (Stats, error) {
+	if q == nil {
+		return Stats{}, errBadQueue
+	} else if q.stats == nil {
+		return Stats{}, ErrNotInstrumented
+	}
+
+	s := q.stats
+	wait := s.wait
+	wait.Bounds = append([]time.Duration(nil), s.wait.Bounds...)
+	wait.Counts = append([]uint64(nil), s.wait.Counts...)
+
+	return Stats{
+		Enqueued:  s.enqueued,
+		Dequeued:  s.dequeued,
+		Dropped:   s.dropped,
+		Depth:     q.count,
+		HighWater: s.highWater,
+		Wait:      wait,
+	}, nil
+}
+
+// Mean gets the average of the measurements, or 0 if there aren't any.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile estimates the measurement that the fraction p of all measurements are no larger than, such as
+// 0.99 for the 99th percentile. The estimate is the upper bound of the bucket that it falls in, or the
+// largest measurement if it is past the last bound. This returns 0 if there are no measurements.
+func (h Histogram) Quantile(p float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	// Find the first bucket where the running count reaches the rank we want.
+	rank := uint64(math.Ceil(p * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, n := range h.Counts {
+		seen += n
+		if seen >= rank {
+			if i < len(h.Bounds) && h.Bounds[i] < h.Max {
+				return h.Bounds[i]
+			}
+			return h.Max
+		}
+	}
+
+	return h.Max
+}
+
+// now gets the current time for stamping items, or the zero time if the queue isn't instrumented.
+func (q *Queue) now() time.Time {
+	if q.stats == nil {
+		return time.Time{}
+	}
+
+	return q.stats.clock.Now()
+}
+
+// enqueue records that an item was added at position i in the buffer at the given time. The caller must
+// have already counted the item.
+func (s *queueStats) enqueue(i int, at time.Time, depth int) {
+	s.times[i] = at
+	s.enqueued++
+	if depth > s.highWater {
+		s.highWater = depth
+	}
+	if s.hook != nil {
+		s.hook.Enqueued(depth)
+	}
+}
+
+// dequeue records that the item at position i in the buffer was removed at the given time. The caller
+// must have already uncounted the item.
+func (s *queueStats) dequeue(i int, now time.Time, depth int) {
+	wait := now.Sub(s.times[i])
+	s.times[i] = time.Time{}
+	if wait < 0 {
+		wait = 0
+	}
+
+	s.dequeued++
+	s.wait.Count++
+	s.wait.Sum += wait
+	if wait > s.wait.Max {
+		s.wait.Max = wait
+	}
+	b := 0
+	for b < len(s.wait.Bounds) && wait > s.wait.Bounds[b] {
+		b++
+	}
+	s.wait.Counts[b]++
+
+	if s.hook != nil {
+		s.hook.Dequeued(depth, wait)
+	}
+}
+
+// drop records that n items were thrown away.
+func (s *queueStats) drop(n int) {
+	if n == 0 {
+		return
+	}
+
+	s.dropped += uint64(n)
+	if s.hook != nil {
+		s.hook.Dropped(n)
+	}
+}
+
+// copyTimesTo copies the times that the items were added in order into the buffer, the same way that
+// copyTo copies the items.
+func (q *Queue) copyTimesTo(buf []time.Time) {
+	if q.count == 0 {
+		return
+	}
+
+	times := q.stats.times
+	if end := q.head + q.count; end <= len(times) {
+		copy(buf, times[q.head:end])
+	} else {
+		n := copy(buf, times[q.head:])
+		copy(buf[n:], times[:end-len(times)])
+	}
+}
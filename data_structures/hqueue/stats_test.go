@@ -0,0 +1,275 @@
+package hqueue_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hqueue"
+)
+
+// recorder is a hook that writes down every call it receives.
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) Enqueued(depth int) {
+	r.calls = append(r.calls, fmt.Sprint("enqueued ", depth))
+}
+
+func (r *recorder) Dequeued(depth int, wait time.Duration) {
+	r.calls = append(r.calls, fmt.Sprint("dequeued ", depth, " ", wait))
+}
+
+func (r *recorder) Dropped(n int) {
+	r.calls = append(r.calls, fmt.Sprint("dropped ", n))
+}
+
+func TestStatsBadPtr(t *testing.T) {
+	var q *hqueue.Queue
+
+	if err := q.Instrument(nil); err == nil {
+		t.Error("unexpectedly passed Instrument() test with bad pointer")
+	}
+	if _, err := q.Stats(); err == nil {
+		t.Error("unexpectedly passed Stats() test with bad pointer")
+	}
+
+	// A queue that isn't instrumented has no statistics.
+	if _, err := hqueue.New().Stats(); !errors.Is(err, hqueue.ErrNotInstrumented) {
+		t.Error("unexpectedly passed Stats() test for uninstrumented queue")
+		t.Log("\tExpected:", hqueue.ErrNotInstrumented)
+		t.Log("\tReceived:", err)
+	}
+}
+
+func TestStatsBadArgs(t *testing.T) {
+	q := hqueue.New()
+
+	bad := [][]time.Duration{
+		{0},
+		{-time.Second},
+		{time.Second, time.Second},
+		{time.Second, time.Millisecond},
+	}
+	for _, buckets := range bad {
+		if err := q.Instrument(&hqueue.StatsOptions{Buckets: buckets}); err == nil {
+			t.Error("unexpectedly passed Instrument() test with bad buckets:", buckets)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	hook := new(recorder)
+	q := newInstrumented(t, clock, hook)
+
+	q.Add("a", "b")
+	clock.Advance(5 * time.Millisecond)
+	q.Add("c")
+	clock.Advance(5 * time.Millisecond)
+	q.Pop()
+	q.Pop()
+	clock.Advance(time.Second)
+	q.Pop()
+
+	stats := checkStats(t, q, 3, 3, 0, 0, 3)
+	if want := []uint64{0, 2, 0, 1}; !reflect.DeepEqual(stats.Wait.Counts, want) {
+		t.Error("Incorrect wait histogram")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", stats.Wait.Counts)
+	}
+	if stats.Wait.Count != 3 || stats.Wait.Max != 1005*time.Millisecond {
+		t.Error("Incorrect wait totals")
+		t.Log("\tExpected: 3 1.005s")
+		t.Log("\tReceived:", stats.Wait.Count, stats.Wait.Max)
+	}
+	if mean := stats.Wait.Mean(); mean != 1025*time.Millisecond/3 {
+		t.Error("Incorrect mean wait")
+		t.Log("\tExpected:", 1025*time.Millisecond/3)
+		t.Log("\tReceived:", mean)
+	}
+	if p := stats.Wait.Quantile(0.5); p != 10*time.Millisecond {
+		t.Error("Incorrect median wait")
+		t.Log("\tExpected: 10ms")
+		t.Log("\tReceived:", p)
+	}
+	if p := stats.Wait.Quantile(0.99); p != 1005*time.Millisecond {
+		t.Error("Incorrect 99th percentile wait")
+		t.Log("\tExpected: 1.005s")
+		t.Log("\tReceived:", p)
+	}
+
+	want := []string{
+		"enqueued 1",
+		"enqueued 2",
+		"enqueued 3",
+		"dequeued 2 10ms",
+		"dequeued 1 10ms",
+		"dequeued 0 1.005s",
+	}
+	checkCalls(t, hook, want)
+
+	// Changing the snapshot doesn't change the queue's statistics.
+	stats.Wait.Counts[0] = 100
+	if stats, _ := q.Stats(); stats.Wait.Counts[0] != 0 {
+		t.Error("Snapshot shares memory with queue")
+	}
+}
+
+func TestStatsGrow(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := newInstrumented(t, clock, nil)
+
+	// Growing and shrinking the buffer, and wrapping around it, must keep each item's time with it.
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+		clock.Advance(time.Millisecond)
+		if i%3 == 0 {
+			q.Pop()
+		}
+	}
+	for q.Count() > 0 {
+		q.Pop()
+	}
+
+	stats := checkStats(t, q, 100, 100, 0, 0, 67)
+	if stats.Wait.Max != 67*time.Millisecond {
+		t.Error("Incorrect wait totals")
+		t.Log("\tExpected: 67ms")
+		t.Log("\tReceived:", stats.Wait.Max)
+	}
+}
+
+func TestStatsClear(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	hook := new(recorder)
+	q := newInstrumented(t, clock, hook)
+
+	q.Add(1, 2, 3)
+	q.Clear()
+	checkStats(t, q, 3, 0, 3, 0, 3)
+
+	// The queue keeps collecting statistics after it is cleared.
+	q.Add(4)
+	clock.Advance(time.Millisecond)
+	q.Pop()
+	checkStats(t, q, 4, 1, 3, 0, 3)
+
+	checkCalls(t, hook, []string{
+		"enqueued 1",
+		"enqueued 2",
+		"enqueued 3",
+		"dropped 3",
+		"enqueued 1",
+		"dequeued 0 1ms",
+	})
+}
+
+func TestStatsMerge(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q1 := newInstrumented(t, clock, nil)
+	q2 := newInstrumented(t, clock, nil)
+
+	q1.Add(1)
+	q2.Add(2, 3)
+	clock.Advance(time.Millisecond)
+
+	// The merged items are removed from one queue and added to the other, not dropped.
+	if err := q1.Merge(q2); err != nil {
+		t.Fatal(err)
+	}
+	checkStats(t, q1, 3, 0, 0, 3, 3)
+	checkStats(t, q2, 2, 2, 0, 0, 2)
+
+	// Merging a queue into itself adds its items again.
+	if err := q1.Merge(q1); err != nil {
+		t.Fatal(err)
+	}
+	checkStats(t, q1, 6, 0, 0, 6, 6)
+
+	// Copies don't collect statistics.
+	c, _ := q1.Copy()
+	if _, err := c.Stats(); !errors.Is(err, hqueue.ErrNotInstrumented) {
+		t.Error("Copy is instrumented")
+	}
+}
+
+func TestStatsExisting(t *testing.T) {
+	clock := hqueue.NewFakeClock(epoch)
+	q := hqueue.New()
+	q.Add(1, 2)
+
+	// Items already in the queue count toward the depth, but not the number added.
+	if err := q.Instrument(&hqueue.StatsOptions{Clock: clock}); err != nil {
+		t.Fatal(err)
+	}
+	checkStats(t, q, 0, 0, 0, 2, 2)
+
+	clock.Advance(time.Second)
+	q.Pop()
+	stats := checkStats(t, q, 0, 1, 0, 1, 2)
+	if stats.Wait.Max != time.Second {
+		t.Error("Incorrect wait for existing item")
+		t.Log("\tExpected: 1s")
+		t.Log("\tReceived:", stats.Wait.Max)
+	}
+
+	// Instrumenting again starts over.
+	if err := q.Instrument(&hqueue.StatsOptions{Clock: clock, Buckets: []time.Duration{time.Second}}); err != nil {
+		t.Fatal(err)
+	}
+	stats = checkStats(t, q, 0, 0, 0, 1, 1)
+	if len(stats.Wait.Counts) != 2 {
+		t.Error("Incorrect number of buckets")
+		t.Log("\tExpected: 2")
+		t.Log("\tReceived:", len(stats.Wait.Counts))
+	}
+}
+
+func BenchmarkAddPopInstrumented(b *testing.B) {
+	q := hqueue.New()
+	q.Instrument(nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Add(i)
+		q.Pop()
+	}
+}
+
+func newInstrumented(t *testing.T, clock hqueue.Clock, hook hqueue.Hook) *hqueue.Queue {
+	q := hqueue.New()
+	opts := hqueue.StatsOptions{Clock: clock, Hook: hook, Buckets: []time.Duration{time.Millisecond, 10 * time.Millisecond, time.Second}}
+	if err := q.Instrument(&opts); err != nil {
+		t.Fatal(err)
+	}
+
+	return q
+}
+
+func checkStats(t *testing.T, q *hqueue.Queue, enqueued, dequeued, dropped uint64, depth, highWater int) hqueue.Stats {
+	stats, err := q.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Enqueued != enqueued || stats.Dequeued != dequeued || stats.Dropped != dropped ||
+		stats.Depth != depth || stats.HighWater != highWater {
+		t.Error("Incorrect stats")
+		t.Log("\tExpected:", enqueued, dequeued, dropped, depth, highWater)
+		t.Log("\tReceived:", stats.Enqueued, stats.Dequeued, stats.Dropped, stats.Depth, stats.HighWater)
+	}
+
+	return stats
+}
+
+func checkCalls(t *testing.T, hook *recorder, want []string) {
+	if !reflect.DeepEqual(hook.calls, want) {
+		t.Error("Incorrect hook calls")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", hook.calls)
+	}
+}
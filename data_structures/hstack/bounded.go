@@ -0,0 +1,102 @@
+package hstack
+
+import (
+	"sync"
+)
+
+// Overflow is the policy that a bounded stack follows when adding items would take it past its
+// maximum depth.
+type Overflow int
+
+const (
+	// Reject makes Add return ErrFull without adding any of the items.
+	Reject Overflow = iota
+	// DropOldest adds the items and then removes items from the bottom of the stack until it is back
+	// within its maximum depth.
+	DropOldest
+	// Block makes Add wait until enough items have been popped for all of the new items to fit. A
+	// stack with this policy is safe for concurrent use. Adding more items at once than the maximum
+	// depth returns ErrFull, because they could never fit.
+	Block
+)
+
+// NewBounded creates a new stack that holds at most depth items. The policy decides what happens when
+// adding items to a full stack.
+func NewBounded(depth int, policy Overflow) (*Stack, error) {
+	if depth <= 0 {
+		return nil, ErrInvalidCount
+	}
+
+	s := New()
+	s.depth = depth
+	s.policy = policy
+
+	switch policy {
+	case Reject, DropOldest:
+	case Block:
+		s.cond = sync.NewCond(new(sync.Mutex))
+	default:
+		return nil, ErrInvalidPolicy
+	}
+
+	return s, nil
+}
+
+// MaxDepth gets the maximum number of items that the stack can hold, or 0 if the stack is unbounded.
+// This returns -1 if the stack hasn't been created yet.
+func (s *Stack) MaxDepth() int {
+	if s == nil {
+		return -1
+	}
+
+	return s.depth
+}
+
+// rejects checks whether or not push would return ErrFull for n items. A stack that blocks only rejects
+// more items than it could ever hold, so this doesn't need the stack's lock. A stack that rejects items
+// when full is not safe for concurrent use, so its depth can't change before the items are pushed.
+func (s *Stack) rejects(n int) bool {
+	switch {
+	case s.depth == 0 || s.policy == DropOldest:
+		return false
+	case s.policy == Block:
+		return n > s.depth
+	default:
+		return len(s.items)+n > s.depth
+	}
+}
+
+// push adds the items to the top of the stack, following the overflow policy if the stack is bounded.
+// The caller must hold the stack's lock.
+func (s *Stack) push(items []interface{}) error {
+	if s.depth == 0 || len(s.items)+len(items) <= s.depth {
+		s.items = append(s.items, items...)
+		return nil
+	}
+
+	switch s.policy {
+	case DropOldest:
+		s.items = append(s.items, items...)
+
+		// Drop the references so the items can be garbage collected. Slicing off the bottom of
+		// the stack leaves the unused room at the front of the array, which will be reclaimed the
+		// next time the stack grows.
+		excess := len(s.items) - s.depth
+		for i := 0; i < excess; i++ {
+			s.items[i] = nil
+		}
+		s.items = s.items[excess:]
+	case Block:
+		if len(items) > s.depth {
+			return ErrFull
+		}
+		for len(s.items)+len(items) > s.depth {
+			s.cond.Wait()
+		}
+		s.items = append(s.items, items...)
+	default:
+		return ErrFull
+	}
+
+	return nil
+}
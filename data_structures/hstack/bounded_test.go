@@ -0,0 +1,369 @@
+package hstack_test
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/snhilde/dsa/data_structures/hstack"
+)
+
+func TestBoundedBadArgs(t *testing.T) {
+	if _, err := hstack.NewBounded(0, hstack.Reject); !errors.Is(err, hstack.ErrInvalidCount) {
+		t.Error("unexpectedly passed NewBounded() test with zero depth")
+		t.Log("\tExpected:", hstack.ErrInvalidCount)
+		t.Log("\tReceived:", err)
+	}
+	if _, err := hstack.NewBounded(5, hstack.Overflow(100)); !errors.Is(err, hstack.ErrInvalidPolicy) {
+		t.Error("unexpectedly passed NewBounded() test with unknown policy")
+		t.Log("\tExpected:", hstack.ErrInvalidPolicy)
+		t.Log("\tReceived:", err)
+	}
+
+	var s *hstack.Stack
+	if n := s.MaxDepth(); n != -1 {
+		t.Error("unexpectedly passed MaxDepth() test with bad pointer")
+	}
+	if n := hstack.New().MaxDepth(); n != 0 {
+		t.Error("Unbounded stack has a maximum depth")
+		t.Log("\tExpected: 0")
+		t.Log("\tReceived:", n)
+	}
+}
+
+func TestBoundedReject(t *testing.T) {
+	s := newBounded(t, 3, hstack.Reject)
+	if n := s.MaxDepth(); n != 3 {
+		t.Error("Incorrect maximum depth")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", n)
+	}
+
+	if err := s.Add(1, 2); err != nil {
+		t.Error(err)
+	}
+
+	// Adding too many items should reject all of them.
+	if err := s.Add(3, 4); !errors.Is(err, hstack.ErrFull) {
+		t.Error("unexpectedly passed Add() test for full stack")
+		t.Log("\tExpected:", hstack.ErrFull)
+		t.Log("\tReceived:", err)
+	}
+	checkString(t, s, "2, 1")
+	checkCount(t, s, 2)
+
+	// Filling the stack exactly should work.
+	if err := s.Add(3); err != nil {
+		t.Error(err)
+	}
+	if err := s.Add(4); !errors.Is(err, hstack.ErrFull) {
+		t.Error("unexpectedly passed Add() test for full stack")
+	}
+	checkString(t, s, "3, 2, 1")
+
+	// Popping should make room again.
+	s.Pop()
+	if err := s.Add(4); err != nil {
+		t.Error(err)
+	}
+	checkString(t, s, "4, 2, 1")
+
+	// Merging should follow the same rules, and the other stack should be untouched on failure.
+	ns := hstack.New()
+	ns.Add("a")
+	if err := s.Merge(ns); !errors.Is(err, hstack.ErrFull) {
+		t.Error("unexpectedly passed Merge() test for full stack")
+	}
+	checkString(t, s, "4, 2, 1")
+	checkString(t, ns, "a")
+
+	// Copies should have the same bounds.
+	cp, err := s.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Add(5); !errors.Is(err, hstack.ErrFull) {
+		t.Error("Copy did not keep bounds")
+	}
+}
+
+func TestBoundedDropOldest(t *testing.T) {
+	s := newBounded(t, 3, hstack.DropOldest)
+
+	s.Add(1, 2, 3)
+	checkString(t, s, "3, 2, 1")
+
+	// Adding more should push the oldest items off the bottom.
+	if err := s.Add(4); err != nil {
+		t.Error(err)
+	}
+	checkString(t, s, "4, 3, 2")
+	checkCount(t, s, 3)
+
+	// Adding more items at once than the stack can hold should keep only the newest.
+	if err := s.Add(5, 6, 7, 8, 9); err != nil {
+		t.Error(err)
+	}
+	checkString(t, s, "9, 8, 7")
+	checkCount(t, s, 3)
+
+	// Keep cycling items through the stack to make sure that it reuses its room.
+	for i := 0; i < 1000; i++ {
+		s.Add(i)
+	}
+	checkString(t, s, "999, 998, 997")
+	if n := s.Capacity(); n > 100 {
+		t.Error("Stack kept growing")
+		t.Log("\tReceived:", n)
+	}
+
+	// Merging should drop the oldest items as well.
+	ns := hstack.New()
+	ns.Add("a", "b")
+	if err := s.Merge(ns); err != nil {
+		t.Error(err)
+	}
+	checkString(t, s, "b, a, 999")
+	checkString(t, ns, "<empty>")
+}
+
+func TestBoundedBlock(t *testing.T) {
+	s := newBounded(t, 2, hstack.Block)
+
+	// Adding more items at once than could ever fit should fail right away.
+	if err := s.Add(1, 2, 3); !errors.Is(err, hstack.ErrFull) {
+		t.Error("unexpectedly passed Add() test for too many items")
+		t.Log("\tExpected:", hstack.ErrFull)
+		t.Log("\tReceived:", err)
+	}
+
+	s.Add(1, 2)
+
+	// This should block until an item is popped.
+	done := make(chan error)
+	go func() {
+		done <- s.Add(3)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Add() did not block on full stack")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if v := s.Pop(); v != 2 {
+		t.Error("Incorrect item popped")
+		t.Log("\tExpected: 2")
+		t.Log("\tReceived:", v)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Add() did not unblock after pop")
+	}
+	checkString(t, s, "3, 1")
+
+	// Clearing should also unblock waiting callers.
+	go func() {
+		done <- s.Add(4)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.Clear()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	checkString(t, s, "4")
+}
+
+func TestBoundedBlockConcurrent(t *testing.T) {
+	s := newBounded(t, 4, hstack.Block)
+
+	const producers = 8
+	const perProducer = 500
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				if err := s.Add(p*perProducer + i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(p)
+	}
+
+	// Pop everything while the producers are running. The stack should never grow past its depth.
+	seen := make(map[interface{}]bool)
+	for len(seen) < producers*perProducer {
+		if n := s.Count(); n > 4 {
+			t.Fatal("Stack grew past its maximum depth:", n)
+		}
+		if v, ok := s.TryPop(); ok {
+			if seen[v] {
+				t.Fatal("Received duplicate item:", v)
+			}
+			seen[v] = true
+		} else {
+			// Give the producers a chance to run.
+			runtime.Gosched()
+		}
+	}
+	wg.Wait()
+	checkCount(t, s, 0)
+}
+
+func TestBoundedMergeConcurrent(t *testing.T) {
+	src := newBounded(t, 16, hstack.Block)
+	dst := newBounded(t, 24, hstack.Block)
+
+	const producers = 4
+	const perProducer = 500
+
+	// Keep adding to the source stack while it is being merged into the destination stack.
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				if err := src.Add(p*perProducer + i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(p)
+	}
+
+	stop := make(chan struct{})
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := dst.Merge(src); err != nil {
+				t.Error(err)
+				return
+			}
+			runtime.Gosched()
+		}
+	}()
+
+	// Every item should come out of the destination stack exactly once.
+	seen := make(map[interface{}]bool)
+	for len(seen) < producers*perProducer {
+		v, ok := dst.TryPop()
+		if !ok {
+			runtime.Gosched()
+			continue
+		}
+		if v == nil || seen[v] {
+			t.Fatal("Received bad or duplicate item:", v)
+		}
+		seen[v] = true
+	}
+	close(stop)
+	<-merged
+	wg.Wait()
+
+	checkCount(t, src, 0)
+	checkCount(t, dst, 0)
+}
+
+func TestBoundedMergeBlocked(t *testing.T) {
+	src := newBounded(t, 4, hstack.Block)
+	dst := newBounded(t, 2, hstack.Block)
+
+	// Fill the destination stack so that the merge has to wait for room.
+	dst.Add(1, 2)
+	src.Add("a")
+	done := make(chan error)
+	go func() {
+		done <- dst.Merge(src)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// Items added to the source stack while the merge is waiting are not part of the merge.
+	if err := src.Add("b"); err != nil {
+		t.Error(err)
+	}
+	dst.Pop()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	checkString(t, dst, "a, 1")
+	checkString(t, src, "b")
+}
+
+func TestBoundedMergeRejected(t *testing.T) {
+	src := newBounded(t, 4, hstack.Block)
+	dst := newBounded(t, 2, hstack.Block)
+
+	// Keep the source stack full while merging it into a stack that is too small to ever take all of
+	// its items. The source stack must be left alone, and never go past its maximum depth.
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			src.Add(i)
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		err := dst.Merge(src)
+		if n := src.Count(); n > 4 {
+			t.Error("Source stack went past its maximum depth")
+			t.Log("\tExpected: <= 4")
+			t.Log("\tReceived:", n)
+			break
+		}
+		if err != nil && !errors.Is(err, hstack.ErrFull) {
+			t.Error(err)
+		}
+		if errors.Is(err, hstack.ErrFull) && dst.Count() != 0 {
+			t.Error("Destination stack changed after failed merge")
+		}
+		dst.Clear()
+		src.Pop()
+		runtime.Gosched()
+	}
+
+	// Let the producer finish.
+	close(stop)
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			src.Clear()
+			runtime.Gosched()
+		}
+	}
+}
+
+func newBounded(t *testing.T, depth int, policy hstack.Overflow) *hstack.Stack {
+	s, err := hstack.NewBounded(depth, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
@@ -0,0 +1,163 @@
+package hstack
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrBadConcurrentStack is returned when trying to use an invalid concurrent stack.
+var ErrBadConcurrentStack = fmt.Errorf("must create stack with NewConcurrent() first")
+
+// ConcurrentStack is a lock-free stack that is safe for use by many goroutines at once. It is a
+// Treiber stack: the top of the stack is a single pointer, and every change swaps in a new top with
+// an atomic compare-and-swap, retrying if another goroutine got there first.
+//
+// Lock-free stacks in languages with manual memory management must guard against the ABA problem,
+// where the top node is popped and freed and its memory is reused for a new node between one
+// goroutine reading the top and swapping it out. That can't happen here. Every push allocates a new
+// node, nodes are never reused, and the garbage collector won't reclaim a node while any goroutine
+// still holds a pointer to it. So if the top pointer still matches, it is still the same node with
+// the same next node.
+type ConcurrentStack struct {
+	// count must be first so that it is 64-bit aligned on 32-bit platforms.
+	count int64
+	head  unsafe.Pointer // *cnode
+}
+
+// cnode is an internal type for an individual node in the concurrent stack. Nodes are never changed
+// after they are pushed.
+type cnode struct {
+	item interface{}
+	next *cnode
+}
+
+// NewConcurrent creates a new lock-free stack.
+func NewConcurrent() *ConcurrentStack {
+	return new(ConcurrentStack)
+}
+
+// Push adds one or more items to the top of the stack. Items are pushed in order, so the last
+// argument will be the first item returned with Pop. All of the items are pushed at once, so other
+// goroutines will never see only some of them on the stack.
+func (s *ConcurrentStack) Push(items ...interface{}) error {
+	if s == nil {
+		return ErrBadConcurrentStack
+	} else if len(items) == 0 {
+		return nil
+	}
+
+	// Build the chain of new nodes, from the top down.
+	var top, bottom *cnode
+	for _, item := range items {
+		top = &cnode{item: item, next: top}
+		if bottom == nil {
+			bottom = top
+		}
+	}
+
+	for {
+		head := atomic.LoadPointer(&s.head)
+		bottom.next = (*cnode)(head)
+		if atomic.CompareAndSwapPointer(&s.head, head, unsafe.Pointer(top)) {
+			break
+		}
+	}
+	atomic.AddInt64(&s.count, int64(len(items)))
+
+	return nil
+}
+
+// Pop removes the top item from the stack and returns its value. The second return value is false if
+// the stack is empty or hasn't been created yet.
+func (s *ConcurrentStack) Pop() (interface{}, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	for {
+		head := atomic.LoadPointer(&s.head)
+		if head == nil {
+			return nil, false
+		}
+
+		node := (*cnode)(head)
+		if atomic.CompareAndSwapPointer(&s.head, head, unsafe.Pointer(node.next)) {
+			atomic.AddInt64(&s.count, -1)
+			return node.item, true
+		}
+	}
+}
+
+// Peek returns the top item on the stack without removing it. The second return value is false if the
+// stack is empty or hasn't been created yet.
+func (s *ConcurrentStack) Peek() (interface{}, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	head := atomic.LoadPointer(&s.head)
+	if head == nil {
+		return nil, false
+	}
+
+	return (*cnode)(head).item, true
+}
+
+// Count gets the current number of items in the stack. If other goroutines are pushing and popping at
+// the same time, then the count might be slightly out of date by the time it is returned.
+func (s *ConcurrentStack) Count() int {
+	if s == nil {
+		return -1
+	}
+
+	// The count is updated just after the stack changes, so it can briefly dip below 0 if an item is
+	// popped before the push that added it has updated the count.
+	n := atomic.LoadInt64(&s.count)
+	if n < 0 {
+		return 0
+	}
+
+	return int(n)
+}
+
+// Drain removes every item from the stack at once and returns them, with the top item first.
+func (s *ConcurrentStack) Drain() []interface{} {
+	if s == nil {
+		return nil
+	}
+
+	head := (*cnode)(atomic.SwapPointer(&s.head, nil))
+
+	var items []interface{}
+	for node := head; node != nil; node = node.next {
+		items = append(items, node.item)
+	}
+	atomic.AddInt64(&s.count, -int64(len(items)))
+
+	return items
+}
+
+// String displays the stack's contents, from the top to the bottom. This is a snapshot of the stack at
+// the moment that it was read.
+func (s *ConcurrentStack) String() string {
+	if s == nil {
+		return "<nil>"
+	}
+
+	head := (*cnode)(atomic.LoadPointer(&s.head))
+	if head == nil {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for node := head; node != nil; node = node.next {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", node.item))
+	}
+
+	return builder.String()
+}
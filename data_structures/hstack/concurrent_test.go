@@ -0,0 +1,198 @@
+package hstack_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hstack"
+)
+
+func TestConcurrentBadPtr(t *testing.T) {
+	var s *hstack.ConcurrentStack
+
+	if err := s.Push(1); !errors.Is(err, hstack.ErrBadConcurrentStack) {
+		t.Error("unexpectedly passed Push() test with bad pointer")
+	}
+	if _, ok := s.Pop(); ok {
+		t.Error("unexpectedly passed Pop() test with bad pointer")
+	}
+	if _, ok := s.Peek(); ok {
+		t.Error("unexpectedly passed Peek() test with bad pointer")
+	}
+	if n := s.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+	if v := s.Drain(); v != nil {
+		t.Error("unexpectedly passed Drain() test with bad pointer")
+	}
+	if v := s.String(); v != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", v)
+	}
+}
+
+func TestConcurrentSequential(t *testing.T) {
+	s := hstack.NewConcurrent()
+	checkConcurrent(t, s, "<empty>", 0)
+	if _, ok := s.Pop(); ok {
+		t.Error("unexpectedly popped item from empty stack")
+	}
+
+	s.Push(1, 2, 3)
+	s.Push(nil)
+	s.Push()
+	checkConcurrent(t, s, "<nil>, 3, 2, 1", 4)
+
+	// A stored nil should be told apart from an empty stack.
+	if v, ok := s.Peek(); v != nil || !ok {
+		t.Error("Incorrect top item")
+		t.Log("\tExpected: <nil> true")
+		t.Log("\tReceived:", v, ok)
+	}
+	if v, ok := s.Pop(); v != nil || !ok {
+		t.Error("Did not pop nil item")
+	}
+	if v, ok := s.Pop(); v != 3 || !ok {
+		t.Error("Incorrect item popped")
+		t.Log("\tExpected: 3 true")
+		t.Log("\tReceived:", v, ok)
+	}
+	checkConcurrent(t, s, "2, 1", 2)
+
+	s.Push("a")
+	if v := s.Drain(); !reflect.DeepEqual(v, []interface{}{"a", 2, 1}) {
+		t.Error("Incorrect items drained")
+		t.Log("\tExpected: [a 2 1]")
+		t.Log("\tReceived:", v)
+	}
+	checkConcurrent(t, s, "<empty>", 0)
+}
+
+func TestConcurrentStress(t *testing.T) {
+	s := hstack.NewConcurrent()
+
+	const workers = 8
+	const perWorker = 5000
+
+	// Every worker pushes its own items and pops whatever it finds, so that pushes and pops are
+	// constantly racing against each other.
+	popped := make([][]interface{}, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				if err := s.Push(w*perWorker + i); err != nil {
+					t.Error(err)
+					return
+				}
+				pops := 1
+				if i%3 == 0 {
+					// Push some items in a batch as well.
+					s.Push(-1, -1)
+					pops += 2
+				}
+				for j := 0; j < pops; j++ {
+					if v, ok := s.Pop(); ok && v != -1 {
+						popped[w] = append(popped[w], v)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Collect what's left. Every item should have been seen exactly once, and the batches should have
+	// cancelled out.
+	seen := make(map[interface{}]bool)
+	for _, items := range append(popped, s.Drain()) {
+		for _, v := range items {
+			if v == -1 {
+				continue
+			}
+			if seen[v] {
+				t.Fatal("Received duplicate item:", v)
+			}
+			seen[v] = true
+		}
+	}
+	if len(seen) != workers*perWorker {
+		t.Error("Incorrect number of items")
+		t.Log("\tExpected:", workers*perWorker)
+		t.Log("\tReceived:", len(seen))
+	}
+	checkConcurrent(t, s, "<empty>", 0)
+}
+
+func TestConcurrentOrder(t *testing.T) {
+	// Items pushed by a single goroutine should come back out in reverse order, even while other
+	// goroutines are using the stack.
+	s := hstack.NewConcurrent()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				s.Push("noise")
+				s.Pop()
+			}
+		}()
+	}
+
+	s.Push(1, 2, 3)
+	wg.Wait()
+
+	var got []interface{}
+	for _, v := range s.Drain() {
+		if v != "noise" {
+			got = append(got, v)
+		}
+	}
+	if !reflect.DeepEqual(got, []interface{}{3, 2, 1}) {
+		t.Error("Incorrect order")
+		t.Log("\tExpected: [3 2 1]")
+		t.Log("\tReceived:", got)
+	}
+}
+
+// The benchmarks below compare the lock-free stack against a Stack that blocks on overflow, which is
+// protected by a mutex.
+
+func BenchmarkConcurrentPushPop(b *testing.B) {
+	s := hstack.NewConcurrent()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.Push(1)
+			s.Pop()
+		}
+	})
+}
+
+func BenchmarkMutexPushPop(b *testing.B) {
+	s, _ := hstack.NewBounded(1<<30, hstack.Block)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.Add(1)
+			s.TryPop()
+		}
+	})
+}
+
+func checkConcurrent(t *testing.T, s *hstack.ConcurrentStack, want string, count int) {
+	if s.String() != want {
+		t.Error("stack contents are incorrect")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", s)
+	}
+	if n := s.Count(); n != count {
+		t.Error("Incorrect count")
+		t.Log("\tExpected:", count)
+		t.Log("\tReceived:", n)
+	}
+}
@@ -0,0 +1,321 @@
+package hstack
+
+import (
+	"fmt"
+)
+
+var (
+	// ErrBadHistory is returned when trying to use an invalid history.
+	ErrBadHistory = fmt.Errorf("must create history with NewHistory() first")
+	// ErrMissingAction is returned when trying to do a nil action.
+	ErrMissingAction = fmt.Errorf("missing action")
+	// ErrNothingToUndo is returned when there are no actions left to undo.
+	ErrNothingToUndo = fmt.Errorf("nothing to undo")
+	// ErrNothingToRedo is returned when there are no actions left to redo.
+	ErrNothingToRedo = fmt.Errorf("nothing to redo")
+	// ErrInTransaction is returned when an operation isn't allowed while a transaction is open.
+	ErrInTransaction = fmt.Errorf("transaction in progress")
+	// ErrNoTransaction is returned when trying to end a transaction that was never started.
+	ErrNoTransaction = fmt.Errorf("no transaction in progress")
+)
+
+// Action is a change that can be applied and reverted. Undo should completely reverse the effects of
+// Do, so that calling Do again afterward gives the same result as the first time.
+type Action interface {
+	Do() error
+	Undo() error
+}
+
+// NewAction creates an Action out of a pair of functions.
+func NewAction(do, undo func() error) Action {
+	return funcAction{do: do, undo: undo}
+}
+
+// funcAction is an internal type for an action built from a pair of functions.
+type funcAction struct {
+	do   func() error
+	undo func() error
+}
+
+func (a funcAction) Do() error {
+	return a.do()
+}
+
+func (a funcAction) Undo() error {
+	return a.undo()
+}
+
+// History keeps track of actions so that they can be undone and redone. It uses two stacks: one for
+// the actions that have been done and can be undone, and one for the actions that have been undone
+// and can be redone. Doing a new action clears the redo stack.
+//
+// Actions can be grouped into a transaction, which is then undone and redone as a single action.
+type History struct {
+	undo *Stack
+	redo *Stack
+
+	// tx holds the actions of the open transaction, or nil if there isn't one.
+	tx *group
+}
+
+// HistorySnapshot is a saved copy of a history's undo and redo stacks. It can be passed to Restore
+// to return the history to that point.
+type HistorySnapshot struct {
+	undo *Stack
+	redo *Stack
+}
+
+// NewHistory creates a new history that remembers at most size actions. Once the history is full,
+// doing a new action forgets the oldest one. A size of 0 means that the history can grow without
+// limit.
+func NewHistory(size int) (*History, error) {
+	if size < 0 {
+		return nil, ErrInvalidCount
+	}
+
+	h := new(History)
+	if size == 0 {
+		h.undo = New()
+	} else {
+		h.undo, _ = NewBounded(size, DropOldest)
+	}
+	h.redo = New()
+
+	return h, nil
+}
+
+// Do applies the action and records it so that it can be undone. If the action fails, then nothing is
+// recorded and the action's error is returned. If the action can't be recorded, then it is undone and
+// the error is returned. If a transaction is open, then the action becomes part of the transaction.
+func (h *History) Do(action Action) error {
+	if h == nil || h.undo == nil {
+		return ErrBadHistory
+	} else if action == nil {
+		return ErrMissingAction
+	}
+
+	if err := action.Do(); err != nil {
+		return err
+	}
+
+	if h.tx != nil {
+		h.tx.actions = append(h.tx.actions, action)
+		return nil
+	}
+
+	if err := h.undo.Add(action); err != nil {
+		action.Undo()
+		return err
+	}
+
+	return h.redo.Clear()
+}
+
+// Undo reverts the most recent action. If the action fails to revert, then the history is left
+// unchanged and the action's error is returned.
+func (h *History) Undo() error {
+	if h == nil || h.undo == nil {
+		return ErrBadHistory
+	} else if h.tx != nil {
+		return ErrInTransaction
+	}
+
+	return move(h.undo, h.redo, ErrNothingToUndo, Action.Undo)
+}
+
+// Redo applies the most recently undone action again. If the action fails, then the history is left
+// unchanged and the action's error is returned.
+func (h *History) Redo() error {
+	if h == nil || h.undo == nil {
+		return ErrBadHistory
+	} else if h.tx != nil {
+		return ErrInTransaction
+	}
+
+	return move(h.redo, h.undo, ErrNothingToRedo, Action.Do)
+}
+
+// UndoCount gets the number of actions that can be undone.
+func (h *History) UndoCount() int {
+	if h == nil || h.undo == nil {
+		return -1
+	}
+
+	return h.undo.Count()
+}
+
+// RedoCount gets the number of actions that can be redone.
+func (h *History) RedoCount() int {
+	if h == nil || h.undo == nil {
+		return -1
+	}
+
+	return h.redo.Count()
+}
+
+// Begin starts a transaction. Every action done until Commit is called is grouped together and undone
+// and redone as one. Transactions cannot be nested.
+func (h *History) Begin() error {
+	if h == nil || h.undo == nil {
+		return ErrBadHistory
+	} else if h.tx != nil {
+		return ErrInTransaction
+	}
+
+	h.tx = new(group)
+
+	return nil
+}
+
+// Commit ends the open transaction and records its actions as a single action. If no actions were
+// done during the transaction, then nothing is recorded.
+func (h *History) Commit() error {
+	if h == nil || h.undo == nil {
+		return ErrBadHistory
+	} else if h.tx == nil {
+		return ErrNoTransaction
+	}
+
+	if len(h.tx.actions) > 0 {
+		if err := h.undo.Add(h.tx); err != nil {
+			return err
+		}
+		if err := h.redo.Clear(); err != nil {
+			return err
+		}
+	}
+	h.tx = nil
+
+	return nil
+}
+
+// Rollback ends the open transaction by undoing all of its actions, most recent first. Nothing is
+// recorded. If an action fails to revert, then the transaction stays open with the actions that have
+// not been undone yet, and the action's error is returned.
+func (h *History) Rollback() error {
+	if h == nil || h.undo == nil {
+		return ErrBadHistory
+	} else if h.tx == nil {
+		return ErrNoTransaction
+	}
+
+	for i := len(h.tx.actions) - 1; i >= 0; i-- {
+		if err := h.tx.actions[i].Undo(); err != nil {
+			return err
+		}
+		h.tx.actions = h.tx.actions[:i]
+	}
+	h.tx = nil
+
+	return nil
+}
+
+// Snapshot saves the current state of the undo and redo stacks. This does not save the state of
+// whatever the actions change; the caller is responsible for that.
+func (h *History) Snapshot() (*HistorySnapshot, error) {
+	if h == nil || h.undo == nil {
+		return nil, ErrBadHistory
+	} else if h.tx != nil {
+		return nil, ErrInTransaction
+	}
+
+	undo, _ := h.undo.Copy()
+	redo, _ := h.redo.Copy()
+
+	return &HistorySnapshot{undo: undo, redo: redo}, nil
+}
+
+// Restore replaces the undo and redo stacks with the ones saved in the snapshot. No actions are done
+// or undone, so the caller must also restore whatever the actions change to the same point. The
+// snapshot can be restored more than once.
+func (h *History) Restore(snap *HistorySnapshot) error {
+	if h == nil || h.undo == nil {
+		return ErrBadHistory
+	} else if snap == nil || snap.undo == nil {
+		return fmt.Errorf("missing snapshot")
+	} else if h.tx != nil {
+		return ErrInTransaction
+	}
+
+	// Copy the stacks again so that changes to this history don't affect the snapshot.
+	h.undo, _ = snap.undo.Copy()
+	h.redo, _ = snap.redo.Copy()
+
+	return nil
+}
+
+// Clear forgets all recorded actions and discards any open transaction without undoing it.
+func (h *History) Clear() error {
+	if h == nil || h.undo == nil {
+		return ErrBadHistory
+	}
+
+	if err := h.undo.Clear(); err != nil {
+		return err
+	}
+	if err := h.redo.Clear(); err != nil {
+		return err
+	}
+	h.tx = nil
+
+	return nil
+}
+
+// move pops the top action from one stack, applies fn to it, and pushes it onto the other stack. If fn
+// fails, then the action is put back and fn's error is returned. Any error from pushing the action is
+// returned as well.
+func move(from, to *Stack, empty error, fn func(Action) error) error {
+	v, ok := from.TryPop()
+	if !ok {
+		return empty
+	}
+
+	action := v.(Action)
+	if err := fn(action); err != nil {
+		if perr := from.Add(action); perr != nil {
+			return perr
+		}
+		return err
+	}
+
+	return to.Add(action)
+}
+
+// group is an internal type for the actions of a transaction, which act as a single action.
+type group struct {
+	actions []Action
+}
+
+// Do applies every action in the group in order. If one fails, then the ones before it are undone.
+func (g *group) Do() error {
+	for i, action := range g.actions {
+		if err := action.Do(); err != nil {
+			g.revert(i)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Undo reverts every action in the group, most recent first. If one fails, then the ones after it are
+// done again.
+func (g *group) Undo() error {
+	for i := len(g.actions) - 1; i >= 0; i-- {
+		if err := g.actions[i].Undo(); err != nil {
+			for _, action := range g.actions[i+1:] {
+				action.Do()
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revert undoes the first n actions in the group, most recent first.
+func (g *group) revert(n int) {
+	for i := n - 1; i >= 0; i-- {
+		g.actions[i].Undo()
+	}
+}
@@ -0,0 +1,338 @@
+package hstack_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hstack"
+)
+
+// editor is a tiny text buffer used to test History.
+type editor struct {
+	text string
+}
+
+// insert returns an action that appends s to the editor's text.
+func (e *editor) insert(s string) hstack.Action {
+	return hstack.NewAction(
+		func() error {
+			e.text += s
+			return nil
+		},
+		func() error {
+			if !strings.HasSuffix(e.text, s) {
+				return fmt.Errorf("text does not end with %q", s)
+			}
+			e.text = strings.TrimSuffix(e.text, s)
+			return nil
+		},
+	)
+}
+
+func TestHistoryBadPtr(t *testing.T) {
+	checkBadHistory(t, nil)
+
+	// A history that wasn't created with NewHistory() should be rejected the same way.
+	checkBadHistory(t, new(hstack.History))
+}
+
+func TestHistoryBadArgs(t *testing.T) {
+	if _, err := hstack.NewHistory(-1); !errors.Is(err, hstack.ErrInvalidCount) {
+		t.Error("unexpectedly passed NewHistory() test with negative size")
+	}
+
+	h := newHistory(t, 0)
+	if err := h.Do(nil); !errors.Is(err, hstack.ErrMissingAction) {
+		t.Error("unexpectedly passed Do() test with missing action")
+	}
+	if err := h.Undo(); !errors.Is(err, hstack.ErrNothingToUndo) {
+		t.Error("unexpectedly passed Undo() test for empty history")
+	}
+	if err := h.Redo(); !errors.Is(err, hstack.ErrNothingToRedo) {
+		t.Error("unexpectedly passed Redo() test for empty history")
+	}
+	if err := h.Commit(); !errors.Is(err, hstack.ErrNoTransaction) {
+		t.Error("unexpectedly passed Commit() test without transaction")
+	}
+	if err := h.Rollback(); !errors.Is(err, hstack.ErrNoTransaction) {
+		t.Error("unexpectedly passed Rollback() test without transaction")
+	}
+	if err := h.Restore(nil); err == nil {
+		t.Error("unexpectedly passed Restore() test with missing snapshot")
+	}
+	if err := h.Restore(new(hstack.HistorySnapshot)); err == nil {
+		t.Error("unexpectedly passed Restore() test with empty snapshot")
+	}
+	if err := h.Do(new(editor).insert("a")); err != nil {
+		t.Error("History is unusable after bad restore:", err)
+	}
+}
+
+func TestHistoryUndoRedo(t *testing.T) {
+	h := newHistory(t, 0)
+	e := new(editor)
+
+	for _, s := range []string{"a", "b", "c"} {
+		if err := h.Do(e.insert(s)); err != nil {
+			t.Error(err)
+		}
+	}
+	checkHistory(t, h, e, "abc", 3, 0)
+
+	if err := h.Undo(); err != nil {
+		t.Error(err)
+	}
+	if err := h.Undo(); err != nil {
+		t.Error(err)
+	}
+	checkHistory(t, h, e, "a", 1, 2)
+
+	if err := h.Redo(); err != nil {
+		t.Error(err)
+	}
+	checkHistory(t, h, e, "ab", 2, 1)
+
+	// Doing a new action should clear everything that could be redone.
+	h.Do(e.insert("x"))
+	checkHistory(t, h, e, "abx", 3, 0)
+	if err := h.Redo(); !errors.Is(err, hstack.ErrNothingToRedo) {
+		t.Error("unexpectedly passed Redo() test after new action")
+	}
+
+	// Undo everything.
+	for h.UndoCount() > 0 {
+		if err := h.Undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkHistory(t, h, e, "", 0, 3)
+}
+
+func TestHistoryFailures(t *testing.T) {
+	h := newHistory(t, 0)
+	e := new(editor)
+
+	// A failed action should not be recorded.
+	fail := hstack.NewAction(
+		func() error { return fmt.Errorf("failed") },
+		func() error { return nil },
+	)
+	if err := h.Do(fail); err == nil {
+		t.Error("unexpectedly passed Do() test with failing action")
+	}
+	checkHistory(t, h, e, "", 0, 0)
+
+	// If an action fails to revert, then the history should not change.
+	h.Do(e.insert("abc"))
+	e.text = "changed"
+	if err := h.Undo(); err == nil {
+		t.Error("unexpectedly passed Undo() test with failing action")
+	}
+	checkHistory(t, h, e, "changed", 1, 0)
+
+	e.text = "abc"
+	if err := h.Undo(); err != nil {
+		t.Error(err)
+	}
+	checkHistory(t, h, e, "", 0, 1)
+}
+
+func TestHistorySize(t *testing.T) {
+	h := newHistory(t, 3)
+	e := new(editor)
+
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		h.Do(e.insert(s))
+	}
+	checkHistory(t, h, e, "abcde", 3, 0)
+
+	// Only the most recent actions should be remembered.
+	for h.UndoCount() > 0 {
+		h.Undo()
+	}
+	checkHistory(t, h, e, "ab", 0, 3)
+	if err := h.Undo(); !errors.Is(err, hstack.ErrNothingToUndo) {
+		t.Error("unexpectedly passed Undo() test past history size")
+	}
+
+	for h.RedoCount() > 0 {
+		h.Redo()
+	}
+	checkHistory(t, h, e, "abcde", 3, 0)
+}
+
+func TestHistoryTransaction(t *testing.T) {
+	h := newHistory(t, 0)
+	e := new(editor)
+
+	h.Do(e.insert("a"))
+
+	if err := h.Begin(); err != nil {
+		t.Error(err)
+	}
+	if err := h.Begin(); !errors.Is(err, hstack.ErrInTransaction) {
+		t.Error("unexpectedly passed Begin() test with open transaction")
+	}
+	h.Do(e.insert("b"))
+	h.Do(e.insert("c"))
+	h.Do(e.insert("d"))
+
+	// Nothing can be undone, redone, or saved while the transaction is open.
+	if err := h.Undo(); !errors.Is(err, hstack.ErrInTransaction) {
+		t.Error("unexpectedly passed Undo() test with open transaction")
+	}
+	if err := h.Redo(); !errors.Is(err, hstack.ErrInTransaction) {
+		t.Error("unexpectedly passed Redo() test with open transaction")
+	}
+	if _, err := h.Snapshot(); !errors.Is(err, hstack.ErrInTransaction) {
+		t.Error("unexpectedly passed Snapshot() test with open transaction")
+	}
+
+	if err := h.Commit(); err != nil {
+		t.Error(err)
+	}
+	checkHistory(t, h, e, "abcd", 2, 0)
+
+	// The whole transaction should be undone and redone at once.
+	h.Undo()
+	checkHistory(t, h, e, "a", 1, 1)
+	h.Redo()
+	checkHistory(t, h, e, "abcd", 2, 0)
+
+	// An empty transaction should not be recorded.
+	h.Begin()
+	h.Commit()
+	checkHistory(t, h, e, "abcd", 2, 0)
+
+	// Rolling back should undo the transaction's actions and record nothing.
+	h.Begin()
+	h.Do(e.insert("x"))
+	h.Do(e.insert("y"))
+	checkHistory(t, h, e, "abcdxy", 2, 0)
+	if err := h.Rollback(); err != nil {
+		t.Error(err)
+	}
+	checkHistory(t, h, e, "abcd", 2, 0)
+
+	// If part of a transaction fails to revert, then the parts that were reverted should be done
+	// again so that the transaction stays whole.
+	h.Begin()
+	h.Do(e.insert("1"))
+	h.Do(e.insert("2"))
+	h.Commit()
+	e.text = "abcd1-2"
+	if err := h.Undo(); err == nil {
+		t.Error("unexpectedly passed Undo() test with failing transaction")
+	}
+	checkHistory(t, h, e, "abcd1-2", 3, 0)
+}
+
+func TestHistorySnapshot(t *testing.T) {
+	h := newHistory(t, 0)
+	e := new(editor)
+
+	h.Do(e.insert("a"))
+	h.Do(e.insert("b"))
+	h.Undo()
+	checkHistory(t, h, e, "a", 1, 1)
+
+	snap, err := h.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := e.text
+
+	// Change the history in several ways, and then go back to the snapshot.
+	h.Do(e.insert("c"))
+	h.Do(e.insert("d"))
+	checkHistory(t, h, e, "acd", 3, 0)
+
+	if err := h.Restore(snap); err != nil {
+		t.Error(err)
+	}
+	e.text = saved
+	checkHistory(t, h, e, "a", 1, 1)
+	h.Redo()
+	checkHistory(t, h, e, "ab", 2, 0)
+
+	// The snapshot should be reusable.
+	h.Restore(snap)
+	e.text = saved
+	checkHistory(t, h, e, "a", 1, 1)
+	h.Undo()
+	checkHistory(t, h, e, "", 0, 2)
+
+	// Test clearing.
+	h.Clear()
+	checkHistory(t, h, e, "", 0, 0)
+}
+
+func newHistory(t *testing.T, size int) *hstack.History {
+	h, err := hstack.NewHistory(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return h
+}
+
+func checkHistory(t *testing.T, h *hstack.History, e *editor, text string, undo int, redo int) {
+	if e.text != text {
+		t.Error("Incorrect text")
+		t.Log("\tExpected:", text)
+		t.Log("\tReceived:", e.text)
+	}
+	if n := h.UndoCount(); n != undo {
+		t.Error("Incorrect undo count")
+		t.Log("\tExpected:", undo)
+		t.Log("\tReceived:", n)
+	}
+	if n := h.RedoCount(); n != redo {
+		t.Error("Incorrect redo count")
+		t.Log("\tExpected:", redo)
+		t.Log("\tReceived:", n)
+	}
+}
+
+func checkBadHistory(t *testing.T, h *hstack.History) {
+	e := new(editor)
+
+	if err := h.Do(e.insert("a")); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Do() test with bad pointer")
+	}
+	if err := h.Undo(); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Undo() test with bad pointer")
+	}
+	if err := h.Redo(); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Redo() test with bad pointer")
+	}
+	if n := h.UndoCount(); n != -1 {
+		t.Error("unexpectedly passed UndoCount() test with bad pointer")
+	}
+	if n := h.RedoCount(); n != -1 {
+		t.Error("unexpectedly passed RedoCount() test with bad pointer")
+	}
+	if err := h.Begin(); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Begin() test with bad pointer")
+	}
+	if err := h.Commit(); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Commit() test with bad pointer")
+	}
+	if err := h.Rollback(); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Rollback() test with bad pointer")
+	}
+	if _, err := h.Snapshot(); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Snapshot() test with bad pointer")
+	}
+	if err := h.Restore(nil); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Restore() test with bad pointer")
+	}
+	if err := h.Clear(); !errors.Is(err, hstack.ErrBadHistory) {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+	if e.text != "" {
+		t.Error("Action was unexpectedly done")
+	}
+}
@@ -3,58 +3,165 @@ package hstack
 
 import (
 	"fmt"
-
-	"github.com/snhilde/dsa/data_structures/hlist"
+	"strings"
+	"sync"
 )
 
-// This is the standard error message when trying to use an invalid stack.
-var errBadStack = fmt.Errorf("must create stack with New() first")
+// These are the errors that this package returns. They can be checked with errors.Is.
+var (
+	// ErrBadStack is returned when trying to use an invalid stack.
+	ErrBadStack = fmt.Errorf("must create stack with New() first")
+	// ErrEmpty is returned when trying to get an item from an empty stack.
+	ErrEmpty = fmt.Errorf("stack is empty")
+	// ErrTooFew is returned when asking for more items than the stack has.
+	ErrTooFew = fmt.Errorf("not enough items in stack")
+	// ErrInvalidCount is returned when a number of items is negative.
+	ErrInvalidCount = fmt.Errorf("invalid count")
+	// ErrSelfAdd is returned when trying to add a stack to itself.
+	ErrSelfAdd = fmt.Errorf("can't add stack to itself")
+	// ErrFull is returned when adding items would take a bounded stack past its maximum depth.
+	ErrFull = fmt.Errorf("stack is full")
+	// ErrInvalidPolicy is returned when creating a bounded stack with an unknown overflow policy.
+	ErrInvalidPolicy = fmt.Errorf("invalid overflow policy")
+)
 
 // Stack is the main type for this package. It holds the internal information about the stack.
 type Stack struct {
-	list *hlist.List
+	// The items are stored bottom to top, so the top of the stack is at the end of the slice.
+	items []interface{}
+
+	// These are only used by bounded stacks. A depth of 0 means that the stack is unbounded.
+	depth  int
+	policy Overflow
+
+	// cond is only set for stacks that block on overflow. Its lock guards all of the fields above.
+	cond *sync.Cond
 }
 
 // New creates a new stack.
 func New() *Stack {
 	s := new(Stack)
-	s.list = hlist.New()
 	return s
 }
 
 // Add adds one or more new items to the top of the stack. Items are pushed in order, so the first
 // argument is pushed first, and the second, second, and so on. This means that the last argument to
 // Add will be the first item returned with Pop.
+//
+// If the stack is bounded, then the stack's overflow policy decides what happens when the items
+// don't fit. See Overflow for more information.
 func (s *Stack) Add(items ...interface{}) error {
 	if s == nil {
-		return errBadStack
+		return ErrBadStack
 	}
 
 	// To prevent infinite recursion, make sure that none of the items is this stack itself.
 	for _, v := range items {
 		if t, ok := v.(*Stack); ok {
 			if s.Same(t) {
-				return fmt.Errorf("can't add stack to itself")
+				return ErrSelfAdd
 			}
 		}
 	}
 
-	// Reverse the items so they are added in the correct order.
-	length := len(items)
-	for i := 0; i < length/2; i++ {
-		items[i], items[length-1-i] = items[length-1-i], items[i]
-	}
+	s.lock()
+	defer s.unlock()
 
-	return s.list.Insert(0, items...)
+	return s.push(items)
 }
 
-// Pop removes the top item from the stack and returns its value.
+// Pop removes the top item from the stack and returns its value. This returns nil if the stack is
+// empty. To tell an empty stack apart from a nil item, use TryPop instead.
 func (s *Stack) Pop() interface{} {
+	item, _ := s.TryPop()
+
+	return item
+}
+
+// TryPop removes the top item from the stack and returns its value. The second return value is false
+// if the stack is empty or hasn't been created yet.
+func (s *Stack) TryPop() (interface{}, bool) {
 	if s == nil {
+		return nil, false
+	}
+
+	s.lock()
+	defer s.unlock()
+
+	if len(s.items) == 0 {
+		return nil, false
+	}
+
+	// Take the item directly instead of going through popN, so that popping doesn't allocate.
+	item := s.items[len(s.items)-1]
+	s.drop(1)
+
+	return item, true
+}
+
+// PopN removes up to n items from the top of the stack and returns them, with the top item first. If
+// the stack has fewer than n items, then all of them are removed.
+func (s *Stack) PopN(n int) []interface{} {
+	if s == nil || n <= 0 {
 		return nil
 	}
 
-	return s.list.Remove(0)
+	s.lock()
+	defer s.unlock()
+
+	if n > len(s.items) {
+		n = len(s.items)
+	}
+
+	return s.popN(n)
+}
+
+// Drain removes every item from the stack and returns them, with the top item first.
+func (s *Stack) Drain() []interface{} {
+	if s == nil {
+		return nil
+	}
+
+	s.lock()
+	defer s.unlock()
+
+	return s.popN(len(s.items))
+}
+
+// Peek returns the top item on the stack without removing it. This returns ErrEmpty if the stack has
+// no items.
+func (s *Stack) Peek() (interface{}, error) {
+	if s == nil {
+		return nil, ErrBadStack
+	}
+
+	s.lock()
+	defer s.unlock()
+
+	if len(s.items) == 0 {
+		return nil, ErrEmpty
+	}
+
+	return s.items[len(s.items)-1], nil
+}
+
+// PeekN returns the top n items on the stack without removing them, with the top item first. This
+// returns ErrTooFew if the stack has fewer than n items.
+func (s *Stack) PeekN(n int) ([]interface{}, error) {
+	if s == nil {
+		return nil, ErrBadStack
+	} else if n < 0 {
+		return nil, ErrInvalidCount
+	}
+
+	s.lock()
+	defer s.unlock()
+
+	if n > len(s.items) {
+		return nil, ErrTooFew
+	}
+
+	return s.topN(n), nil
 }
 
 // Count gets the current number of items in the stack.
@@ -63,31 +170,99 @@ func (s *Stack) Count() int {
 		return -1
 	}
 
-	return s.list.Length()
+	s.lock()
+	defer s.unlock()
+
+	return len(s.items)
 }
 
-// Copy makes an exact copy of the stack.
-func (s *Stack) Copy() (*Stack, error) {
+// Capacity gets the number of items that the stack can hold before it needs to grow, or -1 if the
+// stack hasn't been created yet.
+func (s *Stack) Capacity() int {
 	if s == nil {
-		return nil, errBadStack
+		return -1
 	}
 
-	nl, err := s.list.Copy()
-	if err != nil {
-		return nil, err
+	s.lock()
+	defer s.unlock()
+
+	return cap(s.items)
+}
+
+// Reserve makes sure that the stack has room for at least n more items, so that they can be added
+// without the stack needing to grow.
+func (s *Stack) Reserve(n int) error {
+	if s == nil {
+		return ErrBadStack
+	} else if n < 0 {
+		return ErrInvalidCount
+	}
+
+	s.lock()
+	defer s.unlock()
+
+	if cap(s.items)-len(s.items) >= n {
+		// We already have enough room.
+		return nil
 	}
 
+	items := make([]interface{}, len(s.items), len(s.items)+n)
+	copy(items, s.items)
+	s.items = items
+
+	return nil
+}
+
+// Shrink releases any memory that the stack isn't using for its current items.
+func (s *Stack) Shrink() error {
+	if s == nil {
+		return ErrBadStack
+	}
+
+	s.lock()
+	defer s.unlock()
+
+	if len(s.items) == 0 {
+		s.items = nil
+	} else if cap(s.items) > len(s.items) {
+		items := make([]interface{}, len(s.items))
+		copy(items, s.items)
+		s.items = items
+	}
+
+	return nil
+}
+
+// Copy makes an exact copy of the stack. If the stack is bounded, then the copy has the same bounds.
+func (s *Stack) Copy() (*Stack, error) {
+	if s == nil {
+		return nil, ErrBadStack
+	}
+
+	s.lock()
+	defer s.unlock()
+
 	ns := New()
-	ns.list = nl
+	ns.depth = s.depth
+	ns.policy = s.policy
+	if s.cond != nil {
+		ns.cond = sync.NewCond(new(sync.Mutex))
+	}
+	if len(s.items) > 0 {
+		ns.items = make([]interface{}, len(s.items))
+		copy(ns.items, s.items)
+	}
 
 	return ns, nil
 }
 
 // Merge adds a stack on top of the current stack. This will take ownership of and clear the
-// provided stack.
+// provided stack. If the current stack is bounded, then the items from the provided stack are added
+// as if with Add. If they would be rejected, then ErrFull is returned and the provided stack is left as
+// it was. Items added to the provided stack while the merge is in progress stay in it.
 func (s *Stack) Merge(ns *Stack) error {
 	if s == nil {
-		return errBadStack
+		return ErrBadStack
 	} else if ns == nil || ns.Count() == 0 {
 		// Nothing to add.
 		return nil
@@ -103,25 +278,39 @@ func (s *Stack) Merge(ns *Stack) error {
 		ns = dup
 	}
 
-	// Merge the new list on top of the current list.
-	newList := ns.list
-	currList := s.list
-	if err := newList.Merge(currList); err != nil {
-		return err
+	// Take the new stack's items out of it so that we never hold both stacks' locks at once. Pushing
+	// might block, and anything added to the new stack in the meantime must stay there. The items are
+	// only taken out if the current stack will accept them, so that they never have to be put back
+	// into a new stack that might have filled up in the meantime.
+	ns.lock()
+	if s.rejects(len(ns.items)) {
+		ns.unlock()
+		return ErrFull
 	}
+	items := make([]interface{}, len(ns.items))
+	copy(items, ns.items)
+	ns.drop(len(items))
+	ns.unlock()
 
-	s.list = newList
+	// The bottom of the new stack goes directly on top of the current stack.
+	s.lock()
+	defer s.unlock()
 
-	return ns.Clear()
+	return s.push(items)
 }
 
-// Clear resets the stack to its initial state.
+// Clear removes all items from the stack. The stack keeps its capacity so that it can be reused
+// without growing again. To release the memory as well, call Shrink afterward.
 func (s *Stack) Clear() error {
 	if s == nil {
-		return fmt.Errorf("stack does not exist")
+		return ErrBadStack
 	}
 
-	*s = *(New())
+	s.lock()
+	defer s.unlock()
+
+	s.drop(len(s.items))
+
 	return nil
 }
 
@@ -131,7 +320,7 @@ func (s *Stack) Same(ns *Stack) bool {
 		return false
 	}
 
-	return s.list.Same(ns.list)
+	return s == ns
 }
 
 // String displays the stack's contents, from the top to the bottom, with the top item being at the
@@ -141,5 +330,77 @@ func (s *Stack) String() string {
 		return "<nil>"
 	}
 
-	return s.list.String()
+	s.lock()
+	defer s.unlock()
+
+	if len(s.items) == 0 {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for i := len(s.items) - 1; i >= 0; i-- {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", s.items[i]))
+	}
+
+	return builder.String()
+}
+
+// popN removes the top n items from the stack and returns them, with the top item first. The caller
+// must make sure that the stack has at least n items.
+func (s *Stack) popN(n int) []interface{} {
+	if n == 0 {
+		return nil
+	}
+
+	items := s.topN(n)
+	s.drop(n)
+
+	return items
+}
+
+// drop removes the top n items from the stack. The caller must make sure that the stack has at least
+// n items.
+func (s *Stack) drop(n int) {
+	// Drop the references so the items can be garbage collected.
+	bottom := len(s.items) - n
+	for i := bottom; i < len(s.items); i++ {
+		s.items[i] = nil
+	}
+	s.items = s.items[:bottom]
+
+	// Let any blocked callers know that there's room now.
+	if s.cond != nil && n > 0 {
+		s.cond.Broadcast()
+	}
+}
+
+// topN returns a new slice of the top n items, with the top item first.
+func (s *Stack) topN(n int) []interface{} {
+	if n == 0 {
+		return nil
+	}
+
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = s.items[len(s.items)-1-i]
+	}
+
+	return items
+}
+
+// lock locks the stack if it is safe for concurrent use. Otherwise, it does nothing.
+func (s *Stack) lock() {
+	if s.cond != nil {
+		s.cond.L.Lock()
+	}
+}
+
+// unlock unlocks the stack if it is safe for concurrent use. Otherwise, it does nothing.
+func (s *Stack) unlock() {
+	if s.cond != nil {
+		s.cond.L.Unlock()
+	}
 }
@@ -1,6 +1,7 @@
 package hstack_test
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -24,6 +25,31 @@ func TestBadPtr(t *testing.T) {
 		t.Log("\tReceived:", v)
 	}
 
+	// Test TryPop().
+	if v, ok := s.TryPop(); v != nil || ok {
+		t.Error("unexpectedly passed TryPop() test with bad pointer")
+	}
+
+	// Test PopN() and Drain().
+	if v := s.PopN(1); v != nil {
+		t.Error("unexpectedly passed PopN() test with bad pointer")
+	}
+	if v := s.Drain(); v != nil {
+		t.Error("unexpectedly passed Drain() test with bad pointer")
+	}
+
+	// Test Peek() and PeekN().
+	if _, err := s.Peek(); !errors.Is(err, hstack.ErrBadStack) {
+		t.Error("unexpectedly passed Peek() test with bad pointer")
+		t.Log("\tExpected:", hstack.ErrBadStack)
+		t.Log("\tReceived:", err)
+	}
+	if _, err := s.PeekN(1); !errors.Is(err, hstack.ErrBadStack) {
+		t.Error("unexpectedly passed PeekN() test with bad pointer")
+		t.Log("\tExpected:", hstack.ErrBadStack)
+		t.Log("\tReceived:", err)
+	}
+
 	// Test Count().
 	if n := s.Count(); n != -1 {
 		t.Error("unexpectedly passed Count() test with bad pointer")
@@ -42,10 +68,25 @@ func TestBadPtr(t *testing.T) {
 	}
 
 	// Test Clear().
-	if err := s.Clear(); err == nil {
+	if err := s.Clear(); !errors.Is(err, hstack.ErrBadStack) {
 		t.Error("unexpectedly passed Clear() test with bad pointer")
 	}
 
+	// Test Capacity().
+	if n := s.Capacity(); n != -1 {
+		t.Error("unexpectedly passed Capacity() test with bad pointer")
+	}
+
+	// Test Reserve().
+	if err := s.Reserve(10); err == nil {
+		t.Error("unexpectedly passed Reserve() test with bad pointer")
+	}
+
+	// Test Shrink().
+	if err := s.Shrink(); err == nil {
+		t.Error("unexpectedly passed Shrink() test with bad pointer")
+	}
+
 	// Test Same().
 	if ok := s.Same(hstack.New()); ok {
 		t.Error("unexpectedly passed Same() test with bad pointer")
@@ -125,7 +166,7 @@ func TestAdd(t *testing.T) {
 	checkCount(t, s, 9)
 
 	// Test adding stack to itself. This should fail.
-	if err := s.Add(s); err == nil {
+	if err := s.Add(s); !errors.Is(err, hstack.ErrSelfAdd) {
 		t.Error("should not be able to add a stack to itself")
 	}
 	checkString(t, s, "orange, apple, banana, <empty>, [1 2 3], 3, b, a, 3.1415, kangaroo, 5")
@@ -232,6 +273,126 @@ func TestPop(t *testing.T) {
 	checkCount(t, s, 0)
 }
 
+func TestTryPop(t *testing.T) {
+	s := hstack.New()
+
+	// An empty stack and a nil item should be told apart.
+	if v, ok := s.TryPop(); v != nil || ok {
+		t.Error("unexpectedly popped item from empty stack")
+	}
+	s.Add(nil, 5)
+	if v, ok := s.TryPop(); v != 5 || !ok {
+		t.Error("Incorrect item popped")
+		t.Log("\tExpected: 5 true")
+		t.Log("\tReceived:", v, ok)
+	}
+	if v, ok := s.TryPop(); v != nil || !ok {
+		t.Error("Did not pop nil item")
+		t.Log("\tExpected: <nil> true")
+		t.Log("\tReceived:", v, ok)
+	}
+	if _, ok := s.TryPop(); ok {
+		t.Error("unexpectedly popped item from empty stack")
+	}
+	checkCount(t, s, 0)
+}
+
+func TestPeek(t *testing.T) {
+	s := hstack.New()
+
+	if _, err := s.Peek(); !errors.Is(err, hstack.ErrEmpty) {
+		t.Error("unexpectedly passed Peek() test for empty stack")
+		t.Log("\tExpected:", hstack.ErrEmpty)
+		t.Log("\tReceived:", err)
+	}
+
+	s.Add("a", "b", "c")
+	if v, err := s.Peek(); v != "c" || err != nil {
+		t.Error("Incorrect top item")
+		t.Log("\tExpected: c <nil>")
+		t.Log("\tReceived:", v, err)
+	}
+
+	// Peeking should not change the stack.
+	checkString(t, s, "c, b, a")
+	checkCount(t, s, 3)
+
+	// Test PeekN().
+	if _, err := s.PeekN(-1); !errors.Is(err, hstack.ErrInvalidCount) {
+		t.Error("unexpectedly passed PeekN() test for negative count")
+		t.Log("\tExpected:", hstack.ErrInvalidCount)
+		t.Log("\tReceived:", err)
+	}
+	if _, err := s.PeekN(4); !errors.Is(err, hstack.ErrTooFew) {
+		t.Error("unexpectedly passed PeekN() test for too many items")
+		t.Log("\tExpected:", hstack.ErrTooFew)
+		t.Log("\tReceived:", err)
+	}
+	if v, err := s.PeekN(0); len(v) != 0 || err != nil {
+		t.Error("Incorrect result for PeekN(0)")
+		t.Log("\tReceived:", v, err)
+	}
+	v, err := s.PeekN(2)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(v, []interface{}{"c", "b"}) {
+		t.Error("Incorrect top items")
+		t.Log("\tExpected: [c b]")
+		t.Log("\tReceived:", v)
+	}
+	checkString(t, s, "c, b, a")
+	checkCount(t, s, 3)
+}
+
+func TestPopN(t *testing.T) {
+	s := hstack.New()
+
+	if v := s.PopN(3); v != nil {
+		t.Error("unexpectedly popped items from empty stack")
+	}
+
+	s.Add(1, 2, 3, 4, 5)
+	if v := s.PopN(0); v != nil {
+		t.Error("unexpectedly popped items for PopN(0)")
+	}
+	if v := s.PopN(-1); v != nil {
+		t.Error("unexpectedly popped items for negative count")
+	}
+
+	v := s.PopN(2)
+	if !reflect.DeepEqual(v, []interface{}{5, 4}) {
+		t.Error("Incorrect items popped")
+		t.Log("\tExpected: [5 4]")
+		t.Log("\tReceived:", v)
+	}
+	checkString(t, s, "3, 2, 1")
+	checkCount(t, s, 3)
+
+	// Asking for more items than the stack has should return all of them.
+	v = s.PopN(10)
+	if !reflect.DeepEqual(v, []interface{}{3, 2, 1}) {
+		t.Error("Incorrect items popped")
+		t.Log("\tExpected: [3 2 1]")
+		t.Log("\tReceived:", v)
+	}
+	checkString(t, s, "<empty>")
+	checkCount(t, s, 0)
+
+	// Test Drain().
+	s.Add("x", "y", "z")
+	v = s.Drain()
+	if !reflect.DeepEqual(v, []interface{}{"z", "y", "x"}) {
+		t.Error("Incorrect items drained")
+		t.Log("\tExpected: [z y x]")
+		t.Log("\tReceived:", v)
+	}
+	checkCount(t, s, 0)
+	if v := s.Drain(); v != nil {
+		t.Error("unexpectedly drained items from empty stack")
+	}
+}
+
 func TestCopy(t *testing.T) {
 	s := hstack.New()
 
@@ -357,6 +518,92 @@ func TestClear(t *testing.T) {
 	checkCount(t, s, 0)
 }
 
+func TestCapacity(t *testing.T) {
+	s := hstack.New()
+
+	if err := s.Reserve(-1); err == nil {
+		t.Error("unexpectedly passed Reserve() test with negative size")
+	}
+
+	// Reserve room and make sure that adding up to that many items doesn't grow the stack.
+	if err := s.Reserve(100); err != nil {
+		t.Error(err)
+	}
+	capacity := s.Capacity()
+	if capacity < 100 {
+		t.Error("Stack did not reserve enough room")
+		t.Log("\tExpected: at least 100")
+		t.Log("\tReceived:", capacity)
+	}
+	for i := 0; i < 100; i++ {
+		s.Add(i)
+	}
+	checkCount(t, s, 100)
+	if n := s.Capacity(); n != capacity {
+		t.Error("Stack grew after reserving room")
+		t.Log("\tExpected:", capacity)
+		t.Log("\tReceived:", n)
+	}
+
+	// Reserving room that we already have should do nothing.
+	s.Reserve(capacity - 100)
+	if n := s.Capacity(); n != capacity {
+		t.Error("Stack changed size after reserving existing room")
+		t.Log("\tExpected:", capacity)
+		t.Log("\tReceived:", n)
+	}
+
+	// Reserving more room should keep all of the items.
+	if err := s.Reserve(50); err != nil {
+		t.Error(err)
+	}
+	if n := s.Capacity(); n < 150 {
+		t.Error("Stack did not reserve enough room")
+		t.Log("\tExpected: at least 150")
+		t.Log("\tReceived:", n)
+	}
+	checkCount(t, s, 100)
+	if v := s.Pop(); v != 99 {
+		t.Error("Incorrect top item after reserving room")
+		t.Log("\tExpected: 99")
+		t.Log("\tReceived:", v)
+	}
+
+	// Shrinking should release everything past the current items.
+	for i := 0; i < 89; i++ {
+		s.Pop()
+	}
+	if err := s.Shrink(); err != nil {
+		t.Error(err)
+	}
+	if n := s.Capacity(); n != 10 {
+		t.Error("Stack did not shrink")
+		t.Log("\tExpected: 10")
+		t.Log("\tReceived:", n)
+	}
+	checkString(t, s, "9, 8, 7, 6, 5, 4, 3, 2, 1, 0")
+
+	// Clearing should keep the room, and shrinking an empty stack should release all of it.
+	s.Clear()
+	checkCount(t, s, 0)
+	if n := s.Capacity(); n != 10 {
+		t.Error("Stack lost its room after clearing")
+		t.Log("\tExpected: 10")
+		t.Log("\tReceived:", n)
+	}
+	s.Shrink()
+	if n := s.Capacity(); n != 0 {
+		t.Error("Empty stack did not shrink")
+		t.Log("\tExpected: 0")
+		t.Log("\tReceived:", n)
+	}
+	checkString(t, s, "<empty>")
+
+	// The stack should still work after shrinking.
+	s.Add("a", "b")
+	checkString(t, s, "b, a")
+}
+
 func TestSame(t *testing.T) {
 	s := hstack.New()
 
@@ -419,3 +666,16 @@ func checkCount(t *testing.T, s *hstack.Stack, want int) {
 		t.Log("\tReceived:", s.Count())
 	}
 }
+
+func BenchmarkAddPop(b *testing.B) {
+	b.ReportAllocs()
+	s := hstack.New()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 64; j++ {
+			s.Add(j)
+		}
+		for j := 0; j < 64; j++ {
+			s.Pop()
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package hstack
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrBadMinMaxStack is returned when trying to use an invalid min/max stack.
+	ErrBadMinMaxStack = fmt.Errorf("must create stack with NewMinMax() first")
+	// ErrMissingCompare is returned when creating a min/max stack without a comparison function.
+	ErrMissingCompare = fmt.Errorf("missing comparison callback")
+	// ErrMissingAggregate is returned when asking for an aggregate that was never set up.
+	ErrMissingAggregate = fmt.Errorf("missing aggregate callback")
+)
+
+// MinMaxStack is a stack that can report its smallest item, its largest item, and an aggregate of
+// all of its items in constant time. Each level of the stack remembers these values for everything
+// at or below it, so popping an item never requires searching the rest of the stack.
+type MinMaxStack struct {
+	levels    []mmLevel
+	less      func(left, right interface{}) bool
+	aggregate func(acc, item interface{}) interface{}
+}
+
+// mmLevel is an internal type for a single level of the min/max stack.
+type mmLevel struct {
+	item interface{}
+	min  interface{}
+	max  interface{}
+	agg  interface{}
+}
+
+// NewMinMax creates a new min/max stack. The comparison function less should return true only if
+// left is smaller than right.
+func NewMinMax(less func(left, right interface{}) bool) (*MinMaxStack, error) {
+	if less == nil {
+		return nil, ErrMissingCompare
+	}
+
+	s := new(MinMaxStack)
+	s.less = less
+
+	return s, nil
+}
+
+// SetAggregate sets the function used to build up an aggregate of the items in the stack, such as a
+// sum or greatest common divisor. The function takes the aggregate of the items below a level and the
+// item at the level, and returns the new aggregate. The aggregate of the bottom level is the bottom
+// item itself. Setting a new function recalculates the aggregate for all items already in the stack.
+func (s *MinMaxStack) SetAggregate(fn func(acc, item interface{}) interface{}) error {
+	if s == nil || s.less == nil {
+		return ErrBadMinMaxStack
+	} else if fn == nil {
+		return ErrMissingAggregate
+	}
+
+	s.aggregate = fn
+	for i := range s.levels {
+		if i == 0 {
+			s.levels[i].agg = s.levels[i].item
+		} else {
+			s.levels[i].agg = fn(s.levels[i-1].agg, s.levels[i].item)
+		}
+	}
+
+	return nil
+}
+
+// Add adds one or more new items to the top of the stack. Items are pushed in order, so the last
+// argument to Add will be the first item returned with Pop.
+func (s *MinMaxStack) Add(items ...interface{}) error {
+	if s == nil || s.less == nil {
+		return ErrBadMinMaxStack
+	}
+
+	for _, item := range items {
+		level := mmLevel{item: item, min: item, max: item, agg: item}
+		if n := len(s.levels); n > 0 {
+			below := s.levels[n-1]
+			if !s.less(item, below.min) {
+				level.min = below.min
+			}
+			if !s.less(below.max, item) {
+				level.max = below.max
+			}
+			if s.aggregate != nil {
+				level.agg = s.aggregate(below.agg, item)
+			}
+		}
+		s.levels = append(s.levels, level)
+	}
+
+	return nil
+}
+
+// Pop removes the top item from the stack and returns its value. This returns nil if the stack is
+// empty. To tell an empty stack apart from a nil item, use TryPop instead.
+func (s *MinMaxStack) Pop() interface{} {
+	item, _ := s.TryPop()
+
+	return item
+}
+
+// TryPop removes the top item from the stack and returns its value. The second return value is false
+// if the stack is empty or hasn't been created yet.
+func (s *MinMaxStack) TryPop() (interface{}, bool) {
+	if s == nil || len(s.levels) == 0 {
+		return nil, false
+	}
+
+	top := len(s.levels) - 1
+	item := s.levels[top].item
+
+	// Drop the references so the values can be garbage collected.
+	s.levels[top] = mmLevel{}
+	s.levels = s.levels[:top]
+
+	return item, true
+}
+
+// Peek returns the top item on the stack without removing it.
+func (s *MinMaxStack) Peek() (interface{}, error) {
+	top, err := s.top()
+	if err != nil {
+		return nil, err
+	}
+
+	return top.item, nil
+}
+
+// Min returns the smallest item in the stack. If several items are equally small, then the one
+// closest to the bottom is returned.
+func (s *MinMaxStack) Min() (interface{}, error) {
+	top, err := s.top()
+	if err != nil {
+		return nil, err
+	}
+
+	return top.min, nil
+}
+
+// Max returns the largest item in the stack. If several items are equally large, then the one
+// closest to the bottom is returned.
+func (s *MinMaxStack) Max() (interface{}, error) {
+	top, err := s.top()
+	if err != nil {
+		return nil, err
+	}
+
+	return top.max, nil
+}
+
+// Aggregate returns the aggregate of all items in the stack, as built by the function passed to
+// SetAggregate.
+func (s *MinMaxStack) Aggregate() (interface{}, error) {
+	top, err := s.top()
+	if err != nil {
+		return nil, err
+	} else if s.aggregate == nil {
+		return nil, ErrMissingAggregate
+	}
+
+	return top.agg, nil
+}
+
+// Count gets the current number of items in the stack.
+func (s *MinMaxStack) Count() int {
+	if s == nil {
+		return -1
+	}
+
+	return len(s.levels)
+}
+
+// Clear removes all items from the stack. The comparison and aggregate functions are kept.
+func (s *MinMaxStack) Clear() error {
+	if s == nil {
+		return ErrBadMinMaxStack
+	}
+
+	s.levels = nil
+
+	return nil
+}
+
+// String displays the stack's contents, from the top to the bottom, with the top item being at the
+// beginning of the string and the bottom item at the end.
+func (s *MinMaxStack) String() string {
+	if s == nil {
+		return "<nil>"
+	} else if len(s.levels) == 0 {
+		return "<empty>"
+	}
+
+	builder := new(strings.Builder)
+	for i := len(s.levels) - 1; i >= 0; i-- {
+		if builder.Len() > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v", s.levels[i].item))
+	}
+
+	return builder.String()
+}
+
+// top returns the top level of the stack.
+func (s *MinMaxStack) top() (mmLevel, error) {
+	if s == nil || s.less == nil {
+		return mmLevel{}, ErrBadMinMaxStack
+	} else if len(s.levels) == 0 {
+		return mmLevel{}, ErrEmpty
+	}
+
+	return s.levels[len(s.levels)-1], nil
+}
@@ -0,0 +1,310 @@
+package hstack_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/snhilde/dsa/data_structures/hstack"
+)
+
+func TestMinMaxBadPtr(t *testing.T) {
+	var s *hstack.MinMaxStack
+
+	// Test String().
+	if v := s.String(); v != "<nil>" {
+		t.Error("unexpectedly passed String() test with bad pointer")
+		t.Log("\tExpected: <nil>")
+		t.Log("\tReceived:", v)
+	}
+
+	// Test Count().
+	if n := s.Count(); n != -1 {
+		t.Error("unexpectedly passed Count() test with bad pointer")
+	}
+
+	// Test Add().
+	if err := s.Add(1); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Add() test with bad pointer")
+	}
+
+	// Test Pop() and TryPop().
+	if v := s.Pop(); v != nil {
+		t.Error("unexpectedly passed Pop() test with bad pointer")
+	}
+	if _, ok := s.TryPop(); ok {
+		t.Error("unexpectedly passed TryPop() test with bad pointer")
+	}
+
+	// Test Peek(), Min(), Max(), and Aggregate().
+	if _, err := s.Peek(); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Peek() test with bad pointer")
+	}
+	if _, err := s.Min(); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Min() test with bad pointer")
+	}
+	if _, err := s.Max(); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Max() test with bad pointer")
+	}
+	if _, err := s.Aggregate(); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Aggregate() test with bad pointer")
+	}
+
+	// Test SetAggregate().
+	if err := s.SetAggregate(sumInt); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed SetAggregate() test with bad pointer")
+	}
+
+	// Test Clear().
+	if err := s.Clear(); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Clear() test with bad pointer")
+	}
+}
+
+func TestMinMaxZeroValue(t *testing.T) {
+	// A stack that wasn't created with NewMinMax has no comparison function, so it can't be used.
+	var s hstack.MinMaxStack
+
+	if err := s.Add(1, 2); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Add() test with zero-value stack")
+		t.Log("\tExpected:", hstack.ErrBadMinMaxStack)
+		t.Log("\tReceived:", err)
+	}
+	if err := s.SetAggregate(sumInt); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed SetAggregate() test with zero-value stack")
+	}
+	if _, err := s.Min(); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Min() test with zero-value stack")
+	}
+	if _, err := s.Max(); !errors.Is(err, hstack.ErrBadMinMaxStack) {
+		t.Error("unexpectedly passed Max() test with zero-value stack")
+	}
+}
+
+func TestMinMaxBadArgs(t *testing.T) {
+	if _, err := hstack.NewMinMax(nil); !errors.Is(err, hstack.ErrMissingCompare) {
+		t.Error("unexpectedly passed NewMinMax() test with missing comparison callback")
+	}
+
+	s := newMinMax(t)
+	if err := s.SetAggregate(nil); !errors.Is(err, hstack.ErrMissingAggregate) {
+		t.Error("unexpectedly passed SetAggregate() test with missing aggregate callback")
+	}
+
+	// Everything should report an empty stack.
+	if _, err := s.Min(); !errors.Is(err, hstack.ErrEmpty) {
+		t.Error("unexpectedly passed Min() test for empty stack")
+	}
+	if _, err := s.Max(); !errors.Is(err, hstack.ErrEmpty) {
+		t.Error("unexpectedly passed Max() test for empty stack")
+	}
+	if _, err := s.Peek(); !errors.Is(err, hstack.ErrEmpty) {
+		t.Error("unexpectedly passed Peek() test for empty stack")
+	}
+
+	// Asking for an aggregate that was never set up should fail.
+	s.Add(1)
+	if _, err := s.Aggregate(); !errors.Is(err, hstack.ErrMissingAggregate) {
+		t.Error("unexpectedly passed Aggregate() test with missing aggregate callback")
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	s := newMinMax(t)
+
+	s.Add(5, 3, 8, 3, 1, 9)
+	checkMinMax(t, s, 1, 9)
+	if v := s.String(); v != "9, 1, 3, 8, 3, 5" {
+		t.Error("stack contents are incorrect")
+		t.Log("\tExpected: 9, 1, 3, 8, 3, 5")
+		t.Log("\tReceived:", v)
+	}
+
+	// Popping should bring back the earlier minimums and maximums.
+	s.Pop()
+	checkMinMax(t, s, 1, 8)
+	s.Pop()
+	checkMinMax(t, s, 3, 8)
+	s.Pop()
+	checkMinMax(t, s, 3, 8)
+	s.Pop()
+	checkMinMax(t, s, 3, 5)
+	if v, _ := s.Peek(); v != 3 {
+		t.Error("Incorrect top item")
+		t.Log("\tExpected: 3")
+		t.Log("\tReceived:", v)
+	}
+	s.Pop()
+	checkMinMax(t, s, 5, 5)
+	s.Pop()
+	if n := s.Count(); n != 0 {
+		t.Error("Incorrect count")
+		t.Log("\tExpected: 0")
+		t.Log("\tReceived:", n)
+	}
+}
+
+func TestMinMaxRandom(t *testing.T) {
+	// Compare against a plain search of a slice after every operation.
+	s := newMinMax(t)
+	var items []int
+
+	for i := 0; i < 2000; i++ {
+		if rand.Intn(3) == 0 && len(items) > 0 {
+			v := s.Pop()
+			if v != items[len(items)-1] {
+				t.Fatal("Popped incorrect item")
+			}
+			items = items[:len(items)-1]
+		} else {
+			v := rand.Intn(1000)
+			s.Add(v)
+			items = append(items, v)
+		}
+
+		if len(items) == 0 {
+			continue
+		}
+		min, max := items[0], items[0]
+		for _, v := range items {
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+		checkMinMax(t, s, min, max)
+	}
+}
+
+func TestMinMaxAggregate(t *testing.T) {
+	s := newMinMax(t)
+
+	// Setting the aggregate after adding items should calculate it for the existing items.
+	s.Add(12, 18)
+	if err := s.SetAggregate(sumInt); err != nil {
+		t.Error(err)
+	}
+	checkAggregate(t, s, 30)
+	s.Add(6)
+	checkAggregate(t, s, 36)
+	s.Pop()
+	checkAggregate(t, s, 30)
+
+	// Switch to the greatest common divisor.
+	if err := s.SetAggregate(gcdInt); err != nil {
+		t.Error(err)
+	}
+	checkAggregate(t, s, 6)
+	s.Add(8)
+	checkAggregate(t, s, 2)
+	s.Add(7)
+	checkAggregate(t, s, 1)
+	s.Pop()
+	s.Pop()
+	checkAggregate(t, s, 6)
+
+	// Clearing should keep the aggregate function.
+	s.Clear()
+	s.Add(9, 15)
+	checkAggregate(t, s, 3)
+}
+
+func TestMinMaxSlidingWindow(t *testing.T) {
+	// Build a queue out of two min/max stacks and use it to find the minimum and maximum of every
+	// window of 3 items.
+	items := []int{4, 2, 12, 3, 8, 1, 7, 7, 5}
+	wantMin := []int{2, 2, 3, 1, 1, 1, 5}
+	wantMax := []int{12, 12, 12, 8, 8, 7, 7}
+
+	in := newMinMax(t)
+	out := newMinMax(t)
+	for i, v := range items {
+		in.Add(v)
+		if i < 2 {
+			continue
+		}
+		if in.Count()+out.Count() > 3 {
+			if out.Count() == 0 {
+				for in.Count() > 0 {
+					out.Add(in.Pop())
+				}
+			}
+			out.Pop()
+		}
+
+		min, max := windowMinMax(in, out)
+		if min != wantMin[i-2] || max != wantMax[i-2] {
+			t.Error("Incorrect values for window", i-2)
+			t.Log("\tExpected:", wantMin[i-2], wantMax[i-2])
+			t.Log("\tReceived:", min, max)
+		}
+	}
+}
+
+func newMinMax(t *testing.T) *hstack.MinMaxStack {
+	s, err := hstack.NewMinMax(func(left, right interface{}) bool {
+		return left.(int) < right.(int)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func windowMinMax(in, out *hstack.MinMaxStack) (int, int) {
+	var min, max int
+	first := true
+	for _, s := range []*hstack.MinMaxStack{in, out} {
+		if s.Count() == 0 {
+			continue
+		}
+		lo, _ := s.Min()
+		hi, _ := s.Max()
+		if first || lo.(int) < min {
+			min = lo.(int)
+		}
+		if first || hi.(int) > max {
+			max = hi.(int)
+		}
+		first = false
+	}
+
+	return min, max
+}
+
+func sumInt(acc, item interface{}) interface{} {
+	return acc.(int) + item.(int)
+}
+
+func gcdInt(acc, item interface{}) interface{} {
+	a, b := acc.(int), item.(int)
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+func checkMinMax(t *testing.T, s *hstack.MinMaxStack, min, max int) {
+	if v, err := s.Min(); v != min || err != nil {
+		t.Error("Incorrect minimum")
+		t.Log("\tExpected:", min)
+		t.Log("\tReceived:", v, err)
+	}
+	if v, err := s.Max(); v != max || err != nil {
+		t.Error("Incorrect maximum")
+		t.Log("\tExpected:", max)
+		t.Log("\tReceived:", v, err)
+	}
+}
+
+func checkAggregate(t *testing.T, s *hstack.MinMaxStack, want int) {
+	if v, err := s.Aggregate(); v != want || err != nil {
+		t.Error("Incorrect aggregate")
+		t.Log("\tExpected:", want)
+		t.Log("\tReceived:", v, err)
+	}
+}
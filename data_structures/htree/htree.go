@@ -26,6 +26,11 @@ var (
 type Tree struct {
 	root  *tnode
 	count int
+	// path is reused to track the nodes traversed while adding and removing items, so that each
+	// operation doesn't need to allocate a new stack. subpath is reused the same way to track the
+	// nodes below a removed node that has two children.
+	path    *hstack.Stack
+	subpath *hstack.Stack
 }
 
 // New creates a new binary tree.
@@ -67,10 +72,13 @@ func (t *Tree) AddItems(items ...Item) error {
 		}
 
 		// Find the spot where we need to insert this node.
-		node, stack := t.root.findNode(item.GetIndex())
+		stack := t.pathStack()
+		node := t.root.findNode(item.GetIndex(), stack)
 		if node != nil {
-			// We found a matching index. We only need to update the node's value.
+			// We found a matching index. We only need to update the node's value. Let go of the
+			// path so that the tree doesn't hold on to the nodes.
 			node.item = item
+			stack.Clear()
 			continue
 		}
 
@@ -100,9 +108,12 @@ func (t *Tree) Remove(index int) {
 	}
 
 	// Find the node we want to remove.
-	node, stack := t.root.findNode(index)
+	stack := t.pathStack()
+	node := t.root.findNode(index, stack)
 	if node == nil {
-		// A node with the provided index does not exist in this tree.
+		// A node with the provided index does not exist in this tree. Let go of the path so that the
+		// tree doesn't hold on to the nodes.
+		stack.Clear()
 		return
 	}
 
@@ -111,7 +122,7 @@ func (t *Tree) Remove(index int) {
 		// The node we need to remove has two children. We need to find and promote the node with
 		// the next highest index. We'll start by finding the node we need to swap up and keeping
 		// track of the path to it for potential rebalance operations later.
-		substack := hstack.New()
+		substack := t.subpathStack()
 		for swap = node.right; swap.left != nil; swap = swap.left {
 			substack.Add(swap)
 		}
@@ -178,7 +189,7 @@ func (t *Tree) Item(index int) Item {
 		return Item{}
 	}
 
-	node, _ := t.root.findNode(index)
+	node := t.root.findNode(index, nil)
 	if node == nil {
 		return Item{}
 	}
@@ -733,19 +744,44 @@ func (i *Item) SetIndex(index int) error {
 	return nil
 }
 
-// findNode will iterate down a tree until it finds a matching index. If no matching index is found,
-// then this returns nil for the node. Additionally, it builds a stack of all the nodes traversed
-// along the way.
-func (n *tnode) findNode(index int) (*tnode, *hstack.Stack) {
-	stack := hstack.New()
+// pathStack returns the tree's stack for tracking traversed nodes, emptied and ready for use.
+func (t *Tree) pathStack() *hstack.Stack {
+	t.path = resetStack(t.path)
+
+	return t.path
+}
 
+// subpathStack returns the tree's stack for tracking the nodes below a removed node, emptied and ready
+// for use.
+func (t *Tree) subpathStack() *hstack.Stack {
+	t.subpath = resetStack(t.subpath)
+
+	return t.subpath
+}
+
+// resetStack empties the stack, or creates a new one if it doesn't exist yet.
+func resetStack(stack *hstack.Stack) *hstack.Stack {
+	if stack == nil {
+		return hstack.New()
+	}
+	stack.Clear()
+
+	return stack
+}
+
+// findNode will iterate down a tree until it finds a matching index. If no matching index is found,
+// then this returns nil for the node. Additionally, if stack is not nil, it adds all the nodes
+// traversed along the way to the stack.
+func (n *tnode) findNode(index int, stack *hstack.Stack) *tnode {
 	node := n
 	for node != nil {
 		if index == node.index() {
 			break
 		}
 
-		stack.Add(node)
+		if stack != nil {
+			stack.Add(node)
+		}
 		if index < node.index() {
 			node = node.left
 		} else {
@@ -753,7 +789,7 @@ func (n *tnode) findNode(index int) (*tnode, *hstack.Stack) {
 		}
 	}
 
-	return node, stack
+	return node
 }
 
 // rebalance calculates the balances of the nodes in the path and performs any necessary rotation
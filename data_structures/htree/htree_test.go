@@ -448,6 +448,48 @@ func TestRemove(t *testing.T) {
 	}
 }
 
+// Test that removing items reuses the tree's stacks and doesn't leave nodes behind in them.
+func TestRemoveReuse(t *testing.T) {
+	tr := New()
+	for i := 0; i < 63; i++ {
+		tr.Add(i, i)
+	}
+
+	// Removing the root, which has two children, and adding it back should only allocate the new
+	// node.
+	allocs := testing.AllocsPerRun(100, func() {
+		index := tr.root.index()
+		tr.Remove(index)
+		tr.Add(index, index)
+	})
+	if allocs > 1 {
+		t.Error("Removing a node with two children allocated")
+		t.Log("\tExpected: <= 1")
+		t.Log("\tReceived:", allocs)
+	}
+	if tr.root.left == nil || tr.root.right == nil {
+		t.Error("Root does not have two children")
+	}
+	testCount(t, tr, 63)
+	testHeightBalance(t, tr)
+
+	// Removing an index that isn't in the tree shouldn't leave the path behind.
+	tr.Remove(100)
+	if n := tr.path.Count(); n != 0 {
+		t.Error("Path still holds nodes after missed removal")
+		t.Log("\tExpected: 0")
+		t.Log("\tReceived:", n)
+	}
+
+	// Neither should replacing the value at an existing index.
+	tr.Add("x", 7)
+	if n := tr.path.Count(); n != 0 {
+		t.Error("Path still holds nodes after replacing an item")
+		t.Log("\tExpected: 0")
+		t.Log("\tReceived:", n)
+	}
+}
+
 func TestClear(t *testing.T) {
 	tr := New()
 